@@ -18,12 +18,17 @@ func Process(feed models.Feed) *Aggregate {
 	}
 }
 
+// sortByPublished orders items newest first. Items that carry a source
+// ranking keep that order instead, so ranked listings show their top entries.
 func sortByPublished(items []models.Item) {
 	if len(items) < 2 {
 		return
 	}
 
 	sort.SliceStable(items, func(i, j int) bool {
+		if items[i].Rank > 0 && items[j].Rank > 0 {
+			return items[i].Rank < items[j].Rank
+		}
 		return items[i].Published.After(items[j].Published)
 	})
 }
@@ -43,9 +48,10 @@ func (a *Aggregate) GroupBySource() map[string][]models.Item {
 	return grouped
 }
 
-// LimitPerSource limits the number of items per source to the given limit.
-func (a *Aggregate) LimitPerSource(limit int) *Aggregate {
-	if limit <= 0 {
+// LimitPerSource limits the number of items per source to the given limit,
+// or to the source's entry in limits when it has one.
+func (a *Aggregate) LimitPerSource(limit int, limits map[string]int) *Aggregate {
+	if limit <= 0 && len(limits) == 0 {
 		return a
 	}
 
@@ -60,8 +66,12 @@ func (a *Aggregate) LimitPerSource(limit int) *Aggregate {
 		seen[item.SourceName] = true
 
 		items := grouped[item.SourceName]
-		if len(items) > limit {
-			items = items[:limit]
+		sourceLimit := limit
+		if override, ok := limits[item.SourceName]; ok {
+			sourceLimit = override
+		}
+		if sourceLimit > 0 && len(items) > sourceLimit {
+			items = items[:sourceLimit]
 		}
 		filtered = append(filtered, items...)
 	}
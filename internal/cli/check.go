@@ -0,0 +1,234 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	neturl "net/url"
+	"os"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/dustin/go-humanize"
+
+	"github.com/ppowo/feedlet/internal/config"
+	"github.com/ppowo/feedlet/internal/fetcher"
+	"github.com/ppowo/feedlet/internal/models"
+	"github.com/ppowo/feedlet/internal/source"
+	"github.com/ppowo/feedlet/internal/source/fetcherr"
+	"github.com/ppowo/feedlet/internal/source/httpclient"
+)
+
+func init() {
+	register(command{name: "check", summary: "Validate the config and probe every source once", run: runCheck})
+}
+
+// checkReport is the JSON output of feedlet check.
+type checkReport struct {
+	CheckedAt    time.Time     `json:"checked_at"`
+	OK           bool          `json:"ok"`
+	ConfigErrors []string      `json:"config_errors"`
+	Sources      []sourceProbe `json:"sources"`
+}
+
+// sourceProbe is the outcome of fetching one source.
+type sourceProbe struct {
+	Name          string    `json:"name"`
+	Type          string    `json:"type"`
+	Host          string    `json:"host,omitempty"`
+	OK            bool      `json:"ok"`
+	Items         int       `json:"items"`
+	Newest        time.Time `json:"newest,omitzero"`
+	NewestAgeSecs int64     `json:"newest_age_seconds,omitempty"`
+	LatencyMillis int64     `json:"latency_ms"`
+	ErrorClass    string    `json:"error_class,omitempty"`
+	HTTPStatus    int       `json:"http_status,omitempty"`
+	Error         string    `json:"error,omitempty"`
+}
+
+func runCheck(args []string) int {
+	fs := flag.NewFlagSet("check", flag.ContinueOnError)
+	jsonOutput := fs.Bool("json", false, "print the report as JSON")
+	configOnly := fs.Bool("config-only", false, "only validate the config, without fetching")
+	timeout := fs.Duration("timeout", 30*time.Second, "fetch timeout per source")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: feedlet check [flags] [source name...]")
+		fmt.Fprintln(fs.Output(), "\nValidates the config, then fetches every source (or the named ones) once and")
+		fmt.Fprintln(fs.Output(), "reports its health. Sources on the same host are fetched one after another,")
+		fmt.Fprintln(fs.Output(), "spaced as the server would. Exits with 1 if the config is invalid or any")
+		fmt.Fprintln(fs.Output(), "source fails.")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	report := checkReport{CheckedAt: time.Now(), ConfigErrors: []string{}, Sources: []sourceProbe{}}
+	cfg := config.GetConfig()
+	if err := config.Validate(cfg); err != nil {
+		report.ConfigErrors = joinedErrors(err)
+	} else if err := httpclient.Configure(cfg.HTTPProfiles); err != nil {
+		report.ConfigErrors = []string{err.Error()}
+	}
+
+	if len(report.ConfigErrors) == 0 && !*configOnly {
+		configs, err := selectSources(cfg, fs.Args(), fs.NArg() == 0)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 2
+		}
+		report.Sources = probeSources(configs, *timeout)
+	}
+
+	report.OK = len(report.ConfigErrors) == 0
+	for _, probe := range report.Sources {
+		report.OK = report.OK && probe.OK
+	}
+
+	if *jsonOutput {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(report); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+	} else {
+		printCheckReport(cfg, report, *configOnly)
+	}
+
+	if !report.OK {
+		return 1
+	}
+	return 0
+}
+
+// joinedErrors splits an errors.Join result into its messages.
+func joinedErrors(err error) []string {
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		messages := make([]string, 0, len(joined.Unwrap()))
+		for _, e := range joined.Unwrap() {
+			messages = append(messages, e.Error())
+		}
+		return messages
+	}
+	return []string{err.Error()}
+}
+
+// probeSources fetches each source once. Hosts are probed in parallel; the
+// sources of one host run in config order, at least the host spacing of
+// their type apart.
+func probeSources(configs []models.SourceConfig, timeout time.Duration) []sourceProbe {
+	probes := make([]sourceProbe, len(configs))
+	byHost := make(map[string][]int)
+	var hosts []string
+	for i, cfg := range configs {
+		host := ""
+		if parsed, err := neturl.Parse(cfg.URL); err == nil {
+			host = strings.ToLower(parsed.Hostname())
+		}
+		if _, ok := byHost[host]; !ok {
+			hosts = append(hosts, host)
+		}
+		byHost[host] = append(byHost[host], i)
+	}
+
+	var wg sync.WaitGroup
+	for _, host := range hosts {
+		wg.Add(1)
+		go func(host string, indexes []int) {
+			defer wg.Done()
+			var last time.Time
+			for _, i := range indexes {
+				spacing := time.Duration(0)
+				if info, ok := source.Lookup(configs[i].Type); ok && host != "" {
+					spacing = info.Policy.HostSpacing
+				}
+				if wait := time.Until(last.Add(spacing)); !last.IsZero() && wait > 0 {
+					time.Sleep(wait)
+				}
+				last = time.Now()
+				probes[i] = probeSource(configs[i], host, timeout)
+			}
+		}(host, byHost[host])
+	}
+	wg.Wait()
+	return probes
+}
+
+func probeSource(cfg models.SourceConfig, host string, timeout time.Duration) sourceProbe {
+	probe := sourceProbe{Name: cfg.Name, Type: cfg.Type, Host: host}
+
+	src, err := source.New(cfg)
+	if err != nil {
+		probe.ErrorClass = string(fetcherr.Unknown)
+		probe.Error = err.Error()
+		return probe
+	}
+
+	ctx, cancel := context.WithTimeout(source.RequestContext(context.Background(), cfg.Options), timeout)
+	defer cancel()
+
+	start := time.Now()
+	items, err := src.Fetch(ctx)
+	probe.LatencyMillis = time.Since(start).Milliseconds()
+	if err != nil {
+		info := fetcherr.Classify(err)
+		probe.ErrorClass = string(info.Class)
+		probe.HTTPStatus = info.StatusCode
+		probe.Error = err.Error()
+		return probe
+	}
+
+	items = fetcher.FilterItems(cfg, items)
+	probe.OK = true
+	probe.Items = len(items)
+	for _, item := range items {
+		if item.Published.After(probe.Newest) {
+			probe.Newest = item.Published
+		}
+	}
+	if !probe.Newest.IsZero() {
+		probe.NewestAgeSecs = int64(time.Since(probe.Newest).Seconds())
+	}
+	return probe
+}
+
+func printCheckReport(cfg *models.Config, report checkReport, configOnly bool) {
+	if len(report.ConfigErrors) > 0 {
+		fmt.Printf("Config is invalid (%d problems):\n", len(report.ConfigErrors))
+		for _, message := range report.ConfigErrors {
+			fmt.Printf("  %s\n", message)
+		}
+		return
+	}
+	fmt.Printf("Config OK: %d sources, %d HTTP profiles\n", len(cfg.Sources), len(cfg.HTTPProfiles))
+	if configOnly {
+		return
+	}
+	fmt.Println()
+
+	failed := 0
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "STATUS\tSOURCE\tTYPE\tITEMS\tNEWEST\tLATENCY\tERROR")
+	for _, probe := range report.Sources {
+		status, newest, problem := "ok", "-", "-"
+		if !probe.Newest.IsZero() {
+			newest = humanize.Time(probe.Newest)
+		}
+		if !probe.OK {
+			failed++
+			status = "FAIL"
+			problem = fmt.Sprintf("%s: %s", fetcherr.Class(probe.ErrorClass).Label(), truncate(probe.Error, 80))
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%d\t%s\t%s\t%s\n", status, probe.Name, probe.Type, probe.Items, newest,
+			(time.Duration(probe.LatencyMillis) * time.Millisecond).String(), problem)
+	}
+	tw.Flush()
+
+	if failed > 0 {
+		fmt.Printf("\n%d of %d sources failed\n", failed, len(report.Sources))
+	}
+}
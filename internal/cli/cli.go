@@ -0,0 +1,113 @@
+// Package cli implements feedlet's subcommands. Without one feedlet runs the
+// server.
+package cli
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/ppowo/feedlet/internal/config"
+	"github.com/ppowo/feedlet/internal/models"
+	"github.com/ppowo/feedlet/internal/source/httpclient"
+)
+
+type command struct {
+	name    string
+	summary string
+	run     func(args []string) int
+}
+
+// commands is filled by the init functions of the command files.
+var commands []command
+
+func register(cmd command) {
+	commands = append(commands, cmd)
+}
+
+// Run runs the subcommand named by args[0] with the remaining arguments and
+// returns the process exit code.
+func Run(args []string) int {
+	if len(args) == 0 || args[0] == "help" || args[0] == "-h" || args[0] == "--help" {
+		usage()
+		return 0
+	}
+	for _, cmd := range commands {
+		if cmd.name == args[0] {
+			return cmd.run(args[1:])
+		}
+	}
+	fmt.Fprintf(os.Stderr, "feedlet: unknown command %q\n\n", args[0])
+	usage()
+	return 2
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "Usage: feedlet [command] [arguments]")
+	fmt.Fprintln(os.Stderr)
+	fmt.Fprintln(os.Stderr, "Without a command feedlet runs the server. Commands:")
+	fmt.Fprintln(os.Stderr)
+	for _, cmd := range commands {
+		fmt.Fprintf(os.Stderr, "  %-10s %s\n", cmd.name, cmd.summary)
+	}
+	fmt.Fprintln(os.Stderr)
+	fmt.Fprintln(os.Stderr, `Run "feedlet <command> -h" for the command's options.`)
+}
+
+// loadConfig returns the validated embedded configuration with its HTTP
+// profiles installed.
+func loadConfig() (*models.Config, error) {
+	cfg := config.GetConfig()
+	if err := config.Validate(cfg); err != nil {
+		return nil, fmt.Errorf("invalid configuration:\n%w", err)
+	}
+	if err := httpclient.Configure(cfg.HTTPProfiles); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// selectSources returns the configured sources with the given names, or all
+// of them if all is set.
+func selectSources(cfg *models.Config, names []string, all bool) ([]models.SourceConfig, error) {
+	if all {
+		return cfg.Sources, nil
+	}
+	if len(names) == 0 {
+		return nil, fmt.Errorf("no source given")
+	}
+
+	selected := make([]models.SourceConfig, 0, len(names))
+	for _, name := range names {
+		found := false
+		for _, sourceCfg := range cfg.Sources {
+			if sourceCfg.Name == name {
+				selected = append(selected, sourceCfg)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("no source named %q", name)
+		}
+	}
+	return selected, nil
+}
+
+// slug turns a source name into a directory name: "r/programming" becomes
+// "r-programming".
+func slug(name string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(name) {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9':
+			b.WriteRune(r)
+			dash = false
+		case !dash && b.Len() > 0:
+			b.WriteByte('-')
+			dash = true
+		}
+	}
+	return strings.TrimSuffix(b.String(), "-")
+}
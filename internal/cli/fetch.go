@@ -0,0 +1,364 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net/http"
+	neturl "net/url"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/dustin/go-humanize"
+
+	"github.com/ppowo/feedlet/internal/config"
+	"github.com/ppowo/feedlet/internal/fetcher"
+	"github.com/ppowo/feedlet/internal/models"
+	"github.com/ppowo/feedlet/internal/source"
+	"github.com/ppowo/feedlet/internal/source/fetcherr"
+	"github.com/ppowo/feedlet/internal/source/httpclient"
+)
+
+func init() {
+	register(command{name: "fetch", summary: "Fetch one source and print its items", run: runFetch})
+}
+
+// listFlag collects the values of a repeated flag.
+type listFlag []string
+
+func (l *listFlag) String() string {
+	return strings.Join(*l, ", ")
+}
+
+func (l *listFlag) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
+
+func runFetch(args []string) int {
+	fs := flag.NewFlagSet("fetch", flag.ContinueOnError)
+	format := fs.String("format", "table", "output format: table, json or jsonfeed")
+	verbose := fs.Bool("verbose", false, "print requests, timing and parse diagnostics to stderr")
+	sourceType := fs.String("type", "", "type of an ad hoc source, instead of a configured one")
+	sourceURL := fs.String("url", "", "URL of the ad hoc source")
+	timeout := fs.Duration("timeout", 30*time.Second, "fetch timeout")
+	var options, headers listFlag
+	fs.Var(&options, "option", "option of the ad hoc source as key=value (repeatable)")
+	fs.Var(&headers, "header", "extra request header as 'Name: value' (repeatable)")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: feedlet fetch [flags] <source name>")
+		fmt.Fprintln(fs.Output(), "       feedlet fetch [flags] -type <type> -url <url> [-option key=value...]")
+		fmt.Fprintln(fs.Output(), "\nFetches a source once with its configured settings and prints the items.")
+		fmt.Fprintln(fs.Output(), "Exits with 1 if the fetch fails.")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if *format != "table" && *format != "json" && *format != "jsonfeed" {
+		fmt.Fprintf(os.Stderr, "unknown format %q\n", *format)
+		return 2
+	}
+
+	var usageErr string
+	switch {
+	case *sourceType == "" && (*sourceURL != "" || len(options) > 0):
+		usageErr = "-url and -option need -type"
+	case *sourceType == "" && fs.NArg() != 1:
+		usageErr = "give one source name, or -type and -url"
+	case *sourceType != "" && fs.NArg() > 0:
+		usageErr = "give a source name or -type, not both"
+	}
+	if usageErr != "" {
+		fmt.Fprintln(os.Stderr, usageErr)
+		fs.Usage()
+		return 2
+	}
+
+	sourceCfg, err := fetchConfig(fs.Arg(0), *sourceType, *sourceURL, options, headers)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	src, err := source.New(sourceCfg)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	if *verbose {
+		fmt.Fprintf(os.Stderr, "Fetching %s (%s) %s\n", sourceCfg.Name, sourceCfg.Type, sourceCfg.URL)
+		if httpSrc, ok := src.(source.HTTPSource); ok {
+			httpSrc.SetHTTPClient(httpclient.NewClient(traceTransport{next: httpclient.Transport()}))
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(source.RequestContext(context.Background(), sourceCfg.Options), *timeout)
+	defer cancel()
+
+	start := time.Now()
+	items, err := src.Fetch(ctx)
+	elapsed := time.Since(start)
+
+	if *verbose {
+		if reporter, ok := src.(source.StatsReporter); ok {
+			printExtractStats(reporter.ExtractStats())
+		}
+	}
+	if err != nil {
+		info := fetcherr.Classify(err)
+		fmt.Fprintf(os.Stderr, "%s: %s: %v\n", sourceCfg.Name, info.Class.Label(), err)
+		return 1
+	}
+
+	fetched := len(items)
+	items = fetcher.FilterItems(sourceCfg, items)
+	if *verbose {
+		fmt.Fprintf(os.Stderr, "Fetched %d items in %s", fetched, elapsed.Round(time.Millisecond))
+		if len(items) != fetched {
+			fmt.Fprintf(os.Stderr, ", %d kept by limit and min_score", len(items))
+		}
+		fmt.Fprintln(os.Stderr)
+	}
+
+	switch *format {
+	case "json":
+		err = printJSON(items)
+	case "jsonfeed":
+		err = printJSONFeed(sourceCfg, items)
+	default:
+		err = printItemTable(items)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	return 0
+}
+
+// fetchConfig returns the configured source called name, or an ad hoc one
+// described by the flags if sourceType is set.
+func fetchConfig(name, sourceType, sourceURL string, options, headers listFlag) (models.SourceConfig, error) {
+	if sourceType == "" {
+		cfg, err := loadConfig()
+		if err != nil {
+			return models.SourceConfig{}, err
+		}
+		configs, err := selectSources(cfg, []string{name}, false)
+		if err != nil {
+			return models.SourceConfig{}, err
+		}
+		sourceCfg := configs[0]
+		sourceCfg.Options = withHeaders(sourceCfg.Options, headers)
+		return sourceCfg, nil
+	}
+
+	// Ad hoc sources may still use the configured HTTP profiles.
+	if err := httpclient.Configure(config.GetConfig().HTTPProfiles); err != nil {
+		return models.SourceConfig{}, err
+	}
+
+	sourceCfg := models.SourceConfig{Name: sourceURL, Type: sourceType, URL: sourceURL, Options: models.Options{}}
+	if parsed, err := neturl.Parse(sourceURL); err == nil && parsed.Host != "" {
+		sourceCfg.Name = parsed.Host + parsed.Path
+	}
+	for _, option := range options {
+		key, value, ok := strings.Cut(option, "=")
+		if !ok || key == "" {
+			return models.SourceConfig{}, fmt.Errorf("option %q is not key=value", option)
+		}
+		sourceCfg.Options[key] = value
+	}
+	sourceCfg.Options = withHeaders(sourceCfg.Options, headers)
+
+	if err := source.Validate(sourceCfg); err != nil {
+		return models.SourceConfig{}, err
+	}
+	return sourceCfg, nil
+}
+
+// withHeaders adds headers given as "Name: value" to the headers option.
+func withHeaders(options models.Options, headers listFlag) models.Options {
+	if len(headers) == 0 {
+		return options
+	}
+
+	merged := make(models.Options, len(options)+1)
+	for key, value := range options {
+		merged[key] = value
+	}
+	extra := options.StringMap("headers")
+	if extra == nil {
+		extra = make(map[string]string)
+	}
+	for _, header := range headers {
+		name, value, _ := strings.Cut(header, ":")
+		extra[strings.TrimSpace(name)] = strings.TrimSpace(value)
+	}
+	merged["headers"] = extra
+	return merged
+}
+
+// traceTransport prints every request and its outcome to stderr.
+type traceTransport struct {
+	next http.RoundTripper
+}
+
+func (t traceTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	resp, err := t.next.RoundTrip(req)
+	elapsed := time.Since(start).Round(time.Millisecond)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "  %s %s: %v (%s)\n", req.Method, req.URL, err, elapsed)
+		return nil, err
+	}
+
+	detail := []string{elapsed.String()}
+	if contentType := resp.Header.Get("Content-Type"); contentType != "" {
+		detail = append(detail, contentType)
+	}
+	if resp.ContentLength >= 0 {
+		detail = append(detail, humanize.Bytes(uint64(resp.ContentLength)))
+	}
+	fmt.Fprintf(os.Stderr, "  %s %s: %s (%s)\n", req.Method, req.URL, resp.Status, strings.Join(detail, ", "))
+	return resp, nil
+}
+
+func printExtractStats(stats models.ExtractStats) {
+	if stats.Pages == 0 {
+		return
+	}
+	fmt.Fprintf(os.Stderr, "Extracted %d of %d rows from %d pages (%.0f%%)",
+		stats.RowsParsed, stats.RowsSeen, stats.Pages, stats.ParseRatio()*100)
+	if len(stats.MissingFields) > 0 {
+		fields := make([]string, 0, len(stats.MissingFields))
+		for field, n := range stats.MissingFields {
+			fields = append(fields, fmt.Sprintf("%s=%d", field, n))
+		}
+		sort.Strings(fields)
+		fmt.Fprintf(os.Stderr, ", skipped for missing %s", strings.Join(fields, ", "))
+	}
+	fmt.Fprintln(os.Stderr)
+}
+
+func printItemTable(items []models.Item) error {
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "#\tPUBLISHED\tSCORE\tCOMMENTS\tTITLE\tLINK")
+	for i, item := range items {
+		published := "-"
+		if !item.Published.IsZero() {
+			published = humanize.Time(item.Published)
+		}
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t%s\t%s\n", i+1, published,
+			countOrDash(item.Score), countOrDash(item.Comments), truncate(item.Title, 70), item.Link)
+	}
+	return tw.Flush()
+}
+
+func countOrDash(n int) string {
+	if n == 0 {
+		return "-"
+	}
+	return humanize.Comma(int64(n))
+}
+
+func truncate(s string, n int) string {
+	runes := []rune(strings.Join(strings.Fields(s), " "))
+	if len(runes) <= n {
+		return string(runes)
+	}
+	return string(runes[:n-1]) + "…"
+}
+
+func printJSON(items []models.Item) error {
+	data, err := marshalItems(items)
+	if err != nil {
+		return err
+	}
+	_, err = os.Stdout.Write(data)
+	return err
+}
+
+// jsonFeed is a JSON Feed 1.1 document, https://www.jsonfeed.org/version/1.1/.
+type jsonFeed struct {
+	Version     string         `json:"version"`
+	Title       string         `json:"title"`
+	HomePageURL string         `json:"home_page_url,omitempty"`
+	FeedURL     string         `json:"feed_url,omitempty"`
+	Items       []jsonFeedItem `json:"items"`
+}
+
+type jsonFeedItem struct {
+	ID            string               `json:"id"`
+	URL           string               `json:"url,omitempty"`
+	Title         string               `json:"title,omitempty"`
+	ContentHTML   string               `json:"content_html,omitempty"`
+	Summary       string               `json:"summary,omitempty"`
+	Image         string               `json:"image,omitempty"`
+	DatePublished string               `json:"date_published,omitempty"`
+	Authors       []jsonFeedAuthor     `json:"authors,omitempty"`
+	Tags          []string             `json:"tags,omitempty"`
+	Attachments   []jsonFeedAttachment `json:"attachments,omitempty"`
+}
+
+type jsonFeedAuthor struct {
+	Name string `json:"name"`
+}
+
+type jsonFeedAttachment struct {
+	URL               string `json:"url"`
+	MIMEType          string `json:"mime_type"`
+	SizeInBytes       int64  `json:"size_in_bytes,omitempty"`
+	DurationInSeconds int64  `json:"duration_in_seconds,omitempty"`
+}
+
+func printJSONFeed(cfg models.SourceConfig, items []models.Item) error {
+	feed := jsonFeed{
+		Version:     "https://jsonfeed.org/version/1.1",
+		Title:       cfg.Name,
+		HomePageURL: cfg.HomeURL,
+		FeedURL:     cfg.URL,
+		Items:       make([]jsonFeedItem, 0, len(items)),
+	}
+	for i, item := range items {
+		entry := jsonFeedItem{
+			ID:          item.Link,
+			URL:         item.Link,
+			Title:       item.Title,
+			ContentHTML: item.Content,
+			Image:       item.Thumbnail,
+			Tags:        item.Tags,
+		}
+		if item.Description != item.Content {
+			entry.Summary = item.Description
+		}
+		if entry.ID == "" {
+			entry.ID = fmt.Sprintf("%s#%d", cfg.Name, i+1)
+		}
+		if !item.Published.IsZero() {
+			entry.DatePublished = item.Published.Format(time.RFC3339)
+		}
+		if item.Author != "" {
+			entry.Authors = []jsonFeedAuthor{{Name: item.Author}}
+		}
+		for _, attachment := range item.Attachments {
+			entry.Attachments = append(entry.Attachments, jsonFeedAttachment{
+				URL:               attachment.URL,
+				MIMEType:          attachment.MIMEType,
+				SizeInBytes:       attachment.Length,
+				DurationInSeconds: int64(attachment.Duration.Seconds()),
+			})
+		}
+		feed.Items = append(feed.Items, entry)
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	return enc.Encode(feed)
+}
@@ -0,0 +1,230 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/ppowo/feedlet/internal/models"
+	"github.com/ppowo/feedlet/internal/source"
+	"github.com/ppowo/feedlet/internal/source/httpclient"
+)
+
+const (
+	defaultFixtureDir = "internal/source/testdata"
+	recordTimeout     = 2 * time.Minute
+	cassetteFile      = "http.json"
+	goldenFile        = "items.json"
+)
+
+func init() {
+	register(command{name: "record", summary: "Record HTTP fixtures and golden items of sources", run: runRecord})
+	register(command{name: "replay", summary: "Check sources against their recorded fixtures offline", run: runReplay})
+}
+
+func runRecord(args []string) int {
+	fs := flag.NewFlagSet("record", flag.ContinueOnError)
+	dir := fs.String("dir", defaultFixtureDir, "fixture directory")
+	all := fs.Bool("all", false, "record every configured HTTP source")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: feedlet record [-dir dir] [-all] <source name>...")
+		fmt.Fprintln(fs.Output(), "\nFetches the sources live and saves their HTTP traffic and items as fixtures.")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	configs, err := selectSources(cfg, fs.Args(), *all)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		fs.Usage()
+		return 2
+	}
+
+	code := 0
+	for _, sourceCfg := range configs {
+		if err := record(sourceCfg, *dir); err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", sourceCfg.Name, err)
+			code = 1
+		}
+	}
+	return code
+}
+
+func record(cfg models.SourceConfig, dir string) error {
+	src, err := source.New(cfg)
+	if err != nil {
+		return err
+	}
+	httpSrc, ok := src.(source.HTTPSource)
+	if !ok {
+		return fmt.Errorf("%s sources do not fetch over HTTP", cfg.Type)
+	}
+
+	recorder := httpclient.NewRecorder(httpclient.Transport())
+	httpSrc.SetHTTPClient(httpclient.NewClient(recorder))
+
+	ctx, cancel := context.WithTimeout(source.RequestContext(context.Background(), cfg.Options), recordTimeout)
+	defer cancel()
+
+	items, err := src.Fetch(ctx)
+	if err != nil {
+		return fmt.Errorf("fetch failed, fixtures not written: %w", err)
+	}
+
+	fixtureDir := filepath.Join(dir, slug(cfg.Name))
+	if err := recorder.Save(filepath.Join(fixtureDir, cassetteFile)); err != nil {
+		return err
+	}
+	if err := writeGolden(filepath.Join(fixtureDir, goldenFile), items); err != nil {
+		return err
+	}
+
+	fmt.Printf("%s: recorded %d requests and %d items in %s\n", cfg.Name, recorder.Len(), len(items), fixtureDir)
+	return nil
+}
+
+func runReplay(args []string) int {
+	fs := flag.NewFlagSet("replay", flag.ContinueOnError)
+	dir := fs.String("dir", defaultFixtureDir, "fixture directory")
+	all := fs.Bool("all", false, "replay every configured source that has fixtures")
+	update := fs.Bool("update", false, "rewrite the golden items from the replayed fetch")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: feedlet replay [-dir dir] [-all] [-update] <source name>...")
+		fmt.Fprintln(fs.Output(), "\nFetches the sources from their recorded fixtures and compares the items")
+		fmt.Fprintln(fs.Output(), "with the golden file. Exits with 1 if any differ.")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	configs, err := selectSources(cfg, fs.Args(), *all)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		fs.Usage()
+		return 2
+	}
+
+	code := 0
+	for _, sourceCfg := range configs {
+		fixtureDir := filepath.Join(*dir, slug(sourceCfg.Name))
+		if *all {
+			if _, err := os.Stat(filepath.Join(fixtureDir, cassetteFile)); errors.Is(err, os.ErrNotExist) {
+				continue
+			}
+		}
+
+		if err := replay(sourceCfg, fixtureDir, *update); err != nil {
+			fmt.Printf("FAIL %s: %v\n", sourceCfg.Name, err)
+			code = 1
+			continue
+		}
+		fmt.Printf("ok   %s\n", sourceCfg.Name)
+	}
+	return code
+}
+
+func replay(cfg models.SourceConfig, fixtureDir string, update bool) error {
+	cassette, err := httpclient.LoadCassette(filepath.Join(fixtureDir, cassetteFile))
+	if err != nil {
+		return err
+	}
+
+	src, err := source.New(cfg)
+	if err != nil {
+		return err
+	}
+	httpSrc, ok := src.(source.HTTPSource)
+	if !ok {
+		return fmt.Errorf("%s sources do not fetch over HTTP", cfg.Type)
+	}
+	client := httpclient.NewClient(httpclient.NewReplayer(cassette))
+	client.RetryMax = 0
+	httpSrc.SetHTTPClient(client)
+
+	items, err := src.Fetch(context.Background())
+	if err != nil {
+		return fmt.Errorf("fetch failed: %w", err)
+	}
+
+	goldenPath := filepath.Join(fixtureDir, goldenFile)
+	if update {
+		return writeGolden(goldenPath, items)
+	}
+
+	want, err := os.ReadFile(goldenPath)
+	if err != nil {
+		return err
+	}
+	got, err := marshalItems(items)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(got, want) {
+		return fmt.Errorf("items differ from %s\n%s", goldenPath, firstDifference(want, got))
+	}
+	return nil
+}
+
+func marshalItems(items []models.Item) ([]byte, error) {
+	if items == nil {
+		items = []models.Item{}
+	}
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(items); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func writeGolden(path string, items []models.Item) error {
+	data, err := marshalItems(items)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}
+
+// firstDifference describes the first line at which got differs from want.
+func firstDifference(want, got []byte) string {
+	wantLines := strings.Split(string(want), "\n")
+	gotLines := strings.Split(string(got), "\n")
+	for i := 0; i < len(wantLines) || i < len(gotLines); i++ {
+		var w, g string
+		if i < len(wantLines) {
+			w = wantLines[i]
+		}
+		if i < len(gotLines) {
+			g = gotLines[i]
+		}
+		if w != g {
+			return fmt.Sprintf("  line %d:\n    want: %s\n    got:  %s", i+1, strings.TrimSpace(w), strings.TrimSpace(g))
+		}
+	}
+	return ""
+}
@@ -0,0 +1,115 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/ppowo/feedlet/internal/fetcher"
+	"github.com/ppowo/feedlet/internal/source/fetcherr"
+)
+
+func init() {
+	register(command{name: "simulate", summary: "Replay the fetch schedule of the configured sources on a simulated clock", run: runSimulate})
+}
+
+func runSimulate(args []string) int {
+	fs := flag.NewFlagSet("simulate", flag.ContinueOnError)
+	duration := fs.Duration("duration", 24*time.Hour, "simulated time to run for")
+	seed := fs.Int64("seed", 1, "seed for scheduling jitter and failures")
+	failureRate := fs.Float64("failure-rate", 0.05, "chance that a healthy source starts failing on a fetch")
+	outage := fs.Float64("outage", 0.6, "chance that a failing source fails again on its next fetch")
+	verbose := fs.Bool("v", false, "print the scheduler's log")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: feedlet simulate [flags] [source name...]")
+		fmt.Fprintln(fs.Output(), "\nRuns the scheduler against stand-in sources on a fake clock, without making")
+		fmt.Fprintln(fs.Output(), "requests, and reports when each source was fetched.")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	configs, err := selectSources(cfg, fs.Args(), fs.NArg() == 0)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+
+	handler := slog.DiscardHandler
+	if *verbose {
+		handler = slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelDebug})
+	}
+	slog.SetDefault(slog.New(handler))
+
+	start := time.Now()
+	report := fetcher.Simulate(configs, fetcher.SimOptions{
+		Duration:         *duration,
+		Seed:             *seed,
+		FailureRate:      *failureRate,
+		OutageLength:     *outage,
+		MinFetchInterval: time.Duration(cfg.MinFetchInterval) * time.Second,
+	})
+	elapsed := time.Since(start)
+
+	fmt.Printf("Simulated %s (seed %d, %d timer steps) in %s\n\n", report.Duration, report.Seed, report.Steps, elapsed.Round(time.Millisecond))
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "SOURCE\tTYPE\tFETCHES\tFAILED\tMAX RUN\tFIRST\tMIN GAP\tMEAN GAP\tMAX GAP\tERRORS")
+	for _, s := range report.Sources {
+		fmt.Fprintf(tw, "%s\t%s\t%d\t%d\t%d\t%s\t%s\t%s\t%s\t%s\n",
+			s.Name, s.Type, s.Fetches, s.Failures, s.MaxFailures,
+			s.FirstFetch.Round(time.Second), roundDuration(s.MinGap), roundDuration(s.MeanGap), roundDuration(s.MaxGap),
+			errorCounts(s.Errors))
+	}
+	tw.Flush()
+
+	fmt.Println()
+	tw = tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "HOST\tFETCHES\tSPACING\tMIN SEEN")
+	violations := 0
+	for _, h := range report.Hosts {
+		mark := ""
+		if h.Fetches > 1 && h.MinSpacing < h.Spacing {
+			mark = "  < spacing"
+			violations++
+		}
+		fmt.Fprintf(tw, "%s\t%d\t%s\t%s%s\n", h.Host, h.Fetches, roundDuration(h.Spacing), roundDuration(h.MinSpacing), mark)
+	}
+	tw.Flush()
+
+	if violations > 0 {
+		fmt.Printf("\n%d hosts were fetched closer together than their spacing\n", violations)
+		return 1
+	}
+	return 0
+}
+
+func roundDuration(d time.Duration) string {
+	if d == 0 {
+		return "-"
+	}
+	return d.Round(time.Second).String()
+}
+
+func errorCounts(counts map[fetcherr.Class]int) string {
+	if len(counts) == 0 {
+		return "-"
+	}
+	parts := make([]string, 0, len(counts))
+	for class, n := range counts {
+		parts = append(parts, fmt.Sprintf("%s=%d", class, n))
+	}
+	sort.Strings(parts)
+	return strings.Join(parts, ",")
+}
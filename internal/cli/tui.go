@@ -0,0 +1,63 @@
+package cli
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/ppowo/feedlet/internal/fetcher"
+	"github.com/ppowo/feedlet/internal/tui"
+)
+
+func init() {
+	register(command{name: "tui", summary: "Show the dashboard in the terminal", run: runTUI})
+}
+
+func runTUI(args []string) int {
+	fs := flag.NewFlagSet("tui", flag.ContinueOnError)
+	attach := fs.String("attach", "", "URL of a running feedlet to show, e.g. http://localhost:3737")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: feedlet tui [-attach url]")
+		fmt.Fprintln(fs.Output(), "\nShows the dashboard in the terminal. Without -attach it runs its own fetcher.")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	var backend tui.Backend
+	if *attach != "" {
+		backend = tui.NewRemote(*attach)
+	} else {
+		cfg, err := loadConfig()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+
+		// The fetcher's log would scribble over the screen.
+		slog.SetDefault(slog.New(slog.DiscardHandler))
+
+		f := fetcher.NewFromConfigs(cfg.Sources, cfg.MinFetchInterval, cfg.MaxSubscribers)
+		fetchCtx, cancel := context.WithCancel(ctx)
+		defer func() {
+			cancel()
+			f.Shutdown()
+		}()
+		f.Start(fetchCtx)
+		backend = tui.NewLocal(f, cfg.Sources)
+	}
+
+	if err := tui.Run(ctx, backend); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	return 0
+}
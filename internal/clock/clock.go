@@ -0,0 +1,176 @@
+// Package clock abstracts time so that scheduling code can run against
+// simulated time. Real is the wall clock; Fake only moves when told to.
+package clock
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// Clock tells the time and makes timers.
+type Clock interface {
+	Now() time.Time
+	NewTimer(d time.Duration) Timer
+}
+
+// Timer is the part of time.Timer that schedulers use.
+type Timer interface {
+	C() <-chan time.Time
+	Stop() bool
+}
+
+// Real returns the wall clock.
+func Real() Clock {
+	return realClock{}
+}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+func (realClock) NewTimer(d time.Duration) Timer {
+	return realTimer{time.NewTimer(d)}
+}
+
+type realTimer struct {
+	*time.Timer
+}
+
+func (t realTimer) C() <-chan time.Time {
+	return t.Timer.C
+}
+
+// Fake is a simulated clock. Its timers fire only when the clock is moved
+// with Step or Advance, even those that are already due, and always one at a
+// time in deadline order, ties going to the timer created first. Together
+// with WaitPending this lets a driver run goroutines that sleep on the clock
+// one after another, in the same order on every run.
+type Fake struct {
+	mu      sync.Mutex
+	cond    *sync.Cond
+	now     time.Time
+	seq     uint64
+	pending []*fakeTimer
+}
+
+// NewFake returns a simulated clock set to start.
+func NewFake(start time.Time) *Fake {
+	f := &Fake{now: start}
+	f.cond = sync.NewCond(&f.mu)
+	return f
+}
+
+type fakeTimer struct {
+	clock *Fake
+	when  time.Time
+	seq   uint64
+	c     chan time.Time
+}
+
+func (f *Fake) Now() time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.now
+}
+
+func (f *Fake) NewTimer(d time.Duration) Timer {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.seq++
+	t := &fakeTimer{clock: f, when: f.now.Add(max(d, 0)), seq: f.seq, c: make(chan time.Time, 1)}
+	f.pending = append(f.pending, t)
+	sort.Slice(f.pending, func(i, j int) bool {
+		a, b := f.pending[i], f.pending[j]
+		if !a.when.Equal(b.when) {
+			return a.when.Before(b.when)
+		}
+		return a.seq < b.seq
+	})
+	f.cond.Broadcast()
+	return t
+}
+
+func (t *fakeTimer) C() <-chan time.Time {
+	return t.c
+}
+
+func (t *fakeTimer) Stop() bool {
+	f := t.clock
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for i, pending := range f.pending {
+		if pending == t {
+			f.pending = append(f.pending[:i], f.pending[i+1:]...)
+			f.cond.Broadcast()
+			return true
+		}
+	}
+	return false
+}
+
+// Pending returns the number of timers that have not fired or been stopped.
+func (f *Fake) Pending() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.pending)
+}
+
+// WaitPending blocks until at least n timers are pending, i.e. until n
+// goroutines are asleep on the clock.
+func (f *Fake) WaitPending(n int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for len(f.pending) < n {
+		f.cond.Wait()
+	}
+}
+
+// Next returns the deadline of the next timer to fire.
+func (f *Fake) Next() (time.Time, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if len(f.pending) == 0 {
+		return time.Time{}, false
+	}
+	return f.pending[0].when, true
+}
+
+// Step fires the next timer, moving the clock forward to its deadline if it
+// lies in the future. It reports false if no timer is pending.
+func (f *Fake) Step() bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if len(f.pending) == 0 {
+		return false
+	}
+	f.fireLocked()
+	return true
+}
+
+// Advance moves the clock forward by d, firing the timers that fall due on
+// the way in order.
+func (f *Fake) Advance(d time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	end := f.now.Add(d)
+	for len(f.pending) > 0 && !f.pending[0].when.After(end) {
+		f.fireLocked()
+	}
+	f.now = end
+}
+
+func (f *Fake) fireLocked() {
+	t := f.pending[0]
+	f.pending = f.pending[1:]
+	if t.when.After(f.now) {
+		f.now = t.when
+	}
+	t.c <- f.now
+	f.cond.Broadcast()
+}
@@ -11,6 +11,25 @@ func GetConfig() *models.Config {
 		Port:               3737,
 		MinFetchInterval:   5, // Default 5 second minimum between fetches per source
 		MaxSubscribers:     1000,
+		PublicBaseURL:      "", // e.g. "https://feedlet.example.com" to receive WebSub pushes
+		HTTPProfiles: map[string]models.HTTPProfile{
+			// Wikimedia asks automated clients to identify themselves
+			"wikipedia": {
+				UserAgent: "feedlet/1.0 (https://github.com/ppowo/feedlet)",
+			},
+		},
+		Health: models.HealthConfig{
+			StaleAfter:   3, // Missed intervals before a source is stale
+			FailingAfter: 3, // Consecutive failures before a source is failing
+			// MaxFailing unset: unhealthy when more than half the sources are failing
+		},
+		Logging: models.LoggingConfig{
+			Level:      "info", // debug, info, warn or error; add the debug option to a source to debug just that one
+			Format:     "text", // text or json
+			MaxSize:    10,     // Megabytes per log file
+			MaxBackups: 3,
+			MaxAge:     3, // Days
+		},
 		Sources: []models.SourceConfig{
 			{
 				Name:           "r/Italia Career Advice",
@@ -79,7 +98,7 @@ func GetConfig() *models.Config {
 			{
 				Name:           "DesuArchive /g/ /ptg/",
 				Type:           "desuarchive",
-				URL:            "g",
+				Options:        models.Options{"board": "g"},
 				HomeURL:        "",
 				Interval:       1800,
 				IntervalJitter: 120,
@@ -92,6 +111,7 @@ func GetConfig() *models.Config {
 				HomeURL:        source.CurrentMeltzerWikiHomeURL(),
 				Interval:       3600,
 				IntervalJitter: 300,
+				Options:        models.Options{"http_profile": "wikipedia"},
 			},
 			{
 				Name:           "r/technology",
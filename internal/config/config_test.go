@@ -0,0 +1,26 @@
+package config
+
+import "testing"
+
+func TestDefaultConfigIsValid(t *testing.T) {
+	if err := Validate(GetConfig()); err != nil {
+		t.Fatalf("embedded config is invalid:\n%v", err)
+	}
+}
+
+func TestValidateMaxFailing(t *testing.T) {
+	for _, share := range []float64{0, 1} {
+		cfg := GetConfig()
+		cfg.Health.MaxFailing = &share
+		if err := Validate(cfg); err != nil {
+			t.Errorf("max_failing %v: %v", share, err)
+		}
+	}
+	for _, share := range []float64{-0.1, 1.5} {
+		cfg := GetConfig()
+		cfg.Health.MaxFailing = &share
+		if err := Validate(cfg); err == nil {
+			t.Errorf("max_failing %v accepted", share)
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+
+	"github.com/ppowo/feedlet/internal/logging"
+	"github.com/ppowo/feedlet/internal/models"
+	"github.com/ppowo/feedlet/internal/source"
+)
+
+// Validate checks the configuration against the registered source types and
+// returns all problems found, joined.
+func Validate(cfg *models.Config) error {
+	var errs []error
+
+	if cfg.Port < 0 || cfg.Port > 65535 {
+		errs = append(errs, fmt.Errorf("port %d is out of range", cfg.Port))
+	}
+	if cfg.PublicBaseURL != "" {
+		if u, err := url.Parse(cfg.PublicBaseURL); err != nil || u.Scheme == "" || u.Host == "" {
+			errs = append(errs, fmt.Errorf("public_base_url %q is not an absolute URL", cfg.PublicBaseURL))
+		}
+	}
+
+	if cfg.Health.StaleAfter < 0 || cfg.Health.FailingAfter < 0 {
+		errs = append(errs, errors.New("health: stale_after and failing_after must not be negative"))
+	}
+	if share := cfg.Health.MaxFailing; share != nil && (*share < 0 || *share > 1) {
+		errs = append(errs, fmt.Errorf("health: max_failing %v is not between 0 and 1", *share))
+	}
+
+	if _, err := logging.NewHandler(io.Discard, cfg.Logging, nil); err != nil {
+		errs = append(errs, fmt.Errorf("logging: %w", err))
+	}
+	if cfg.Logging.MaxSize < 0 || cfg.Logging.MaxBackups < 0 || cfg.Logging.MaxAge < 0 {
+		errs = append(errs, errors.New("logging: max_size, max_backups and max_age must not be negative"))
+	}
+
+	for name, profile := range cfg.HTTPProfiles {
+		if profile.Proxy != "" {
+			if u, err := url.Parse(profile.Proxy); err != nil || u.Host == "" {
+				errs = append(errs, fmt.Errorf("http profile %q: invalid proxy URL %q", name, profile.Proxy))
+			}
+		}
+		if profile.Timeout < 0 || profile.ConnectTimeout < 0 || profile.MaxBodyBytes < 0 {
+			errs = append(errs, fmt.Errorf("http profile %q: timeouts and max_body_bytes must not be negative", name))
+		}
+	}
+
+	names := make(map[string]bool, len(cfg.Sources))
+	for i, sc := range cfg.Sources {
+		if names[sc.Name] {
+			errs = append(errs, fmt.Errorf("source %d: duplicate name %q", i, sc.Name))
+		}
+		names[sc.Name] = true
+
+		if sc.Interval < 0 || sc.IntervalJitter < 0 {
+			errs = append(errs, fmt.Errorf("source %q: interval and interval_jitter must not be negative", sc.Name))
+		}
+		if profile := sc.Options.String("http_profile", ""); profile != "" {
+			if _, ok := cfg.HTTPProfiles[profile]; !ok {
+				errs = append(errs, fmt.Errorf("source %q: unknown http profile %q", sc.Name, profile))
+			}
+		}
+		if err := source.Validate(sc); err != nil {
+			errs = append(errs, fmt.Errorf("source %q: %w", sc.Name, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,165 @@
+package fetcher
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/ppowo/feedlet/internal/models"
+	"github.com/ppowo/feedlet/internal/source/fetcherr"
+)
+
+const (
+	// extractHistorySize is how many healthy scrapes a source's baseline
+	// is computed from.
+	extractHistorySize = 20
+
+	// extractMinHistory is how many healthy scrapes are needed before
+	// drops versus the baseline are flagged.
+	extractMinHistory = 3
+
+	// degradedFactor flags a scrape whose parse ratio or row count falls
+	// below this fraction of the baseline.
+	degradedFactor = 0.5
+
+	// extractRebaseAfter is how many degraded scrapes in a row, steady among
+	// themselves, replace the baseline. A lasting drop such as fewer pages
+	// or a split table is then taken as the source's new normal.
+	extractRebaseAfter = 5
+)
+
+// extractBaseline is what a source's scrapes are judged against.
+type extractBaseline struct {
+	healthy []models.ExtractStats // Recent scrapes that were not degraded
+	drops   []models.ExtractStats // The current run of degraded scrapes
+}
+
+// recordExtract stores the extraction stats of a scrape and flags the source
+// as degraded when they drop sharply versus its baseline.
+func (f *Fetcher) recordExtract(sc sourceWithConfig, stats models.ExtractStats, fetchErr error) {
+	if stats.Pages == 0 {
+		return
+	}
+	// A fetch that failed for other reasons may have stopped half way.
+	if class := fetcherr.Classify(fetchErr).Class; fetchErr != nil && class != fetcherr.SchemaDrift && class != fetcherr.Empty {
+		return
+	}
+
+	name := sc.source.Name()
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	baseline, degraded, reason, rebased := f.extractHistory[name].add(stats)
+	f.extractHistory[name] = baseline
+
+	state := f.ensureSourceStateLocked(sc)
+	switch {
+	case rebased:
+		sc.log.Info("Extraction steady at a new level, baseline reset")
+	case degraded && !state.Degraded:
+		sc.log.Warn("Extraction degraded", "reason", reason)
+	case !degraded && state.Degraded:
+		sc.log.Info("Extraction recovered")
+	}
+	state.Extract = &stats
+	state.Degraded = degraded
+	state.DegradedReason = reason
+	f.feed.SourceStates[name] = state
+}
+
+// add judges a scrape against the baseline and returns the updated
+// baseline. Healthy scrapes join the history. Degraded ones are kept apart
+// until extractRebaseAfter of them in a row agree with each other, at which
+// point they become the history and rebased is true.
+func (b extractBaseline) add(stats models.ExtractStats) (next extractBaseline, degraded bool, reason string, rebased bool) {
+	degraded, reason = extractDegraded(stats, b.healthy)
+	if !degraded {
+		return extractBaseline{healthy: appendHistory(b.healthy, stats)}, false, "", false
+	}
+
+	drops := append(append([]models.ExtractStats(nil), b.drops...), stats)
+	if len(drops) > extractRebaseAfter {
+		drops = drops[len(drops)-extractRebaseAfter:]
+	}
+	if len(drops) == extractRebaseAfter && extractSteady(drops) {
+		return extractBaseline{healthy: drops}, false, "", true
+	}
+	return extractBaseline{healthy: b.healthy, drops: drops}, true, reason, false
+}
+
+// extractSteady reports whether the scrapes all parse something and agree
+// with each other, none of them falling below degradedFactor of another in
+// row count or parse ratio.
+func extractSteady(scrapes []models.ExtractStats) bool {
+	lowRows, highRows := math.Inf(1), 0.0
+	lowRatio, highRatio := math.Inf(1), 0.0
+	for _, stats := range scrapes {
+		if stats.RowsSeen > 0 && stats.RowsParsed == 0 {
+			return false
+		}
+		rows, ratio := float64(stats.RowsSeen), stats.ParseRatio()
+		lowRows, highRows = min(lowRows, rows), max(highRows, rows)
+		lowRatio, highRatio = min(lowRatio, ratio), max(highRatio, ratio)
+	}
+	return lowRows >= highRows*degradedFactor && lowRatio >= highRatio*degradedFactor
+}
+
+func appendHistory(history []models.ExtractStats, stats models.ExtractStats) []models.ExtractStats {
+	history = append(append([]models.ExtractStats(nil), history...), stats)
+	if len(history) > extractHistorySize {
+		history = history[len(history)-extractHistorySize:]
+	}
+	return history
+}
+
+// extractDegraded compares a scrape with the source's healthy history.
+func extractDegraded(stats models.ExtractStats, history []models.ExtractStats) (bool, string) {
+	if stats.RowsSeen > 0 && stats.RowsParsed == 0 {
+		return true, fmt.Sprintf("none of %d rows could be parsed%s", stats.RowsSeen, missingSummary(stats))
+	}
+	if len(history) < extractMinHistory {
+		return false, ""
+	}
+
+	ratios := make([]float64, len(history))
+	rows := make([]float64, len(history))
+	for i, past := range history {
+		ratios[i] = past.ParseRatio()
+		rows[i] = float64(past.RowsSeen)
+	}
+	usualRatio := median(ratios)
+	usualRows := median(rows)
+
+	if ratio := stats.ParseRatio(); ratio < usualRatio*degradedFactor {
+		return true, fmt.Sprintf("parsed %.0f%% of rows, usually %.0f%%%s", ratio*100, usualRatio*100, missingSummary(stats))
+	}
+	if float64(stats.RowsSeen) < usualRows*degradedFactor {
+		return true, fmt.Sprintf("found %d rows, usually about %.0f", stats.RowsSeen, usualRows)
+	}
+	return false, ""
+}
+
+// missingSummary names the field that caused the most skipped rows.
+func missingSummary(stats models.ExtractStats) string {
+	field, count := "", 0
+	for name, n := range stats.MissingFields {
+		if n > count || (n == count && name < field) {
+			field, count = name, n
+		}
+	}
+	if count == 0 {
+		return ""
+	}
+	return fmt.Sprintf(" (%d missing %s)", count, field)
+}
+
+func median(values []float64) float64 {
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	mid := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[mid-1] + sorted[mid]) / 2
+	}
+	return sorted[mid]
+}
@@ -0,0 +1,186 @@
+package fetcher
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ppowo/feedlet/internal/clock"
+	"github.com/ppowo/feedlet/internal/models"
+	"github.com/ppowo/feedlet/internal/source/fetcherr"
+)
+
+func scrape(seen, parsed int) models.ExtractStats {
+	return models.ExtractStats{Pages: 1, RowsSeen: seen, RowsParsed: parsed}
+}
+
+func scrapes(n, seen, parsed int) []models.ExtractStats {
+	history := make([]models.ExtractStats, n)
+	for i := range history {
+		history[i] = scrape(seen, parsed)
+	}
+	return history
+}
+
+func TestMedian(t *testing.T) {
+	tests := []struct {
+		values []float64
+		want   float64
+	}{
+		{[]float64{3}, 3},
+		{[]float64{5, 1, 3}, 3},
+		{[]float64{4, 1, 3, 2}, 2.5},
+		{[]float64{1, 1, 100}, 1},
+	}
+	for _, tt := range tests {
+		if got := median(tt.values); got != tt.want {
+			t.Errorf("median(%v) = %v, want %v", tt.values, got, tt.want)
+		}
+	}
+}
+
+func TestExtractDegraded(t *testing.T) {
+	missingDate := scrape(40, 0)
+	missingDate.MissingFields = map[string]int{"date": 40, "title": 2}
+
+	tests := []struct {
+		name       string
+		stats      models.ExtractStats
+		history    []models.ExtractStats
+		want       bool
+		wantReason string
+	}{
+		{"zero parsed without history", missingDate, nil, true, "none of 40 rows could be parsed (40 missing date)"},
+		{"zero parsed with history", scrape(40, 0), scrapes(5, 40, 40), true, "none of 40 rows could be parsed"},
+		{"too little history", scrape(40, 10), scrapes(2, 40, 40), false, ""},
+		{"steady", scrape(41, 39), scrapes(5, 40, 40), false, ""},
+		{"ratio drop", scrape(40, 15), scrapes(5, 40, 40), true, "parsed 38% of rows, usually 100%"},
+		{"row drop", scrape(15, 15), scrapes(5, 40, 40), true, "found 15 rows, usually about 40"},
+		{"half is not yet a drop", scrape(20, 20), scrapes(5, 40, 40), false, ""},
+		{"no rows seen", scrape(0, 0), scrapes(5, 40, 40), true, "found 0 rows, usually about 40"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, reason := extractDegraded(tt.stats, tt.history)
+			if got != tt.want || reason != tt.wantReason {
+				t.Errorf("extractDegraded = %v, %q; want %v, %q", got, reason, tt.want, tt.wantReason)
+			}
+		})
+	}
+}
+
+func TestExtractBaseline(t *testing.T) {
+	type step struct {
+		stats    models.ExtractStats
+		degraded bool
+		rebased  bool
+	}
+	healthy := []step{{scrape(40, 40), false, false}, {scrape(40, 40), false, false}, {scrape(40, 40), false, false}}
+	tests := []struct {
+		name  string
+		steps []step
+	}{
+		{
+			name: "recovery",
+			steps: append(append([]step(nil), healthy...),
+				step{scrape(40, 10), true, false},
+				step{scrape(40, 10), true, false},
+				step{scrape(40, 40), false, false},
+			),
+		},
+		{
+			name: "lasting drop becomes the baseline",
+			steps: append(append([]step(nil), healthy...),
+				step{scrape(15, 15), true, false},
+				step{scrape(15, 15), true, false},
+				step{scrape(16, 16), true, false},
+				step{scrape(15, 15), true, false},
+				step{scrape(14, 14), false, true},
+				step{scrape(15, 15), false, false},
+				step{scrape(40, 40), false, false},
+			),
+		},
+		{
+			name: "unsteady drops keep the old baseline",
+			steps: append(append([]step(nil), healthy...),
+				step{scrape(15, 15), true, false},
+				step{scrape(2, 2), true, false},
+				step{scrape(15, 15), true, false},
+				step{scrape(2, 2), true, false},
+				step{scrape(15, 15), true, false},
+				step{scrape(2, 2), true, false},
+			),
+		},
+		{
+			name: "parsing nothing never becomes the baseline",
+			steps: append(append([]step(nil), healthy...),
+				step{scrape(40, 0), true, false},
+				step{scrape(40, 0), true, false},
+				step{scrape(40, 0), true, false},
+				step{scrape(40, 0), true, false},
+				step{scrape(40, 0), true, false},
+				step{scrape(40, 0), true, false},
+			),
+		},
+		{
+			name: "a healthy scrape ends the run of drops",
+			steps: append(append([]step(nil), healthy...),
+				step{scrape(15, 15), true, false},
+				step{scrape(15, 15), true, false},
+				step{scrape(15, 15), true, false},
+				step{scrape(15, 15), true, false},
+				step{scrape(40, 40), false, false},
+				step{scrape(15, 15), true, false},
+			),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var b extractBaseline
+			for i, s := range tt.steps {
+				var degraded, rebased bool
+				b, degraded, _, rebased = b.add(s.stats)
+				if degraded != s.degraded || rebased != s.rebased {
+					t.Fatalf("scrape %d (%d/%d rows): degraded %v, rebased %v; want %v, %v",
+						i+1, s.stats.RowsParsed, s.stats.RowsSeen, degraded, rebased, s.degraded, s.rebased)
+				}
+			}
+		})
+	}
+}
+
+func TestExtractHistoryIsBounded(t *testing.T) {
+	var b extractBaseline
+	for range extractHistorySize + 5 {
+		b, _, _, _ = b.add(scrape(40, 40))
+	}
+	if len(b.healthy) != extractHistorySize {
+		t.Errorf("history holds %d scrapes, want %d", len(b.healthy), extractHistorySize)
+	}
+}
+
+func TestRecordExtract(t *testing.T) {
+	clk := clock.NewFake(testEpoch)
+	sc := stubConfig(&stubSource{name: "s", clock: clk}, time.Hour, 0)
+	f := newTestFetcher(clk, sc)
+
+	for range 3 {
+		f.recordExtract(sc, scrape(40, 40), nil)
+	}
+	f.recordExtract(sc, scrape(40, 0), fetcherr.New(fetcherr.SchemaDrift, "no rows parsed"))
+	state := f.sourceState("s")
+	if !state.Degraded || !strings.HasPrefix(state.DegradedReason, "none of 40 rows") || state.Extract.RowsParsed != 0 {
+		t.Fatalf("after a broken scrape: %+v", state)
+	}
+
+	// A network failure says nothing about the markup
+	f.recordExtract(sc, scrape(3, 3), fetcherr.New(fetcherr.Network, "connection reset"))
+	if state := f.sourceState("s"); !state.Degraded || state.Extract.RowsSeen != 40 {
+		t.Errorf("failed fetch recorded: %+v", state)
+	}
+
+	f.recordExtract(sc, scrape(40, 40), nil)
+	if state := f.sourceState("s"); state.Degraded || state.DegradedReason != "" {
+		t.Errorf("after recovery: %+v", state)
+	}
+}
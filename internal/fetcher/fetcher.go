@@ -2,27 +2,33 @@ package fetcher
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"log"
+	"log/slog"
 	"maps"
 	"math/rand"
 	"net/url"
+	"sort"
 	"strings"
 	"sync"
 	"time"
 
 	"golang.org/x/time/rate"
 
+	"github.com/ppowo/feedlet/internal/clock"
 	"github.com/ppowo/feedlet/internal/models"
 	"github.com/ppowo/feedlet/internal/source"
+	"github.com/ppowo/feedlet/internal/source/fetcherr"
+	"github.com/ppowo/feedlet/internal/source/httpclient"
 )
 
 const (
-	defaultFetchTimeout        = 30 * time.Second
-	defaultRedditHostSpacing   = 3 * time.Second
-	defaultRedditStartupJitter = 10 * time.Second
-	defaultRedditBackoffCap    = 2 * time.Hour
-	defaultSourceInterval      = 30 * time.Minute
+	defaultFetchTimeout   = source.FetchTimeout
+	defaultSourceInterval = 30 * time.Minute
+
+	// throttledBackoffCap is the least a rate limited or blocked source backs
+	// off to, also for types without a backoff policy of their own.
+	throttledBackoffCap = time.Hour
 )
 
 type Fetcher struct {
@@ -41,14 +47,26 @@ type Fetcher struct {
 	closed         bool
 	closedMu       sync.Mutex
 	subOnce        map[chan struct{}]*sync.Once
+	clock          clock.Clock
 	rng            *rand.Rand
 	rngMu          sync.Mutex
+	push           PushSubscriber
+	extractHistory map[string]extractBaseline // Guarded by mu
+}
+
+// PushSubscriber keeps WebSub subscriptions for sources whose feeds advertise
+// a hub. While Active reports true for a source, polling is skipped.
+type PushSubscriber interface {
+	Ensure(ctx context.Context, name, hub, topic string) error
+	Active(name string) bool
 }
 
 // Config holds configuration for the fetcher.
 type Config struct {
 	MaxSubscribers   int
 	MinFetchInterval time.Duration
+	Clock            clock.Clock // Wall clock if nil
+	Seed             int64       // Seeds stagger and jitter; random if zero
 }
 
 type sourceWithConfig struct {
@@ -56,9 +74,13 @@ type sourceWithConfig struct {
 	interval          time.Duration
 	intervalJitter    time.Duration
 	host              string
-	isReddit          bool
+	hostSpacing       time.Duration
 	startupStaggerMax time.Duration
 	failureBackoffCap time.Duration
+	limit             int
+	minScore          int
+	options           models.Options
+	log               *slog.Logger // Carries the source, type and host attributes
 }
 
 // New creates a new Fetcher with default config.
@@ -69,19 +91,28 @@ func New(sources []sourceWithConfig) *Fetcher {
 	})
 }
 
-func newFeed() *models.Feed {
+func newFeed(now time.Time) *models.Feed {
 	return &models.Feed{
 		Items:        make([]models.Item, 0),
-		UpdatedAt:    time.Now(),
+		UpdatedAt:    now,
 		Errors:       make(map[string]string),
 		SourceStates: make(map[string]models.SourceState),
 	}
 }
 
 func NewWithConfig(sources []sourceWithConfig, cfg Config) *Fetcher {
+	clk := cfg.Clock
+	if clk == nil {
+		clk = clock.Real()
+	}
+	seed := cfg.Seed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+
 	return &Fetcher{
 		sources: sources,
-		feed:    newFeed(),
+		feed:    newFeed(clk.Now()),
 
 		subscribers:    make(map[chan struct{}]struct{}),
 		subOnce:        make(map[chan struct{}]*sync.Once),
@@ -89,7 +120,9 @@ func NewWithConfig(sources []sourceWithConfig, cfg Config) *Fetcher {
 		limiters:       make(map[string]*rate.Limiter),
 		hostLimiters:   make(map[string]*rate.Limiter),
 		minInterval:    cfg.MinFetchInterval,
-		rng:            rand.New(rand.NewSource(time.Now().UnixNano())),
+		extractHistory: make(map[string]extractBaseline),
+		clock:          clk,
+		rng:            rand.New(rand.NewSource(seed)),
 	}
 }
 
@@ -98,72 +131,72 @@ func NewFromConfigs(configs []models.SourceConfig, minFetchInterval int, maxSubs
 	sources := make([]sourceWithConfig, 0, len(configs))
 
 	for _, cfg := range configs {
-		var src source.Source
-		switch cfg.Type {
-		case "rss":
-			src = source.NewFeedSource(cfg.Name, cfg.URL, "rss", false)
-		case "tildes":
-			src = source.NewTildesSource(cfg.Name, cfg.URL, 4)
-		case "hnalgolia":
-			src = source.NewHNAlgoliaSource(cfg.Name, cfg.URL, cfg.Type)
-		case "reddit":
-			src = source.NewFeedSource(cfg.Name, cfg.URL, "reddit", false)
-		case "lobsters":
-			src = source.NewFeedSource(cfg.Name, cfg.URL, "lobsters", true)
-		case "desuarchive":
-			src = source.NewDesuArchiveSource(cfg.Name, cfg.URL, 4, cfg.NSFW)
-		case "meltzerwiki":
-			src = source.NewMeltzerWikiSource(cfg.Name, 4)
-		default:
-			log.Printf("Unknown source type: %s", cfg.Type)
+		src, err := source.New(cfg)
+		if err != nil {
+			slog.Error("Invalid source", "source", cfg.Name, "type", cfg.Type, "error", err)
 			continue
 		}
-
-		sources = append(sources, sourceWithConfig{
-			source:            src,
-			interval:          time.Duration(cfg.Interval) * time.Second,
-			intervalJitter:    time.Duration(cfg.IntervalJitter) * time.Second,
-			host:              sourceHost(cfg.URL),
-			isReddit:          cfg.Type == "reddit",
-			startupStaggerMax: defaultRedditStartupJitter,
-			failureBackoffCap: defaultRedditBackoffCap,
-		})
+		sources = append(sources, newSourceWithConfig(cfg, src))
 	}
 
-	return &Fetcher{
-		sources: sources,
-		feed:    newFeed(),
+	return NewWithConfig(sources, Config{
+		MaxSubscribers:   maxSubscribers,
+		MinFetchInterval: time.Duration(minFetchInterval) * time.Second,
+	})
+}
 
-		subscribers:    make(map[chan struct{}]struct{}),
-		subOnce:        make(map[chan struct{}]*sync.Once),
-		maxSubscribers: maxSubscribers,
-		limiters:       make(map[string]*rate.Limiter),
-		hostLimiters:   make(map[string]*rate.Limiter),
-		minInterval:    time.Duration(minFetchInterval) * time.Second,
-		rng:            rand.New(rand.NewSource(time.Now().UnixNano())),
+// newSourceWithConfig schedules src with the settings of cfg and the policy
+// of its type.
+func newSourceWithConfig(cfg models.SourceConfig, src source.Source) sourceWithConfig {
+	policy := source.MustLookup(cfg.Type).Policy
+
+	interval := time.Duration(cfg.Interval) * time.Second
+	if interval <= 0 {
+		interval = policy.Interval
+	}
+	host := sourceHost(cfg.URL)
+
+	return sourceWithConfig{
+		source:            src,
+		interval:          interval,
+		intervalJitter:    time.Duration(cfg.IntervalJitter) * time.Second,
+		host:              host,
+		hostSpacing:       policy.HostSpacing,
+		startupStaggerMax: policy.StartupStagger,
+		failureBackoffCap: policy.BackoffCap,
+		limit:             cfg.Options.Int("limit", 0),
+		minScore:          cfg.Options.Int("min_score", 0),
+		options:           cfg.Options,
+		log:               slog.With("source", cfg.Name, "type", cfg.Type, "host", host),
 	}
 }
 
-// Start begins fetching from all sources in background.
+// SetPushSubscriber enables WebSub for pushable sources. It must be called
+// before Start.
+func (f *Fetcher) SetPushSubscriber(p PushSubscriber) {
+	f.push = p
+}
+
+// Start begins fetching from all sources in background. The startup
+// stagger timers are set here, in source order, so that a seeded fetcher on
+// a fake clock schedules the same way on every run.
 func (f *Fetcher) Start(ctx context.Context) {
 	f.initSourceStates()
 
 	for _, sc := range f.sources {
+		first := f.clock.NewTimer(f.initialDelay(sc))
 		f.wg.Add(1)
 		go func(sc sourceWithConfig) {
 			defer f.wg.Done()
-			f.fetchLoop(ctx, sc)
+			f.fetchLoop(ctx, sc, first)
 		}(sc)
 	}
 }
 
-// fetchLoop runs a fetch loop for a single source with semi-random intervals.
-func (f *Fetcher) fetchLoop(ctx context.Context, sc sourceWithConfig) {
-	if ctx.Err() != nil {
-		return
-	}
-
-	if !f.waitInitialDelay(ctx, sc) {
+// fetchLoop runs a fetch loop for a single source with semi-random intervals,
+// starting when the first timer fires.
+func (f *Fetcher) fetchLoop(ctx context.Context, sc sourceWithConfig, first clock.Timer) {
+	if !f.sleep(ctx, first) {
 		return
 	}
 
@@ -172,46 +205,73 @@ func (f *Fetcher) fetchLoop(ctx context.Context, sc sourceWithConfig) {
 			return
 		}
 
-		f.fetchSource(ctx, sc)
-		f.notifySubscribers()
+		if f.push != nil && f.push.Active(sc.source.Name()) {
+			sc.log.Debug("Skipping poll, receiving WebSub pushes")
+		} else {
+			f.fetchSource(ctx, sc)
+			f.notifySubscribers()
+		}
 
-		delay := f.nextDelay(sc)
-		f.logNextFetch(sc, delay)
+		delay, backoff := f.nextDelay(sc)
+		f.logNextFetch(sc, delay, backoff)
 
-		timer := time.NewTimer(delay)
-		select {
-		case <-ctx.Done():
-			timer.Stop()
+		if !f.sleep(ctx, f.clock.NewTimer(delay)) {
 			return
-		case <-timer.C:
 		}
 	}
 }
 
-func (f *Fetcher) waitInitialDelay(ctx context.Context, sc sourceWithConfig) bool {
-	if !sc.isReddit || sc.startupStaggerMax <= 0 {
-		return true
-	}
-
+// initialDelay returns the random startup stagger of sc.
+func (f *Fetcher) initialDelay(sc sourceWithConfig) time.Duration {
 	delay := f.randomDuration(sc.startupStaggerMax)
-	if delay <= 0 {
-		return true
+	if delay > 0 {
+		sc.log.Debug("Initial stagger", "delay", delay.Round(time.Second))
 	}
+	return delay
+}
 
-	log.Printf("Initial stagger for %s: first fetch %s", sc.source.Name(), delay.Round(time.Second))
-
-	timer := time.NewTimer(delay)
-	defer timer.Stop()
-
+// sleep waits for timer to fire. It reports false if ctx was cancelled
+// first.
+func (f *Fetcher) sleep(ctx context.Context, timer clock.Timer) bool {
 	select {
 	case <-ctx.Done():
+		timer.Stop()
 		return false
-	case <-timer.C:
+	case <-timer.C():
 		return true
 	}
 }
 
-func (f *Fetcher) nextDelay(sc sourceWithConfig) time.Duration {
+// waitLimiter is rate.Limiter.Wait on the fetcher's clock.
+func (f *Fetcher) waitLimiter(ctx context.Context, limiter *rate.Limiter) error {
+	now := f.clock.Now()
+	reservation := limiter.ReserveN(now, 1)
+	if !reservation.OK() {
+		return fmt.Errorf("rate: wait exceeds limiter's burst")
+	}
+	delay := reservation.DelayFrom(now)
+	if delay <= 0 {
+		return nil
+	}
+	if deadline, ok := ctx.Deadline(); ok && now.Add(delay).After(deadline) {
+		reservation.CancelAt(now)
+		return fmt.Errorf("rate: wait of %s would exceed context deadline", delay)
+	}
+
+	if !f.sleep(ctx, f.clock.NewTimer(delay)) {
+		reservation.CancelAt(f.clock.Now())
+		return ctx.Err()
+	}
+	return nil
+}
+
+// nextDelay returns the time until the next fetch of sc and whether it was
+// extended by backoff. How failures back off depends on their class:
+// throttled sources back off from the first failure and honour Retry-After,
+// transient failures back off after the second one if the type has a
+// BackoffCap, and parse, empty and schema drift failures keep the normal
+// interval since polling less would not fix them any sooner.
+func (f *Fetcher) nextDelay(sc sourceWithConfig) (time.Duration, bool) {
 	base := sc.interval
 	if base <= 0 {
 		base = defaultSourceInterval
@@ -219,15 +279,26 @@ func (f *Fetcher) nextDelay(sc sourceWithConfig) time.Duration {
 	if sc.intervalJitter > 0 {
 		base += f.randomDuration(sc.intervalJitter)
 	}
-	if !sc.isReddit {
-		return base
+
+	state := f.sourceState(sc.source.Name())
+	if state.ConsecutiveFailures == 0 {
+		return base, false
 	}
 
-	failures := f.consecutiveFailures(sc.source.Name())
-	if failures <= 1 {
-		return base
+	class := fetcherr.Class(state.ErrorClass)
+	switch {
+	case class.Throttled():
+		delay := backoffDelay(base, state.ConsecutiveFailures+1, max(sc.failureBackoffCap, throttledBackoffCap))
+		return max(delay, state.RetryAfter), true
+	case class.Transient() && sc.failureBackoffCap > 0 && state.ConsecutiveFailures > 1:
+		return backoffDelay(base, state.ConsecutiveFailures, sc.failureBackoffCap), true
+	default:
+		return base, false
 	}
+}
 
+// backoffDelay doubles base for every failure after the first, up to limit.
+func backoffDelay(base time.Duration, failures int, limit time.Duration) time.Duration {
 	multiplier := 1
 	for i := 1; i < failures; i++ {
 		if multiplier >= 64 {
@@ -237,8 +308,8 @@ func (f *Fetcher) nextDelay(sc sourceWithConfig) time.Duration {
 	}
 
 	delay := time.Duration(multiplier) * base
-	if sc.failureBackoffCap > 0 && delay > sc.failureBackoffCap {
-		return sc.failureBackoffCap
+	if delay > limit {
+		return limit
 	}
 	return delay
 }
@@ -248,38 +319,170 @@ func (f *Fetcher) fetchSource(ctx context.Context, sc sourceWithConfig) {
 
 	if f.minInterval > 0 {
 		limiter := f.getLimiter(src)
-		if err := limiter.Wait(ctx); err != nil {
-			log.Printf("Rate limiting %s: need to wait", src.Name())
+		if err := f.waitLimiter(ctx, limiter); err != nil {
+			sc.log.Warn("Rate limited, skipping fetch", "error", err)
 			return
 		}
 	}
 
 	if limiter := f.getHostLimiter(sc); limiter != nil {
-		if err := limiter.Wait(ctx); err != nil {
-			log.Printf("Host limiting %s (%s): %v", src.Name(), sc.host, err)
+		waitStart := f.clock.Now()
+		err := f.waitLimiter(ctx, limiter)
+		hostLimiterWait.With(sc.host).Observe(f.clock.Now().Sub(waitStart).Seconds())
+		if err != nil {
+			sc.log.Warn("Host limited, skipping fetch", "error", err)
 			return
 		}
 	}
 
-	start := time.Now()
+	start := f.clock.Now()
 	attemptAt := start
 	f.markAttempt(sc, attemptAt)
-	log.Printf("Fetching from %s (%s)", src.Name(), src.Type())
+	sc.log.Debug("Fetching")
 
-	fetchCtx, fetchCancel := context.WithTimeout(ctx, defaultFetchTimeout)
+	requestCtx := source.RequestContext(ctx, sc.options)
+	fetchCtx, fetchCancel := context.WithTimeout(requestCtx, defaultFetchTimeout)
 	defer fetchCancel()
 
 	items, err := src.Fetch(fetchCtx)
-	duration := time.Since(start)
+	duration := f.clock.Now().Sub(start)
+	items = sc.filterItems(items)
+	recordFetchMetrics(sc, duration, err)
+
+	if reporter, ok := src.(source.StatsReporter); ok {
+		f.recordExtract(sc, reporter.ExtractStats(), err)
+	}
 
 	if err != nil {
 		failures := f.markFailure(sc, attemptAt, err)
-		log.Printf("Error fetching from %s (host=%s, duration=%s, failures=%d): %v", src.Name(), sc.host, duration.Round(time.Millisecond), failures, err)
+		sc.log.Warn("Fetch failed",
+			"duration", duration.Round(time.Millisecond),
+			"failures", failures,
+			"error_class", fetcherr.Classify(err).Class,
+			"error", err)
 		return
 	}
 
 	f.markSuccess(sc, attemptAt, items)
-	log.Printf("Fetched %d items from %s (host=%s, duration=%s)", len(items), src.Name(), sc.host, duration.Round(time.Millisecond))
+	sc.log.Info("Fetched", "items", len(items), "duration", duration.Round(time.Millisecond))
+
+	f.ensurePush(ctx, sc)
+}
+
+// ensurePush subscribes to the source's WebSub hub, if it advertised one.
+func (f *Fetcher) ensurePush(ctx context.Context, sc sourceWithConfig) {
+	if f.push == nil {
+		return
+	}
+	pushable, ok := sc.source.(source.Pushable)
+	if !ok {
+		return
+	}
+	hub, topic := pushable.PushHub()
+	if hub == "" {
+		return
+	}
+
+	subCtx, cancel := context.WithTimeout(ctx, defaultFetchTimeout)
+	defer cancel()
+	if err := f.push.Ensure(subCtx, sc.source.Name(), hub, topic); err != nil {
+		sc.log.Warn("WebSub subscription failed, polling instead", "error", err)
+	}
+}
+
+// HandlePush applies content pushed by a WebSub hub to the named source.
+// Hubs may deliver only the changed entries, so pushed items are merged with
+// the current ones rather than replacing them.
+func (f *Fetcher) HandlePush(name string, body []byte) {
+	var sc sourceWithConfig
+	found := false
+	for _, candidate := range f.sources {
+		if candidate.source.Name() == name {
+			sc, found = candidate, true
+			break
+		}
+	}
+	if !found {
+		return
+	}
+
+	pushable, ok := sc.source.(source.Pushable)
+	if !ok {
+		return
+	}
+
+	pushed, err := pushable.ParsePush(body)
+	if err != nil {
+		sc.log.Warn("Ignoring WebSub push", "error", err)
+		return
+	}
+
+	f.mu.RLock()
+	current := make([]models.Item, 0)
+	for _, item := range f.feed.Items {
+		if item.SourceName == name {
+			current = append(current, item)
+		}
+	}
+	f.mu.RUnlock()
+
+	items := sc.filterItems(mergePushedItems(current, pushed))
+	f.markSuccess(sc, f.clock.Now(), items)
+	f.notifySubscribers()
+	sc.log.Info("Received WebSub push", "items", len(pushed))
+}
+
+// mergePushedItems overlays pushed items on the current ones by link, newest
+// first, keeping the source at its usual size.
+func mergePushedItems(current, pushed []models.Item) []models.Item {
+	keep := max(len(current), len(pushed))
+	seen := make(map[string]bool, len(pushed))
+	merged := make([]models.Item, 0, len(current)+len(pushed))
+
+	for _, item := range pushed {
+		seen[item.Link] = true
+		merged = append(merged, item)
+	}
+	for _, item := range current {
+		if !seen[item.Link] {
+			merged = append(merged, item)
+		}
+	}
+
+	sort.SliceStable(merged, func(i, j int) bool {
+		return merged[i].Published.After(merged[j].Published)
+	})
+	if len(merged) > keep {
+		merged = merged[:keep]
+	}
+	return merged
+}
+
+// FilterItems applies the generic min_score and limit options of cfg to
+// the items of a fetch, as the fetcher does.
+func FilterItems(cfg models.SourceConfig, items []models.Item) []models.Item {
+	sc := sourceWithConfig{
+		limit:    cfg.Options.Int("limit", 0),
+		minScore: cfg.Options.Int("min_score", 0),
+	}
+	return sc.filterItems(items)
+}
+
+// filterItems applies the generic min_score and limit options.
+func (sc sourceWithConfig) filterItems(items []models.Item) []models.Item {
+	if sc.minScore > 0 {
+		kept := items[:0:0]
+		for _, item := range items {
+			if item.Score >= sc.minScore {
+				kept = append(kept, item)
+			}
+		}
+		items = kept
+	}
+	if sc.limit > 0 && len(items) > sc.limit {
+		items = items[:sc.limit]
+	}
+	return items
 }
 
 func (f *Fetcher) getLimiter(src source.Source) *rate.Limiter {
@@ -297,7 +500,7 @@ func (f *Fetcher) getLimiter(src source.Source) *rate.Limiter {
 }
 
 func (f *Fetcher) getHostLimiter(sc sourceWithConfig) *rate.Limiter {
-	if !sc.isReddit || sc.host == "" {
+	if sc.hostSpacing <= 0 || sc.host == "" {
 		return nil
 	}
 
@@ -308,7 +511,7 @@ func (f *Fetcher) getHostLimiter(sc sourceWithConfig) *rate.Limiter {
 		return limiter
 	}
 
-	limiter := rate.NewLimiter(rate.Every(defaultRedditHostSpacing), 1)
+	limiter := rate.NewLimiter(rate.Every(sc.hostSpacing), 1)
 	f.hostLimiters[sc.host] = limiter
 	return limiter
 }
@@ -320,6 +523,7 @@ func (f *Fetcher) initSourceStates() {
 	for _, sc := range f.sources {
 		state := f.ensureSourceStateLocked(sc)
 		f.feed.SourceStates[sc.source.Name()] = state
+		recordStateMetrics(state)
 	}
 }
 
@@ -360,12 +564,22 @@ func (f *Fetcher) markFailure(sc sourceWithConfig, at time.Time, err error) int
 
 	state := f.ensureSourceStateLocked(sc)
 	state.LastAttemptAt = at
+	info := fetcherr.Classify(err)
 	state.LastError = err.Error()
+	state.ErrorClass = string(info.Class)
+	state.HTTPStatus = info.StatusCode
+	state.RetryAfter = info.RetryAfter
+	state.BlockedBy = ""
+	var challenge *httpclient.ChallengeError
+	if errors.As(err, &challenge) {
+		state.BlockedBy = challenge.Provider
+	}
 	state.ConsecutiveFailures++
 	state.Stale = true
 	f.feed.SourceStates[sc.source.Name()] = state
+	recordStateMetrics(state)
 	f.feed.Errors[sc.source.Name()] = err.Error()
-	f.feed.UpdatedAt = time.Now()
+	f.feed.UpdatedAt = f.clock.Now()
 
 	return state.ConsecutiveFailures
 }
@@ -387,28 +601,34 @@ func (f *Fetcher) markSuccess(sc sourceWithConfig, at time.Time, items []models.
 	state.LastAttemptAt = at
 	state.LastSuccessAt = at
 	state.LastError = ""
+	state.ErrorClass = ""
+	state.HTTPStatus = 0
+	state.RetryAfter = 0
+	state.BlockedBy = ""
 	state.ConsecutiveFailures = 0
 	state.Stale = false
 	f.feed.SourceStates[sc.source.Name()] = state
+	recordStateMetrics(state)
+	sourceItems.With(sc.source.Name()).Set(float64(len(items)))
 
 	delete(f.feed.Errors, sc.source.Name())
-	f.feed.UpdatedAt = time.Now()
+	f.feed.UpdatedAt = f.clock.Now()
 }
 
-func (f *Fetcher) consecutiveFailures(sourceName string) int {
+func (f *Fetcher) sourceState(sourceName string) models.SourceState {
 	f.mu.RLock()
 	defer f.mu.RUnlock()
 
-	if state, exists := f.feed.SourceStates[sourceName]; exists {
-		return state.ConsecutiveFailures
-	}
-	return 0
+	return f.feed.SourceStates[sourceName]
 }
 
-func (f *Fetcher) logNextFetch(sc sourceWithConfig, delay time.Duration) {
-	failures := f.consecutiveFailures(sc.source.Name())
-	backoff := sc.isReddit && failures > 1
-	log.Printf("Next fetch for %s in %s (backoff=%t, failures=%d)", sc.source.Name(), delay.Round(time.Second), backoff, failures)
+func (f *Fetcher) logNextFetch(sc sourceWithConfig, delay time.Duration, backoff bool) {
+	state := f.sourceState(sc.source.Name())
+	attrs := []any{"delay", delay.Round(time.Second), "backoff", backoff, "failures", state.ConsecutiveFailures}
+	if state.ErrorClass != "" {
+		attrs = append(attrs, "error_class", state.ErrorClass)
+	}
+	sc.log.Debug("Next fetch", attrs...)
 }
 
 func (f *Fetcher) randomDuration(max time.Duration) time.Duration {
@@ -501,6 +721,13 @@ func (f *Fetcher) GetFeed() models.Feed {
 	statesCopy := make(map[string]models.SourceState, len(f.feed.SourceStates))
 	maps.Copy(statesCopy, f.feed.SourceStates)
 
+	if f.push != nil {
+		for name, state := range statesCopy {
+			state.Pushed = f.push.Active(name)
+			statesCopy[name] = state
+		}
+	}
+
 	return models.Feed{
 		Items:        append([]models.Item(nil), f.feed.Items...),
 		UpdatedAt:    f.feed.UpdatedAt,
@@ -0,0 +1,275 @@
+package fetcher
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ppowo/feedlet/internal/clock"
+	"github.com/ppowo/feedlet/internal/models"
+	"github.com/ppowo/feedlet/internal/source/fetcherr"
+	"github.com/ppowo/feedlet/internal/source/httpclient"
+)
+
+var (
+	testEpoch  = time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	testLogger = slog.New(slog.NewTextHandler(io.Discard, nil))
+)
+
+// stubSource records the fetcher clock's time at each fetch and returns err.
+type stubSource struct {
+	name  string
+	clock clock.Clock
+	err   error
+
+	mu      sync.Mutex
+	fetches []time.Time
+}
+
+func (s *stubSource) Fetch(ctx context.Context) ([]models.Item, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.clock.Now()
+	s.fetches = append(s.fetches, now)
+	if s.err != nil {
+		return nil, s.err
+	}
+	return []models.Item{{Title: "item", Link: "https://example.com/" + s.name, Published: now, SourceName: s.name}}, nil
+}
+
+func (s *stubSource) Name() string { return s.name }
+func (s *stubSource) Type() string { return "stub" }
+
+func (s *stubSource) fetchTimes() []time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]time.Time(nil), s.fetches...)
+}
+
+func newTestFetcher(clk clock.Clock, sources ...sourceWithConfig) *Fetcher {
+	return NewWithConfig(sources, Config{Clock: clk, Seed: 1})
+}
+
+func stubConfig(src *stubSource, interval, backoffCap time.Duration) sourceWithConfig {
+	return sourceWithConfig{
+		source:            src,
+		interval:          interval,
+		failureBackoffCap: backoffCap,
+		log:               testLogger,
+	}
+}
+
+func TestNextDelayByErrorClass(t *testing.T) {
+	const interval = 10 * time.Minute
+	tests := []struct {
+		name        string
+		class       fetcherr.Class
+		failures    int
+		backoffCap  time.Duration
+		wantDelay   time.Duration
+		wantBackoff bool
+	}{
+		{"healthy", "", 0, 2 * time.Hour, interval, false},
+		{"first network failure", fetcherr.Network, 1, 2 * time.Hour, interval, false},
+		{"third network failure", fetcherr.Network, 3, 2 * time.Hour, 4 * interval, true},
+		{"network failures up to the cap", fetcherr.Network, 10, 2 * time.Hour, 2 * time.Hour, true},
+		{"network failure without a cap", fetcherr.Network, 3, 0, interval, false},
+		{"timeout", fetcherr.Timeout, 2, 2 * time.Hour, 2 * interval, true},
+		{"http status", fetcherr.HTTPStatus, 2, 2 * time.Hour, 2 * interval, true},
+		{"first rate limit", fetcherr.RateLimited, 1, 0, 2 * interval, true},
+		{"rate limits up to the throttled cap", fetcherr.RateLimited, 6, 0, throttledBackoffCap, true},
+		{"blocked with a longer cap", fetcherr.Blocked, 6, 4 * time.Hour, 4 * time.Hour, true},
+		{"parse", fetcherr.Parse, 5, 2 * time.Hour, interval, false},
+		{"empty", fetcherr.Empty, 5, 2 * time.Hour, interval, false},
+		{"schema drift", fetcherr.SchemaDrift, 5, 2 * time.Hour, interval, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clk := clock.NewFake(testEpoch)
+			sc := stubConfig(&stubSource{name: "s", clock: clk}, interval, tt.backoffCap)
+			f := newTestFetcher(clk, sc)
+			for range tt.failures {
+				f.markFailure(sc, clk.Now(), fetcherr.New(tt.class, "failed"))
+			}
+
+			delay, backoff := f.nextDelay(sc)
+			if delay != tt.wantDelay || backoff != tt.wantBackoff {
+				t.Errorf("nextDelay = %s, %v; want %s, %v", delay, backoff, tt.wantDelay, tt.wantBackoff)
+			}
+		})
+	}
+}
+
+func TestNextDelayHonoursRetryAfter(t *testing.T) {
+	clk := clock.NewFake(testEpoch)
+	sc := stubConfig(&stubSource{name: "s", clock: clk}, 10*time.Minute, 0)
+	f := newTestFetcher(clk, sc)
+
+	rateLimited := func(retryAfter time.Duration) error {
+		return fmt.Errorf("fetch: %w", &httpclient.StatusError{StatusCode: 429, RetryAfter: retryAfter})
+	}
+
+	// Retry-After longer than the backoff wins
+	f.markFailure(sc, clk.Now(), rateLimited(3*time.Hour))
+	if delay, backoff := f.nextDelay(sc); delay != 3*time.Hour || !backoff {
+		t.Errorf("with Retry-After 3h: nextDelay = %s, %v; want 3h, true", delay, backoff)
+	}
+
+	// A shorter one does not cut the backoff short
+	f.markFailure(sc, clk.Now(), rateLimited(time.Minute))
+	if delay, _ := f.nextDelay(sc); delay != 40*time.Minute {
+		t.Errorf("with Retry-After 1m: nextDelay = %s, want the 40m backoff", delay)
+	}
+
+	if state := f.sourceState("s"); state.ErrorClass != string(fetcherr.RateLimited) || state.HTTPStatus != 429 {
+		t.Errorf("state = %+v, want a rate limited 429", state)
+	}
+}
+
+func TestHostSpacing(t *testing.T) {
+	clk := clock.NewFake(testEpoch)
+	first := &stubSource{name: "first", clock: clk}
+	second := &stubSource{name: "second", clock: clk}
+	other := &stubSource{name: "other", clock: clk}
+	spaced := func(src *stubSource, host string) sourceWithConfig {
+		sc := stubConfig(src, time.Hour, 0)
+		sc.host = host
+		sc.hostSpacing = 3 * time.Second
+		return sc
+	}
+	scFirst, scSecond, scOther := spaced(first, "a.example"), spaced(second, "a.example"), spaced(other, "b.example")
+	f := newTestFetcher(clk, scFirst, scSecond, scOther)
+	ctx := context.Background()
+
+	f.fetchSource(ctx, scFirst)
+	f.fetchSource(ctx, scOther)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		f.fetchSource(ctx, scSecond)
+	}()
+	clk.WaitPending(1)
+	if next, _ := clk.Next(); !next.Equal(testEpoch.Add(3 * time.Second)) {
+		t.Errorf("second fetch of the host waits until %s, want 3s after the first", next.Sub(testEpoch))
+	}
+	clk.Step()
+	<-done
+
+	if got := first.fetchTimes(); len(got) != 1 || !got[0].Equal(testEpoch) {
+		t.Errorf("first fetched at %v, want at the start", got)
+	}
+	if got := other.fetchTimes(); len(got) != 1 || !got[0].Equal(testEpoch) {
+		t.Errorf("other host fetched at %v, want at the start", got)
+	}
+	if got := second.fetchTimes(); len(got) != 1 || !got[0].Equal(testEpoch.Add(3*time.Second)) {
+		t.Errorf("second fetched at %v, want 3s after the start", got)
+	}
+}
+
+func TestWaitLimiterCancelled(t *testing.T) {
+	clk := clock.NewFake(testEpoch)
+	sc := stubConfig(&stubSource{name: "s", clock: clk}, time.Hour, 0)
+	sc.host, sc.hostSpacing = "a.example", time.Minute
+	f := newTestFetcher(clk, sc)
+	limiter := f.getHostLimiter(sc)
+
+	if err := f.waitLimiter(context.Background(), limiter); err != nil {
+		t.Fatalf("first wait: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errc := make(chan error, 1)
+	go func() { errc <- f.waitLimiter(ctx, limiter) }()
+	clk.WaitPending(1)
+	cancel()
+	if err := <-errc; !errors.Is(err, context.Canceled) {
+		t.Errorf("cancelled wait returned %v", err)
+	}
+
+	// The cancelled reservation is given back, so the next wait is a minute
+	// after the first fetch, not two
+	go func() { errc <- f.waitLimiter(context.Background(), limiter) }()
+	clk.WaitPending(1)
+	if next, _ := clk.Next(); !next.Equal(testEpoch.Add(time.Minute)) {
+		t.Errorf("next wait ends at %s, want 1m", next.Sub(testEpoch))
+	}
+	clk.Step()
+	if err := <-errc; err != nil {
+		t.Errorf("wait: %v", err)
+	}
+}
+
+func TestFailureAndSuccessTransitions(t *testing.T) {
+	clk := clock.NewFake(testEpoch)
+	src := &stubSource{name: "s", clock: clk}
+	sc := stubConfig(src, time.Hour, 0)
+	f := newTestFetcher(clk, sc)
+	ctx := context.Background()
+
+	f.fetchSource(ctx, sc)
+	state := f.sourceState("s")
+	if state.Stale || state.ConsecutiveFailures != 0 || !state.LastSuccessAt.Equal(testEpoch) {
+		t.Fatalf("after success: %+v", state)
+	}
+
+	src.err = fmt.Errorf("fetch: %w", &httpclient.StatusError{StatusCode: 503, Status: "503 Service Unavailable"})
+	for i := 1; i <= 2; i++ {
+		clk.Advance(time.Minute)
+		f.fetchSource(ctx, sc)
+		state = f.sourceState("s")
+		if !state.Stale || state.ConsecutiveFailures != i {
+			t.Fatalf("after failure %d: stale %v, failures %d", i, state.Stale, state.ConsecutiveFailures)
+		}
+	}
+	if state.ErrorClass != string(fetcherr.HTTPStatus) || state.HTTPStatus != 503 || state.LastError == "" {
+		t.Errorf("failure state = %+v, want an HTTP 503", state)
+	}
+	if !state.LastSuccessAt.Equal(testEpoch) || !state.LastAttemptAt.Equal(testEpoch.Add(2*time.Minute)) {
+		t.Errorf("last success %s, last attempt %s", state.LastSuccessAt, state.LastAttemptAt)
+	}
+	if feed := f.GetFeed(); feed.Errors["s"] == "" || len(feed.Items) != 1 {
+		t.Errorf("failing source should keep its items and report its error: %+v", feed)
+	}
+
+	src.err = nil
+	clk.Advance(time.Minute)
+	f.fetchSource(ctx, sc)
+	state = f.sourceState("s")
+	if state.Stale || state.ConsecutiveFailures != 0 || state.ErrorClass != "" || state.HTTPStatus != 0 || state.LastError != "" {
+		t.Errorf("after recovery: %+v", state)
+	}
+	if !state.LastSuccessAt.Equal(testEpoch.Add(3 * time.Minute)) {
+		t.Errorf("last success = %s, want the recovering fetch", state.LastSuccessAt)
+	}
+	if _, ok := f.GetFeed().Errors["s"]; ok {
+		t.Error("error kept after recovery")
+	}
+}
+
+// stubPush reports the sources in active as receiving pushes.
+type stubPush struct {
+	active map[string]bool
+}
+
+func (p stubPush) Ensure(ctx context.Context, name, hub, topic string) error { return nil }
+func (p stubPush) Active(name string) bool                                   { return p.active[name] }
+
+func TestGetFeedMarksPushedSources(t *testing.T) {
+	clk := clock.NewFake(testEpoch)
+	pushed := stubConfig(&stubSource{name: "pushed", clock: clk}, time.Hour, 0)
+	polled := stubConfig(&stubSource{name: "polled", clock: clk}, time.Hour, 0)
+	f := newTestFetcher(clk, pushed, polled)
+	f.SetPushSubscriber(stubPush{active: map[string]bool{"pushed": true}})
+	f.initSourceStates()
+
+	states := f.GetFeed().SourceStates
+	if !states["pushed"].Pushed || states["polled"].Pushed {
+		t.Errorf("pushed = %v, polled = %v; want only the first marked", states["pushed"].Pushed, states["polled"].Pushed)
+	}
+}
@@ -0,0 +1,48 @@
+package fetcher
+
+import (
+	"time"
+
+	"github.com/ppowo/feedlet/internal/metrics"
+	"github.com/ppowo/feedlet/internal/models"
+	"github.com/ppowo/feedlet/internal/source/fetcherr"
+)
+
+var (
+	fetchesTotal = metrics.Default.Counter("feedlet_fetches_total",
+		"Fetches by source and outcome: success or the failure's error class.",
+		"source", "type", "outcome")
+	fetchDuration = metrics.Default.Histogram("feedlet_fetch_duration_seconds",
+		"Time taken by fetches, including failed ones.",
+		metrics.DefaultBuckets, "source")
+	sourceItems = metrics.Default.Gauge("feedlet_source_items",
+		"Items kept from the source's last successful fetch.",
+		"source")
+	sourceFailures = metrics.Default.Gauge("feedlet_source_consecutive_failures",
+		"Failed fetches of the source since its last success.",
+		"source")
+	sourceLastSuccess = metrics.Default.Gauge("feedlet_source_last_success_timestamp_seconds",
+		"Unix time of the source's last successful fetch.",
+		"source")
+	hostLimiterWait = metrics.Default.Histogram("feedlet_host_limiter_wait_seconds",
+		"Time fetches waited for their host's rate limiter.",
+		[]float64{0, 0.5, 1, 2.5, 5, 10, 30, 60, 120}, "host")
+)
+
+// recordFetchMetrics counts a finished fetch by its outcome.
+func recordFetchMetrics(sc sourceWithConfig, duration time.Duration, err error) {
+	outcome := "success"
+	if err != nil {
+		outcome = string(fetcherr.Classify(err).Class)
+	}
+	fetchesTotal.With(sc.source.Name(), sc.source.Type(), outcome).Inc()
+	fetchDuration.With(sc.source.Name()).Observe(duration.Seconds())
+}
+
+// recordStateMetrics updates the gauges derived from a source's state.
+func recordStateMetrics(state models.SourceState) {
+	sourceFailures.With(state.Name).Set(float64(state.ConsecutiveFailures))
+	if !state.LastSuccessAt.IsZero() {
+		sourceLastSuccess.With(state.Name).Set(float64(state.LastSuccessAt.UnixNano()) / 1e9)
+	}
+}
@@ -0,0 +1,252 @@
+package fetcher
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ppowo/feedlet/internal/clock"
+	"github.com/ppowo/feedlet/internal/models"
+	"github.com/ppowo/feedlet/internal/source/fetcherr"
+	"github.com/ppowo/feedlet/internal/source/httpclient"
+)
+
+// simEpoch is where simulated time starts, so that reports of the same seed
+// are identical.
+var simEpoch = time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// SimOptions configures Simulate.
+type SimOptions struct {
+	Duration         time.Duration // Simulated time to run for
+	Seed             int64         // Seeds scheduling and failures; 1 if zero
+	FailureRate      float64       // Chance that a healthy source starts failing on a fetch
+	OutageLength     float64       // Chance that a failing source fails again on the next fetch
+	MinFetchInterval time.Duration
+}
+
+// SimReport is the outcome of a simulation.
+type SimReport struct {
+	Duration time.Duration
+	Seed     int64
+	Steps    int
+	Sources  []SimSource
+	Hosts    []SimHost
+}
+
+// SimSource sums up the simulated fetches of one source.
+type SimSource struct {
+	Name           string
+	Type           string
+	Host           string
+	Fetches        int
+	Failures       int
+	MaxFailures    int           // Longest run of consecutive failures
+	FirstFetch     time.Duration // Since the start of the simulation
+	MinGap, MaxGap time.Duration // Between consecutive fetches
+	MeanGap        time.Duration
+	Errors         map[fetcherr.Class]int
+}
+
+// SimHost sums up the simulated fetches against one host.
+type SimHost struct {
+	Host       string
+	Fetches    int
+	Spacing    time.Duration // Required by the type policies
+	MinSpacing time.Duration // Observed between consecutive fetches
+}
+
+// Simulate runs the scheduler over configs against stand-in sources on a
+// fake clock. No requests are made: each fetch returns a single item or,
+// driven by the failure options, a network, rate limit or parse error, so the
+// startup stagger, jitter, backoff and host spacing of the real scheduler can
+// be watched over a day in a few milliseconds. Runs with the same seed give
+// the same report.
+func Simulate(configs []models.SourceConfig, opts SimOptions) SimReport {
+	if opts.Seed == 0 {
+		opts.Seed = 1
+	}
+
+	clk := clock.NewFake(simEpoch)
+	outcomes := &simOutcomes{
+		rng:          rand.New(rand.NewSource(opts.Seed)),
+		failureRate:  opts.FailureRate,
+		outageLength: opts.OutageLength,
+	}
+
+	sources := make([]sourceWithConfig, 0, len(configs))
+	sims := make([]*simSource, 0, len(configs))
+	for i, cfg := range configs {
+		sim := &simSource{
+			name:     cfg.Name,
+			typ:      cfg.Type,
+			class:    simClasses[i%len(simClasses)],
+			clock:    clk,
+			outcomes: outcomes,
+		}
+		sims = append(sims, sim)
+		sources = append(sources, newSourceWithConfig(cfg, sim))
+	}
+
+	f := NewWithConfig(sources, Config{
+		MinFetchInterval: opts.MinFetchInterval,
+		Clock:            clk,
+		Seed:             opts.Seed,
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	f.Start(ctx)
+
+	// Every fetch loop is asleep on the clock between fetches. Waking them
+	// one at a time keeps the run deterministic.
+	end := simEpoch.Add(opts.Duration)
+	steps := 0
+	for {
+		clk.WaitPending(len(sources))
+		next, ok := clk.Next()
+		if !ok || next.After(end) {
+			break
+		}
+		clk.Step()
+		steps++
+	}
+	cancel()
+	f.wg.Wait()
+
+	report := SimReport{Duration: opts.Duration, Seed: opts.Seed, Steps: steps}
+	hosts := make(map[string]*SimHost)
+	hostFetches := make(map[string][]time.Time)
+	for i, sim := range sims {
+		sc := sources[i]
+		report.Sources = append(report.Sources, sim.report(sc.host))
+
+		if sc.host == "" {
+			continue
+		}
+		host, ok := hosts[sc.host]
+		if !ok {
+			host = &SimHost{Host: sc.host}
+			hosts[sc.host] = host
+		}
+		host.Spacing = max(host.Spacing, sc.hostSpacing)
+		hostFetches[sc.host] = append(hostFetches[sc.host], sim.fetches...)
+	}
+
+	for name, host := range hosts {
+		fetches := hostFetches[name]
+		sort.Slice(fetches, func(i, j int) bool { return fetches[i].Before(fetches[j]) })
+		host.Fetches = len(fetches)
+		host.MinSpacing, _, _ = gaps(fetches)
+		report.Hosts = append(report.Hosts, *host)
+	}
+	sort.Slice(report.Hosts, func(i, j int) bool { return report.Hosts[i].Host < report.Hosts[j].Host })
+
+	return report
+}
+
+// simClasses are the failure classes handed out to stand-in sources in turn.
+var simClasses = []fetcherr.Class{fetcherr.Network, fetcherr.RateLimited, fetcherr.Parse}
+
+// simOutcomes decides which fetches fail. Fetches run one at a time, but the
+// mutex keeps it safe outside of Simulate.
+type simOutcomes struct {
+	mu           sync.Mutex
+	rng          *rand.Rand
+	failureRate  float64
+	outageLength float64
+}
+
+func (o *simOutcomes) fail(failing bool) bool {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if failing {
+		return o.rng.Float64() < o.outageLength
+	}
+	return o.rng.Float64() < o.failureRate
+}
+
+// simSource stands in for a source in simulations.
+type simSource struct {
+	name     string
+	typ      string
+	class    fetcherr.Class
+	clock    clock.Clock
+	outcomes *simOutcomes
+
+	fetches   []time.Time
+	failures  int
+	failing   int
+	maxFailed int
+	errors    map[fetcherr.Class]int
+}
+
+func (s *simSource) Name() string { return s.name }
+func (s *simSource) Type() string { return s.typ }
+
+func (s *simSource) Fetch(ctx context.Context) ([]models.Item, error) {
+	now := s.clock.Now()
+	s.fetches = append(s.fetches, now)
+
+	if !s.outcomes.fail(s.failing > 0) {
+		s.failing = 0
+		return []models.Item{{
+			Title:      fmt.Sprintf("%s #%d", s.name, len(s.fetches)),
+			Link:       fmt.Sprintf("https://example.com/%d", len(s.fetches)),
+			Published:  now,
+			SourceName: s.name,
+		}}, nil
+	}
+
+	s.failures++
+	s.failing++
+	s.maxFailed = max(s.maxFailed, s.failing)
+	if s.errors == nil {
+		s.errors = make(map[fetcherr.Class]int)
+	}
+	s.errors[s.class]++
+
+	switch s.class {
+	case fetcherr.RateLimited:
+		return nil, &httpclient.StatusError{StatusCode: 429, Status: "429 Too Many Requests", RetryAfter: 15 * time.Minute}
+	case fetcherr.Parse:
+		return nil, fetcherr.New(fetcherr.Parse, "simulated parse error")
+	default:
+		return nil, fetcherr.New(fetcherr.Network, "simulated connection reset")
+	}
+}
+
+func (s *simSource) report(host string) SimSource {
+	r := SimSource{
+		Name:        s.name,
+		Type:        s.typ,
+		Host:        host,
+		Fetches:     len(s.fetches),
+		Failures:    s.failures,
+		MaxFailures: s.maxFailed,
+		Errors:      s.errors,
+	}
+	if len(s.fetches) > 0 {
+		r.FirstFetch = s.fetches[0].Sub(simEpoch)
+	}
+	r.MinGap, r.MaxGap, r.MeanGap = gaps(s.fetches)
+	return r
+}
+
+// gaps returns the shortest, longest and mean time between sorted times.
+func gaps(times []time.Time) (minGap, maxGap, meanGap time.Duration) {
+	if len(times) < 2 {
+		return 0, 0, 0
+	}
+	for i := 1; i < len(times); i++ {
+		gap := times[i].Sub(times[i-1])
+		if i == 1 || gap < minGap {
+			minGap = gap
+		}
+		maxGap = max(maxGap, gap)
+	}
+	meanGap = times[len(times)-1].Sub(times[0]) / time.Duration(len(times)-1)
+	return minGap, maxGap, meanGap
+}
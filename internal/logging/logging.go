@@ -1,15 +1,31 @@
+// Package logging sets up the process-wide slog logger: text or JSON
+// records to stdout and to a rotated file in the OS log directory.
 package logging
 
 import (
+	"context"
+	"fmt"
 	"io"
-	"log"
+	"log/slog"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"gopkg.in/natefinch/lumberjack.v2"
+
+	"github.com/ppowo/feedlet/internal/models"
+)
+
+// Rotation defaults, used for zero values in the config.
+const (
+	defaultMaxSize    = 10 // Megabytes
+	defaultMaxBackups = 3
+	defaultMaxAge     = 3 // Days
 )
 
-func Setup() error {
+// Setup installs the default logger described by cfg. Sources with the
+// debug option are logged at debug level whatever the configured level.
+func Setup(cfg models.LoggingConfig, sources []models.SourceConfig) error {
 	logDir, err := getLogDir()
 	if err != nil {
 		return err
@@ -21,22 +37,108 @@ func Setup() error {
 
 	logPath := filepath.Join(logDir, "feedlet.log")
 
-	logger := &lumberjack.Logger{
+	file := &lumberjack.Logger{
 		Filename:   logPath,
-		MaxSize:    10,
-		MaxBackups: 3,
-		MaxAge:     3,
-		Compress:   false,
+		MaxSize:    orDefault(cfg.MaxSize, defaultMaxSize),
+		MaxBackups: orDefault(cfg.MaxBackups, defaultMaxBackups),
+		MaxAge:     orDefault(cfg.MaxAge, defaultMaxAge),
+		Compress:   cfg.Compress,
 	}
 
-	multiWriter := io.MultiWriter(os.Stdout, logger)
-	log.SetOutput(multiWriter)
-	log.SetFlags(0)
+	debug := make(map[string]bool)
+	for _, sc := range sources {
+		if sc.Options.Bool("debug", false) {
+			debug[sc.Name] = true
+		}
+	}
+
+	handler, err := NewHandler(io.MultiWriter(os.Stdout, file), cfg, debug)
+	if err != nil {
+		return err
+	}
+	slog.SetDefault(slog.New(handler))
 
-	log.Printf("Logging to %s", logPath)
+	slog.Info("Logging to file", "path", logPath)
 	return nil
 }
 
+// NewHandler returns a handler writing cfg's format to w at cfg's level.
+// Loggers derived with a "source" attribute naming a source in debug also
+// pass debug records.
+func NewHandler(w io.Writer, cfg models.LoggingConfig, debug map[string]bool) (slog.Handler, error) {
+	level, err := ParseLevel(cfg.Level)
+	if err != nil {
+		return nil, err
+	}
+
+	// sourceHandler does the level filtering, so the inner handler is set to
+	// accept every record
+	opts := &slog.HandlerOptions{Level: slog.LevelDebug}
+	var inner slog.Handler
+	switch strings.ToLower(cfg.Format) {
+	case "", "text":
+		inner = slog.NewTextHandler(w, opts)
+	case "json":
+		inner = slog.NewJSONHandler(w, opts)
+	default:
+		return nil, fmt.Errorf("unknown log format %q (want text or json)", cfg.Format)
+	}
+
+	return &sourceHandler{inner: inner, level: level, debug: debug}, nil
+}
+
+// ParseLevel parses debug, info, warn or error; empty means info.
+func ParseLevel(s string) (slog.Level, error) {
+	if s == "" {
+		return slog.LevelInfo, nil
+	}
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(s)); err != nil {
+		return 0, fmt.Errorf("unknown log level %q (want debug, info, warn or error)", s)
+	}
+	return level, nil
+}
+
+// sourceHandler filters records by level, lowering it to debug for loggers
+// of the sources in debug.
+type sourceHandler struct {
+	inner slog.Handler
+	level slog.Level
+	debug map[string]bool
+}
+
+func (h *sourceHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return level >= h.level
+}
+
+func (h *sourceHandler) Handle(ctx context.Context, record slog.Record) error {
+	return h.inner.Handle(ctx, record)
+}
+
+func (h *sourceHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	derived := *h
+	derived.inner = h.inner.WithAttrs(attrs)
+	for _, attr := range attrs {
+		if attr.Key == "source" && h.debug[attr.Value.String()] {
+			derived.level = slog.LevelDebug
+		}
+	}
+	return &derived
+}
+
+func (h *sourceHandler) WithGroup(name string) slog.Handler {
+	derived := *h
+	derived.inner = h.inner.WithGroup(name)
+	return &derived
+}
+
+func orDefault(value, fallback int) int {
+	if value <= 0 {
+		return fallback
+	}
+	return value
+}
+
 func getLogDir() (string, error) {
 	switch {
 	case os.Getenv("XDG_STATE_HOME") != "":
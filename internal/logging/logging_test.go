@@ -0,0 +1,94 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/ppowo/feedlet/internal/models"
+)
+
+func TestParseLevel(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    slog.Level
+		wantErr bool
+	}{
+		{"", slog.LevelInfo, false},
+		{"debug", slog.LevelDebug, false},
+		{"INFO", slog.LevelInfo, false},
+		{"warn", slog.LevelWarn, false},
+		{"error", slog.LevelError, false},
+		{"verbose", 0, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseLevel(tt.value)
+		if (err != nil) != tt.wantErr || (!tt.wantErr && got != tt.want) {
+			t.Errorf("ParseLevel(%q) = %v, %v; want %v, error %v", tt.value, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestNewHandlerRejectsUnknownFormat(t *testing.T) {
+	if _, err := NewHandler(&bytes.Buffer{}, models.LoggingConfig{Format: "xml"}, nil); err == nil {
+		t.Error("format xml accepted")
+	}
+	if _, err := NewHandler(&bytes.Buffer{}, models.LoggingConfig{Level: "loud"}, nil); err == nil {
+		t.Error("level loud accepted")
+	}
+}
+
+func TestSourceDebug(t *testing.T) {
+	var buf bytes.Buffer
+	handler, err := NewHandler(&buf, models.LoggingConfig{Level: "info"}, map[string]bool{"noisy": true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger := slog.New(handler)
+
+	logger.Debug("root debug")
+	logger.Info("root info")
+	logger.With("source", "quiet").Debug("quiet debug")
+	logger.With("source", "quiet").Warn("quiet warn")
+	noisy := logger.With("source", "noisy")
+	noisy.Debug("noisy debug")
+	noisy.With("host", "example.com").Debug("noisy derived debug")
+	noisy.WithGroup("fetch").Debug("noisy grouped debug")
+
+	var got []string
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		for _, msg := range []string{"root debug", "root info", "quiet debug", "quiet warn", "noisy debug", "noisy derived debug", "noisy grouped debug"} {
+			if strings.Contains(line, `msg="`+msg+`"`) {
+				got = append(got, msg)
+			}
+		}
+	}
+	want := []string{"root info", "quiet warn", "noisy debug", "noisy derived debug", "noisy grouped debug"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("logged %q, want %q\n%s", got, want, buf.String())
+	}
+	if !strings.Contains(buf.String(), "source=noisy") {
+		t.Errorf("source attribute missing:\n%s", buf.String())
+	}
+}
+
+func TestJSONFormat(t *testing.T) {
+	var buf bytes.Buffer
+	handler, err := NewHandler(&buf, models.LoggingConfig{Level: "warn", Format: "json"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger := slog.New(handler).With("source", "s")
+	logger.Info("dropped")
+	logger.Error("kept", "failures", 3)
+
+	var record map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+		t.Fatalf("output is not one JSON record: %v\n%s", err, buf.String())
+	}
+	if record["msg"] != "kept" || record["level"] != "ERROR" || record["source"] != "s" || record["failures"] != 3.0 {
+		t.Errorf("record = %v", record)
+	}
+}
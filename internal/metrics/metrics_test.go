@@ -0,0 +1,98 @@
+package metrics
+
+import (
+	"strings"
+	"testing"
+)
+
+func render(t *testing.T, r *Registry) string {
+	t.Helper()
+	var b strings.Builder
+	n, err := r.WriteTo(&b)
+	if err != nil {
+		t.Fatalf("WriteTo: %v", err)
+	}
+	if n != int64(b.Len()) {
+		t.Errorf("WriteTo reported %d bytes, wrote %d", n, b.Len())
+	}
+	return b.String()
+}
+
+func TestWriteTo(t *testing.T) {
+	r := NewRegistry()
+	fetches := r.Counter("test_fetches_total", "Fetches by source.", "source", "outcome")
+	items := r.Gauge("test_items", "Items per source.", "source")
+	duration := r.Histogram("test_duration_seconds", "Fetch duration.", []float64{0.5, 1, 2.5}, "source")
+	r.Gauge("test_unused", "Never set, so not written.")
+
+	fetches.With("b", "ok").Add(2)
+	fetches.With("a", "ok").Inc()
+	fetches.With("a", "ok").Add(-5) // Ignored
+	items.With("a").Set(10)
+	items.With("a").Add(-3)
+	for _, v := range []float64{0.1, 0.5, 0.7, 2} {
+		duration.With("a").Observe(v)
+	}
+
+	want := `# HELP test_fetches_total Fetches by source.
+# TYPE test_fetches_total counter
+test_fetches_total{source="a",outcome="ok"} 1
+test_fetches_total{source="b",outcome="ok"} 2
+# HELP test_items Items per source.
+# TYPE test_items gauge
+test_items{source="a"} 7
+# HELP test_duration_seconds Fetch duration.
+# TYPE test_duration_seconds histogram
+test_duration_seconds_bucket{source="a",le="0.5"} 2
+test_duration_seconds_bucket{source="a",le="1"} 3
+test_duration_seconds_bucket{source="a",le="2.5"} 4
+test_duration_seconds_bucket{source="a",le="+Inf"} 4
+test_duration_seconds_sum{source="a"} 3.3
+test_duration_seconds_count{source="a"} 4
+`
+	if got := render(t, r); got != want {
+		t.Errorf("WriteTo output:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestWriteToEscapes(t *testing.T) {
+	r := NewRegistry()
+	r.Counter("test_escaped_total", "Help with a \\ and a\nnewline.", "name").With("say \"hi\"\\\n").Inc()
+
+	want := `# HELP test_escaped_total Help with a \\ and a\nnewline.
+# TYPE test_escaped_total counter
+test_escaped_total{name="say \"hi\"\\\n"} 1
+`
+	if got := render(t, r); got != want {
+		t.Errorf("WriteTo output:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestObserveAboveLastBucket(t *testing.T) {
+	r := NewRegistry()
+	h := r.Histogram("test_seconds", "Test.", []float64{1, 5})
+	h.With().Observe(60)
+
+	want := `# HELP test_seconds Test.
+# TYPE test_seconds histogram
+test_seconds_bucket{le="1"} 0
+test_seconds_bucket{le="5"} 0
+test_seconds_bucket{le="+Inf"} 1
+test_seconds_sum 60
+test_seconds_count 1
+`
+	if got := render(t, r); got != want {
+		t.Errorf("WriteTo output:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestRegisterTwicePanics(t *testing.T) {
+	r := NewRegistry()
+	r.Counter("test_total", "Test.")
+	defer func() {
+		if recover() == nil {
+			t.Error("registering a name twice did not panic")
+		}
+	}()
+	r.Gauge("test_total", "Test.")
+}
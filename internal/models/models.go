@@ -1,17 +1,56 @@
 package models
 
-import "time"
+import (
+	"strings"
+	"time"
+)
 
 // Item represents a single feed item from any source
 type Item struct {
-	Title           string
-	Link            string
-	Description     string
-	Content         string
-	Author          string
-	Published       time.Time
-	SourceName      string
-	SourceType      string
+	Title       string       `json:"title"`
+	Link        string       `json:"link"`
+	Description string       `json:"description,omitempty"`
+	Content     string       `json:"content,omitempty"`
+	Author      string       `json:"author,omitempty"`
+	Published   time.Time    `json:"published"`
+	SourceName  string       `json:"source_name"`
+	SourceType  string       `json:"source_type"`
+	Thumbnail   string       `json:"thumbnail,omitempty"` // Image URL shown next to the title, if any
+	Views       int64        `json:"views,omitempty"`     // View count, if the source reports one
+	Rank        int          `json:"rank,omitempty"`      // Position in the source's own ranking (1-based), 0 if unranked
+	Score       int          `json:"score,omitempty"`     // Points or votes, if the source reports them
+	Comments    int          `json:"comments,omitempty"`  // Comment count, if the source reports one
+	Tags        []string     `json:"tags,omitempty"`
+	Domain      string       `json:"domain,omitempty"` // Domain of the linked content, if the source reports one
+	Attachments []Attachment `json:"attachments,omitempty"`
+}
+
+// Attachment is a media file attached to an item, such as a podcast episode.
+type Attachment struct {
+	URL      string        `json:"url"`
+	MIMEType string        `json:"mime_type"`
+	Length   int64         `json:"length,omitempty"`   // Size in bytes, 0 if unknown
+	Duration time.Duration `json:"duration,omitempty"` // Play time in nanoseconds, 0 if unknown
+}
+
+// IsAudio reports whether the attachment is an audio file.
+func (a Attachment) IsAudio() bool {
+	return strings.HasPrefix(a.MIMEType, "audio/")
+}
+
+// IsVideo reports whether the attachment is a video file.
+func (a Attachment) IsVideo() bool {
+	return strings.HasPrefix(a.MIMEType, "video/")
+}
+
+// PlayableAttachment returns the first audio or video attachment, or nil.
+func (i Item) PlayableAttachment() *Attachment {
+	for _, a := range i.Attachments {
+		if a.IsAudio() || a.IsVideo() {
+			return &a
+		}
+	}
+	return nil
 }
 
 // SourceState represents the runtime health of a source.
@@ -22,8 +61,34 @@ type SourceState struct {
 	LastAttemptAt       time.Time
 	LastSuccessAt       time.Time
 	LastError           string
+	ErrorClass          string        // Class of the last error, see fetcherr.Class
+	HTTPStatus          int           // HTTP status of the last failed response, if any
+	RetryAfter          time.Duration // Delay the server asked for with the last error
+	BlockedBy           string        // Anti-bot challenge provider that blocked the last fetch
 	ConsecutiveFailures int
 	Stale               bool
+	Pushed              bool          // Fed by WebSub pushes while polling is paused
+	Extract             *ExtractStats // Extraction stats of the last scrape, for scrapers
+	Degraded            bool          // Extraction dropped sharply versus the source's history
+	DegradedReason      string
+}
+
+// ExtractStats describes how well a scraper extracted items from the pages of
+// one fetch.
+type ExtractStats struct {
+	Pages         int            `json:"pages"`                    // Pages parsed
+	RowsSeen      int            `json:"rows_seen"`                // Candidate rows (topics, table rows) found
+	RowsParsed    int            `json:"rows_parsed"`              // Rows that became items
+	MissingFields map[string]int `json:"missing_fields,omitempty"` // Rows skipped per missing or malformed field
+}
+
+// ParseRatio returns the share of seen rows that were parsed, or 1 if no rows
+// were seen.
+func (s ExtractStats) ParseRatio() float64 {
+	if s.RowsSeen == 0 {
+		return 1
+	}
+	return float64(s.RowsParsed) / float64(s.RowsSeen)
 }
 
 // Feed represents a collection of items from all sources.
@@ -36,17 +101,82 @@ type Feed struct {
 
 // SourceConfig represents configuration for a single source.
 type SourceConfig struct {
-	Name            string `yaml:"name"`
-	Type            string `yaml:"type"`
-	URL             string `yaml:"url"`
-	HomeURL         string `yaml:"home_url"`
-	Interval        int    `yaml:"interval"`
-	IntervalJitter  int    `yaml:"interval_jitter"`
-	NSFW            bool   `yaml:"nsfw"`
+	Name           string  `yaml:"name"`
+	Type           string  `yaml:"type"`
+	URL            string  `yaml:"url"`
+	HomeURL        string  `yaml:"home_url"`
+	Interval       int     `yaml:"interval"`
+	IntervalJitter int     `yaml:"interval_jitter"`
+	NSFW           bool    `yaml:"nsfw"`
+	Options        Options `yaml:"options"` // Typed settings; see GET /api/v1/source-types
+}
+
+// IsNSFW reports whether the source is marked NSFW, by field or option.
+func (c SourceConfig) IsNSFW() bool {
+	return c.Options.Bool("nsfw", c.NSFW)
 }
+
+// DisplayLimit returns how many items the dashboard shows for the source.
+func (c SourceConfig) DisplayLimit(fallback int) int {
+	if limit := c.Options.Int("display_limit", 0); limit > 0 {
+		return limit
+	}
+	return fallback
+}
+
+// TileSpan returns how many grid columns and rows the source's tile spans,
+// each 1 or 2.
+func (c SourceConfig) TileSpan() (cols, rows int) {
+	return min(max(c.Options.Int("tile_cols", 1), 1), 2), min(max(c.Options.Int("tile_rows", 1), 1), 2)
+}
+
+// ShowDescription reports whether the dashboard shows item excerpts.
+func (c SourceConfig) ShowDescription() bool {
+	return c.Options.Bool("show_description", false)
+}
+
 type Config struct {
-	Port               int             `yaml:"port"`
-	MinFetchInterval   int             `yaml:"min_fetch_interval"`
-	MaxSubscribers     int             `yaml:"max_subscribers"`
-	Sources            []SourceConfig  `yaml:"sources"`
+	Port             int                    `yaml:"port"`
+	MinFetchInterval int                    `yaml:"min_fetch_interval"`
+	MaxSubscribers   int                    `yaml:"max_subscribers"`
+	PublicBaseURL    string                 `yaml:"public_base_url"` // Externally reachable URL; enables WebSub push when set
+	HTTPProfiles     map[string]HTTPProfile `yaml:"http_profiles"`   // Referenced by the http_profile source option
+	Health           HealthConfig           `yaml:"health"`
+	Logging          LoggingConfig          `yaml:"logging"`
+	Sources          []SourceConfig         `yaml:"sources"`
+}
+
+// HealthConfig sets when sources count as stale or failing on /status, and
+// when that makes feedlet unhealthy. Zero counts keep the defaults.
+// MaxFailing is a pointer so that 0, unhealthy on any failing source, can be
+// told apart from unset.
+type HealthConfig struct {
+	StaleAfter   int      `yaml:"stale_after"`   // Fetch intervals without a success before a source is stale (default 3)
+	FailingAfter int      `yaml:"failing_after"` // Consecutive failures before a source is failing (default 3)
+	MaxFailing   *float64 `yaml:"max_failing"`   // Share of failing sources above which feedlet is unhealthy (default 0.5)
+}
+
+// LoggingConfig sets the log level and format and how the log file is
+// rotated. Zero values keep the defaults.
+type LoggingConfig struct {
+	Level      string `yaml:"level"`       // debug, info, warn or error (default info)
+	Format     string `yaml:"format"`      // text or json (default text)
+	MaxSize    int    `yaml:"max_size"`    // Megabytes before the log file is rotated (default 10)
+	MaxBackups int    `yaml:"max_backups"` // Rotated files kept (default 3)
+	MaxAge     int    `yaml:"max_age"`     // Days rotated files are kept (default 3)
+	Compress   bool   `yaml:"compress"`    // Gzip rotated files
+}
+
+// HTTPProfile configures how requests to a site are made. Zero values keep
+// the defaults of the shared client.
+type HTTPProfile struct {
+	Headers        map[string]string `yaml:"headers"`
+	UserAgent      string            `yaml:"user_agent"`      // Static User-Agent instead of the rotating browser pool
+	CookieFile     string            `yaml:"cookie_file"`     // Netscape cookies.txt file, loaded at startup
+	Proxy          string            `yaml:"proxy"`           // http://, https:// or socks5:// proxy URL
+	CABundle       string            `yaml:"ca_bundle"`       // PEM file with extra root certificates
+	HTTP2          bool              `yaml:"http2"`           // Allow HTTP/2 (off by default, see httpclient)
+	Timeout        int               `yaml:"timeout"`         // Per-request timeout in seconds
+	ConnectTimeout int               `yaml:"connect_timeout"` // Dial and TLS handshake timeout in seconds
+	MaxBodyBytes   int64             `yaml:"max_body_bytes"`  // Largest response body read
 }
@@ -0,0 +1,286 @@
+package models
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// OptionKind is the value type of a source option.
+type OptionKind string
+
+const (
+	OptionString   OptionKind = "string"
+	OptionInt      OptionKind = "int"
+	OptionBool     OptionKind = "bool"
+	OptionDuration OptionKind = "duration" // Go duration string, e.g. "24h"
+	OptionList     OptionKind = "list"     // List of strings, or a comma separated string
+	OptionMap      OptionKind = "map"      // String to string map
+)
+
+// Options holds typed per-source settings. Values are whatever the config
+// holds: strings, numbers, booleans, lists or maps. The accessors convert
+// them and fall back to a default when the key is missing or malformed;
+// NormalizeOption reports malformed values.
+type Options map[string]any
+
+// Has reports whether key is set.
+func (o Options) Has(key string) bool {
+	_, ok := o[key]
+	return ok
+}
+
+// String returns the option as a string.
+func (o Options) String(key, fallback string) string {
+	if text, ok := o.Text(key); ok {
+		return text
+	}
+	return fallback
+}
+
+// Int returns the option as an integer.
+func (o Options) Int(key string, fallback int) int {
+	value, ok := o[key]
+	if !ok {
+		return fallback
+	}
+	n, err := optionInt(value)
+	if err != nil {
+		return fallback
+	}
+	return n
+}
+
+// Bool returns the option as a boolean. Strings like "1", "true" and "yes"
+// are accepted.
+func (o Options) Bool(key string, fallback bool) bool {
+	value, ok := o[key]
+	if !ok {
+		return fallback
+	}
+	b, err := optionBool(value)
+	if err != nil {
+		return fallback
+	}
+	return b
+}
+
+// Duration returns the option as a duration. Numbers are seconds.
+func (o Options) Duration(key string, fallback time.Duration) time.Duration {
+	value, ok := o[key]
+	if !ok {
+		return fallback
+	}
+	d, err := optionDuration(value)
+	if err != nil {
+		return fallback
+	}
+	return d
+}
+
+// Strings returns the option as a list of non-empty, trimmed strings.
+func (o Options) Strings(key string) []string {
+	value, ok := o[key]
+	if !ok {
+		return nil
+	}
+	list, err := optionList(value)
+	if err != nil {
+		return nil
+	}
+	return list
+}
+
+// StringMap returns the option as a string map.
+func (o Options) StringMap(key string) map[string]string {
+	value, ok := o[key]
+	if !ok {
+		return nil
+	}
+	m, err := optionMap(value)
+	if err != nil {
+		return nil
+	}
+	return m
+}
+
+// Text returns the option in the form it would take as a URL query
+// parameter: lists are comma separated and booleans are "1" or "0".
+func (o Options) Text(key string) (string, bool) {
+	value, ok := o[key]
+	if !ok {
+		return "", false
+	}
+
+	switch v := value.(type) {
+	case string:
+		return v, true
+	case bool:
+		if v {
+			return "1", true
+		}
+		return "0", true
+	case time.Duration:
+		return v.String(), true
+	case []string, []any:
+		list, err := optionList(v)
+		if err != nil {
+			return "", false
+		}
+		return strings.Join(list, ","), true
+	default:
+		if n, err := optionInt(v); err == nil {
+			return strconv.Itoa(n), true
+		}
+		return fmt.Sprint(v), true
+	}
+}
+
+// NormalizeOption converts a config value to the Go type used for kind:
+// string, int, bool, time.Duration, []string or map[string]string.
+func NormalizeOption(value any, kind OptionKind) (any, error) {
+	switch kind {
+	case OptionString:
+		switch v := value.(type) {
+		case string:
+			return v, nil
+		case int, int64, float64, bool:
+			return fmt.Sprint(v), nil
+		default:
+			return nil, fmt.Errorf("expected a string, got %T", value)
+		}
+	case OptionInt:
+		return optionInt(value)
+	case OptionBool:
+		return optionBool(value)
+	case OptionDuration:
+		return optionDuration(value)
+	case OptionList:
+		return optionList(value)
+	case OptionMap:
+		return optionMap(value)
+	default:
+		return nil, fmt.Errorf("unknown option kind %q", kind)
+	}
+}
+
+// Keys returns the option names in sorted order.
+func (o Options) Keys() []string {
+	keys := make([]string, 0, len(o))
+	for key := range o {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func optionInt(value any) (int, error) {
+	switch v := value.(type) {
+	case int:
+		return v, nil
+	case int64:
+		return int(v), nil
+	case float64:
+		if v != math.Trunc(v) {
+			return 0, fmt.Errorf("expected an integer, got %v", v)
+		}
+		return int(v), nil
+	case string:
+		n, err := strconv.Atoi(strings.TrimSpace(v))
+		if err != nil {
+			return 0, fmt.Errorf("expected an integer, got %q", v)
+		}
+		return n, nil
+	default:
+		return 0, fmt.Errorf("expected an integer, got %T", value)
+	}
+}
+
+func optionBool(value any) (bool, error) {
+	switch v := value.(type) {
+	case bool:
+		return v, nil
+	case int:
+		return v != 0, nil
+	case string:
+		switch strings.ToLower(strings.TrimSpace(v)) {
+		case "1", "true", "yes", "on":
+			return true, nil
+		case "0", "false", "no", "off":
+			return false, nil
+		}
+		return false, fmt.Errorf("expected a boolean, got %q", v)
+	default:
+		return false, fmt.Errorf("expected a boolean, got %T", value)
+	}
+}
+
+func optionDuration(value any) (time.Duration, error) {
+	switch v := value.(type) {
+	case time.Duration:
+		return v, nil
+	case string:
+		d, err := time.ParseDuration(strings.TrimSpace(v))
+		if err != nil {
+			return 0, fmt.Errorf("expected a duration, got %q", v)
+		}
+		return d, nil
+	default:
+		seconds, err := optionInt(v)
+		if err != nil {
+			return 0, fmt.Errorf("expected a duration, got %T", value)
+		}
+		return time.Duration(seconds) * time.Second, nil
+	}
+}
+
+func optionList(value any) ([]string, error) {
+	var raw []string
+	switch v := value.(type) {
+	case string:
+		raw = strings.Split(v, ",")
+	case []string:
+		raw = v
+	case []any:
+		raw = make([]string, 0, len(v))
+		for _, elem := range v {
+			s, ok := elem.(string)
+			if !ok {
+				return nil, fmt.Errorf("expected a list of strings, got a %T element", elem)
+			}
+			raw = append(raw, s)
+		}
+	default:
+		return nil, fmt.Errorf("expected a list, got %T", value)
+	}
+
+	list := make([]string, 0, len(raw))
+	for _, s := range raw {
+		if s = strings.TrimSpace(s); s != "" {
+			list = append(list, s)
+		}
+	}
+	return list, nil
+}
+
+func optionMap(value any) (map[string]string, error) {
+	switch v := value.(type) {
+	case map[string]string:
+		return v, nil
+	case map[string]any:
+		m := make(map[string]string, len(v))
+		for key, elem := range v {
+			s, ok := elem.(string)
+			if !ok {
+				return nil, fmt.Errorf("expected string values, got %T for %q", elem, key)
+			}
+			m[key] = s
+		}
+		return m, nil
+	default:
+		return nil, fmt.Errorf("expected a map, got %T", value)
+	}
+}
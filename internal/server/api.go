@@ -0,0 +1,131 @@
+package server
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/ppowo/feedlet/internal/models"
+	"github.com/ppowo/feedlet/internal/source"
+)
+
+type sourceTypeView struct {
+	Type         string              `json:"type"`
+	Description  string              `json:"description"`
+	Params       []source.Param      `json:"params"`
+	Policy       sourcePolicyView    `json:"policy"`
+	Capabilities source.Capabilities `json:"capabilities"`
+}
+
+// sourcePolicyView reports durations in seconds, like the config does.
+type sourcePolicyView struct {
+	Interval       int     `json:"interval,omitempty"`
+	StartupStagger int     `json:"startup_stagger,omitempty"`
+	BackoffCap     int     `json:"backoff_cap,omitempty"`
+	HostSpacing    float64 `json:"host_spacing,omitempty"`
+}
+
+func (s *Server) handleSourceTypes(w http.ResponseWriter, r *http.Request) {
+	types := source.Types()
+	views := make([]sourceTypeView, 0, len(types))
+	for _, info := range types {
+		params := append([]source.Param{}, info.Params...)
+		for _, param := range source.GenericParams {
+			if param.Name == "min_score" && !info.Capabilities.Scores {
+				continue
+			}
+			params = append(params, param)
+		}
+		views = append(views, sourceTypeView{
+			Type:        info.Type,
+			Description: info.Description,
+			Params:      params,
+			Policy: sourcePolicyView{
+				Interval:       int(info.Policy.Interval.Seconds()),
+				StartupStagger: int(info.Policy.StartupStagger.Seconds()),
+				BackoffCap:     int(info.Policy.BackoffCap.Seconds()),
+				HostSpacing:    info.Policy.HostSpacing.Seconds(),
+			},
+			Capabilities: info.Capabilities,
+		})
+	}
+
+	writeJSON(w, views)
+}
+
+// sourceStateView is the health and extraction diagnostics of one source.
+type sourceStateView struct {
+	Name                string               `json:"name"`
+	Type                string               `json:"type"`
+	Host                string               `json:"host,omitempty"`
+	LastAttemptAt       *time.Time           `json:"last_attempt_at,omitempty"`
+	LastSuccessAt       *time.Time           `json:"last_success_at,omitempty"`
+	Stale               bool                 `json:"stale"`
+	ConsecutiveFailures int                  `json:"consecutive_failures"`
+	LastError           string               `json:"last_error,omitempty"`
+	ErrorClass          string               `json:"error_class,omitempty"`
+	HTTPStatus          int                  `json:"http_status,omitempty"`
+	RetryAfter          int                  `json:"retry_after,omitempty"`
+	BlockedBy           string               `json:"blocked_by,omitempty"`
+	Degraded            bool                 `json:"degraded"`
+	DegradedReason      string               `json:"degraded_reason,omitempty"`
+	Extract             *models.ExtractStats `json:"extract,omitempty"`
+	ParseRatio          *float64             `json:"parse_ratio,omitempty"`
+}
+
+func (s *Server) handleSources(w http.ResponseWriter, r *http.Request) {
+	feed := s.fetcher.GetFeed()
+
+	views := make([]sourceStateView, 0, len(s.sourceConfigs))
+	for _, cfg := range s.sourceConfigs {
+		state, ok := feed.SourceStates[cfg.Name]
+		if !ok {
+			continue
+		}
+		view := sourceStateView{
+			Name:                state.Name,
+			Type:                state.Type,
+			Host:                state.Host,
+			Stale:               state.Stale,
+			ConsecutiveFailures: state.ConsecutiveFailures,
+			LastError:           state.LastError,
+			ErrorClass:          state.ErrorClass,
+			HTTPStatus:          state.HTTPStatus,
+			RetryAfter:          int(state.RetryAfter.Seconds()),
+			BlockedBy:           state.BlockedBy,
+			Degraded:            state.Degraded,
+			DegradedReason:      state.DegradedReason,
+			Extract:             state.Extract,
+		}
+		if !state.LastAttemptAt.IsZero() {
+			view.LastAttemptAt = &state.LastAttemptAt
+		}
+		if !state.LastSuccessAt.IsZero() {
+			view.LastSuccessAt = &state.LastSuccessAt
+		}
+		if state.Extract != nil {
+			ratio := state.Extract.ParseRatio()
+			view.ParseRatio = &ratio
+		}
+		views = append(views, view)
+	}
+
+	writeJSON(w, views)
+}
+
+// handleFeed serves the dashboard content for clients such as feedlet tui.
+func (s *Server) handleFeed(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, BuildPage(s.fetcher.GetFeed(), s.sourceConfigs, s.defaultLimit))
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-cache")
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(v); err != nil {
+		slog.Warn("Failed to write JSON response", "error", err)
+	}
+}
@@ -0,0 +1,48 @@
+package server
+
+import (
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+const excerptLength = 240
+
+// excerpt reduces an item description, which is often HTML, to a short line
+// of plain text.
+func excerpt(description string) string {
+	var b strings.Builder
+	tokenizer := html.NewTokenizer(strings.NewReader(description))
+	skip := 0
+
+loop:
+	for b.Len() < excerptLength*4 {
+		switch tokenizer.Next() {
+		case html.ErrorToken:
+			break loop
+		case html.StartTagToken:
+			if name, _ := tokenizer.TagName(); string(name) == "script" || string(name) == "style" {
+				skip++
+			}
+			b.WriteByte(' ')
+		case html.EndTagToken:
+			if name, _ := tokenizer.TagName(); (string(name) == "script" || string(name) == "style") && skip > 0 {
+				skip--
+			}
+			b.WriteByte(' ')
+		case html.SelfClosingTagToken:
+			b.WriteByte(' ')
+		case html.TextToken:
+			if skip == 0 {
+				b.Write(tokenizer.Text())
+			}
+		}
+	}
+
+	text := strings.Join(strings.Fields(b.String()), " ")
+	runes := []rune(text)
+	if len(runes) <= excerptLength {
+		return text
+	}
+	return strings.TrimSpace(string(runes[:excerptLength])) + "..."
+}
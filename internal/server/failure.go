@@ -0,0 +1,49 @@
+package server
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/ppowo/feedlet/internal/models"
+	"github.com/ppowo/feedlet/internal/source/fetcherr"
+)
+
+// failureIcons are the tile icons of the fetch error classes.
+var failureIcons = map[fetcherr.Class]string{
+	fetcherr.Network:     "🔌",
+	fetcherr.Timeout:     "⏱️",
+	fetcherr.DNS:         "🧭",
+	fetcherr.TLS:         "🔒",
+	fetcherr.HTTPStatus:  "🚫",
+	fetcherr.RateLimited: "🐢",
+	fetcherr.Blocked:     "🛡️",
+	fetcherr.Parse:       "🧩",
+	fetcherr.Empty:       "📭",
+	fetcherr.SchemaDrift: "🏗️",
+	fetcherr.Command:     "⚙️",
+}
+
+// describeFailure returns the icon and a short label for the last error of
+// a source, e.g. "HTTP 404" or "blocked by Cloudflare challenge".
+func describeFailure(state models.SourceState) (icon, label string) {
+	class := fetcherr.Class(state.ErrorClass)
+	if class == "" {
+		class = fetcherr.Unknown
+	}
+
+	icon, ok := failureIcons[class]
+	if !ok {
+		icon = "⚠️"
+	}
+
+	label = class.Label()
+	switch {
+	case class == fetcherr.HTTPStatus && state.HTTPStatus != 0:
+		label = fmt.Sprintf("HTTP %d", state.HTTPStatus)
+	case class == fetcherr.Blocked && state.BlockedBy != "":
+		label = fmt.Sprintf("blocked by %s challenge", state.BlockedBy)
+	case class == fetcherr.RateLimited && state.RetryAfter > 0:
+		label = fmt.Sprintf("rate limited, retry after %s", state.RetryAfter.Round(time.Second))
+	}
+	return icon, label
+}
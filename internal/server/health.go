@@ -0,0 +1,194 @@
+package server
+
+import (
+	"net/http"
+	"runtime"
+	"runtime/debug"
+	"time"
+
+	"github.com/ppowo/feedlet/internal/models"
+	"github.com/ppowo/feedlet/internal/source"
+)
+
+const (
+	defaultStaleAfter   = 3
+	defaultFailingAfter = 3
+	defaultMaxFailing   = 0.5
+
+	// fallbackInterval is the fetcher's interval for types without a policy.
+	fallbackInterval = 30 * time.Minute
+)
+
+// startedAt is when the process started, for the uptime on /status.
+var startedAt = time.Now()
+
+// Source health on /status.
+const (
+	sourcePending = "pending" // Not fetched yet
+	sourceHealthy = "healthy"
+	sourceStale   = "stale"   // No success for StaleAfter intervals
+	sourceFailing = "failing" // FailingAfter consecutive failures
+)
+
+// SetHealth sets the thresholds /status judges sources by. Zero counts and a
+// nil MaxFailing keep the defaults.
+func (s *Server) SetHealth(cfg models.HealthConfig) {
+	s.health = healthThreshold{
+		StaleAfter:   cfg.StaleAfter,
+		FailingAfter: cfg.FailingAfter,
+		MaxFailing:   defaultMaxFailing,
+	}
+	if s.health.StaleAfter <= 0 {
+		s.health.StaleAfter = defaultStaleAfter
+	}
+	if s.health.FailingAfter <= 0 {
+		s.health.FailingAfter = defaultFailingAfter
+	}
+	if cfg.MaxFailing != nil {
+		s.health.MaxFailing = *cfg.MaxFailing
+	}
+}
+
+// handleHealthz reports that the process is up and serving.
+func (s *Server) handleHealthz(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Write([]byte("ok\n"))
+}
+
+// handleReadyz reports ready once any source has been fetched successfully,
+// so the dashboard has something to show.
+func (s *Server) handleReadyz(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-cache")
+
+	for _, state := range s.fetcher.GetFeed().SourceStates {
+		if !state.LastSuccessAt.IsZero() {
+			w.Write([]byte("ready\n"))
+			return
+		}
+	}
+	w.WriteHeader(http.StatusServiceUnavailable)
+	w.Write([]byte("not ready: no successful fetch yet\n"))
+}
+
+type statusView struct {
+	Status        string          `json:"status"` // ok, degraded or unhealthy
+	Version       string          `json:"version"`
+	Build         buildView       `json:"build"`
+	StartedAt     time.Time       `json:"started_at"`
+	UptimeSeconds int64           `json:"uptime_seconds"`
+	Goroutines    int             `json:"goroutines"`
+	Sources       sourceCounts    `json:"sources"`
+	Stale         []string        `json:"stale,omitempty"`
+	Failing       []string        `json:"failing,omitempty"`
+	Thresholds    healthThreshold `json:"thresholds"`
+}
+
+type buildView struct {
+	GoVersion    string `json:"go_version"`
+	Revision     string `json:"revision,omitempty"`
+	RevisionTime string `json:"revision_time,omitempty"`
+	Modified     bool   `json:"modified,omitempty"`
+}
+
+type sourceCounts struct {
+	Total   int `json:"total"`
+	Healthy int `json:"healthy"`
+	Stale   int `json:"stale"`
+	Failing int `json:"failing"`
+	Pending int `json:"pending"`
+}
+
+type healthThreshold struct {
+	StaleAfter   int     `json:"stale_after"`
+	FailingAfter int     `json:"failing_after"`
+	MaxFailing   float64 `json:"max_failing"`
+}
+
+// handleStatus reports the process and a summary of source health. It
+// answers 200 even when unhealthy; /readyz is the probe to act on.
+func (s *Server) handleStatus(w http.ResponseWriter, r *http.Request) {
+	now := time.Now()
+	feed := s.fetcher.GetFeed()
+
+	view := statusView{
+		Status:        "ok",
+		Version:       "(unknown)",
+		Build:         buildView{GoVersion: runtime.Version()},
+		StartedAt:     startedAt,
+		UptimeSeconds: int64(now.Sub(startedAt).Seconds()),
+		Goroutines:    runtime.NumGoroutine(),
+		Thresholds:    s.health,
+	}
+	if info, ok := debug.ReadBuildInfo(); ok {
+		view.Version = info.Main.Version
+		for _, setting := range info.Settings {
+			switch setting.Key {
+			case "vcs.revision":
+				view.Build.Revision = setting.Value
+			case "vcs.time":
+				view.Build.RevisionTime = setting.Value
+			case "vcs.modified":
+				view.Build.Modified = setting.Value == "true"
+			}
+		}
+	}
+
+	for _, cfg := range s.sourceConfigs {
+		view.Sources.Total++
+		switch s.sourceHealth(cfg, feed.SourceStates[cfg.Name], now) {
+		case sourcePending:
+			view.Sources.Pending++
+		case sourceHealthy:
+			view.Sources.Healthy++
+		case sourceStale:
+			view.Sources.Stale++
+			view.Stale = append(view.Stale, cfg.Name)
+		case sourceFailing:
+			view.Sources.Failing++
+			view.Failing = append(view.Failing, cfg.Name)
+		}
+	}
+
+	switch {
+	case view.Sources.Total > 0 && float64(view.Sources.Failing) > s.health.MaxFailing*float64(view.Sources.Total):
+		view.Status = "unhealthy"
+	case view.Sources.Failing > 0 || view.Sources.Stale > 0:
+		view.Status = "degraded"
+	}
+
+	writeJSON(w, view)
+}
+
+// sourceHealth judges a source by its state. A source is failing after
+// FailingAfter consecutive failures and stale when its last success is more
+// than StaleAfter fetch intervals ago. Sources fed by WebSub are not polled
+// and only hear from their hub when the feed changes, so they are never stale.
+func (s *Server) sourceHealth(cfg models.SourceConfig, state models.SourceState, now time.Time) string {
+	if state.ConsecutiveFailures >= s.health.FailingAfter {
+		return sourceFailing
+	}
+	if state.Pushed {
+		return sourceHealthy
+	}
+	if state.LastSuccessAt.IsZero() {
+		if state.LastAttemptAt.IsZero() {
+			return sourcePending
+		}
+		return sourceStale
+	}
+
+	interval := time.Duration(cfg.Interval+cfg.IntervalJitter) * time.Second
+	if cfg.Interval <= 0 {
+		interval = fallbackInterval
+		if info, ok := source.Lookup(cfg.Type); ok && info.Policy.Interval > 0 {
+			interval = info.Policy.Interval
+		}
+		interval += time.Duration(cfg.IntervalJitter) * time.Second
+	}
+	if now.Sub(state.LastSuccessAt) > time.Duration(s.health.StaleAfter)*interval {
+		return sourceStale
+	}
+	return sourceHealthy
+}
@@ -0,0 +1,56 @@
+package server
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ppowo/feedlet/internal/models"
+)
+
+func TestSetHealthDefaults(t *testing.T) {
+	zero := 0.0
+	tests := []struct {
+		name string
+		cfg  models.HealthConfig
+		want healthThreshold
+	}{
+		{"unset", models.HealthConfig{}, healthThreshold{StaleAfter: 3, FailingAfter: 3, MaxFailing: 0.5}},
+		{"set", models.HealthConfig{StaleAfter: 5, FailingAfter: 2, MaxFailing: &zero}, healthThreshold{StaleAfter: 5, FailingAfter: 2, MaxFailing: 0}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var s Server
+			s.SetHealth(tt.cfg)
+			if s.health != tt.want {
+				t.Errorf("thresholds = %+v, want %+v", s.health, tt.want)
+			}
+		})
+	}
+}
+
+func TestSourceHealth(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	cfg := models.SourceConfig{Name: "s", Type: "rss", Interval: 600}
+	tests := []struct {
+		name  string
+		state models.SourceState
+		want  string
+	}{
+		{"never fetched", models.SourceState{}, sourcePending},
+		{"never succeeded", models.SourceState{LastAttemptAt: now}, sourceStale},
+		{"recent success", models.SourceState{LastSuccessAt: now.Add(-29 * time.Minute)}, sourceHealthy},
+		{"old success", models.SourceState{LastSuccessAt: now.Add(-31 * time.Minute)}, sourceStale},
+		{"failing", models.SourceState{LastSuccessAt: now, ConsecutiveFailures: 3}, sourceFailing},
+		{"pushed long ago", models.SourceState{LastSuccessAt: now.Add(-24 * time.Hour), Pushed: true}, sourceHealthy},
+		{"pushed but failing", models.SourceState{LastSuccessAt: now.Add(-24 * time.Hour), ConsecutiveFailures: 3, Pushed: true}, sourceFailing},
+	}
+	var s Server
+	s.SetHealth(models.HealthConfig{})
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := s.sourceHealth(cfg, tt.state, now); got != tt.want {
+				t.Errorf("sourceHealth = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,200 @@
+package server
+
+import (
+	"sort"
+	"time"
+
+	"github.com/dustin/go-humanize"
+	"github.com/ppowo/feedlet/internal/aggregator"
+	"github.com/ppowo/feedlet/internal/models"
+)
+
+// DefaultLimit is the number of items a tile shows unless the source sets
+// display_limit.
+const DefaultLimit = 4
+
+// Page is the content of the dashboard. It is rendered by the index
+// template, served as JSON by /api/v1/feed and drawn by the terminal UI.
+type Page struct {
+	Title     string    `json:"title"`
+	UpdatedAt time.Time `json:"updated_at"`
+	Sources   []Tile    `json:"sources"` // In display order
+}
+
+// Tile is one source on the dashboard.
+type Tile struct {
+	Name                string        `json:"name"`
+	HomeURL             string        `json:"home_url,omitempty"`
+	Items               []models.Item `json:"items"`
+	HasItems            bool          `json:"-"`
+	NSFW                bool          `json:"nsfw,omitempty"`
+	TileCols            int           `json:"tile_cols"`
+	TileRows            int           `json:"tile_rows"`
+	ShowDescription     bool          `json:"show_description,omitempty"`
+	NewestItemAge       time.Time     `json:"newest_item,omitzero"`
+	Error               string        `json:"error,omitempty"`
+	ErrorIcon           string        `json:"error_icon,omitempty"`
+	ErrorLabel          string        `json:"error_label,omitempty"`
+	BlockedBy           string        `json:"blocked_by,omitempty"`
+	Stale               bool          `json:"stale,omitempty"`
+	Degraded            bool          `json:"degraded,omitempty"`
+	DegradedReason      string        `json:"degraded_reason,omitempty"`
+	LastAttemptAt       time.Time     `json:"last_attempt_at,omitzero"`
+	LastSuccessAt       time.Time     `json:"last_success_at,omitzero"`
+	ConsecutiveFailures int           `json:"consecutive_failures,omitempty"`
+	HasEverSucceeded    bool          `json:"-"`
+	IsWaiting           bool          `json:"waiting,omitempty"`
+	StatusText          string        `json:"status"`
+	EmptyText           string        `json:"empty_text,omitempty"`
+	ShowErrorPanel      bool          `json:"-"`
+	Order               int           `json:"-"`
+}
+
+// BuildPage lays out feed as the dashboard shows it: configured sources
+// first, each limited to its display limit, then sorted by newest item.
+func BuildPage(feed models.Feed, sourceConfigs []models.SourceConfig, defaultLimit int) Page {
+	limits := make(map[string]int, len(sourceConfigs))
+	for _, cfg := range sourceConfigs {
+		limits[cfg.Name] = cfg.DisplayLimit(defaultLimit)
+	}
+	grouped := aggregator.Process(feed).LimitPerSource(defaultLimit, limits).GroupBySource()
+
+	applyState := func(dst *Tile, name string) {
+		if state, ok := feed.SourceStates[name]; ok {
+			dst.Error = state.LastError
+			dst.BlockedBy = state.BlockedBy
+			dst.Degraded = state.Degraded
+			dst.DegradedReason = state.DegradedReason
+			if state.LastError != "" {
+				dst.ErrorIcon, dst.ErrorLabel = describeFailure(state)
+			}
+			dst.Stale = state.Stale
+			dst.LastAttemptAt = state.LastAttemptAt
+			dst.LastSuccessAt = state.LastSuccessAt
+			dst.ConsecutiveFailures = state.ConsecutiveFailures
+			dst.HasEverSucceeded = !state.LastSuccessAt.IsZero()
+		}
+		if dst.Error == "" {
+			if errMsg, ok := feed.Errors[name]; ok {
+				dst.Error = errMsg
+				dst.Stale = true
+				dst.ErrorIcon, dst.ErrorLabel = describeFailure(models.SourceState{})
+			}
+		}
+	}
+
+	sourceByName := make(map[string]*Tile, len(sourceConfigs))
+	ordered := make([]*Tile, 0, len(sourceConfigs))
+
+	for i, cfg := range sourceConfigs {
+		cols, rows := cfg.TileSpan()
+		src := &Tile{
+			Name:            cfg.Name,
+			HomeURL:         cfg.HomeURL,
+			Items:           []models.Item{},
+			NSFW:            cfg.IsNSFW(),
+			TileCols:        cols,
+			TileRows:        rows,
+			ShowDescription: cfg.ShowDescription(),
+			Order:           i,
+		}
+		applyState(src, cfg.Name)
+		sourceByName[cfg.Name] = src
+		ordered = append(ordered, src)
+	}
+
+	ensureSource := func(name string) *Tile {
+		if src, ok := sourceByName[name]; ok {
+			return src
+		}
+
+		src := &Tile{
+			Name:     name,
+			Items:    []models.Item{},
+			TileCols: 1,
+			TileRows: 1,
+			Order:    len(ordered),
+		}
+
+		sourceByName[name] = src
+		ordered = append(ordered, src)
+		return src
+	}
+
+	for name, items := range grouped {
+		src := ensureSource(name)
+
+		src.Items = items
+		src.HasItems = len(items) > 0
+
+		for i, item := range items {
+			if i > 0 || src.NewestItemAge.Before(item.Published) {
+				src.NewestItemAge = item.Published
+			}
+		}
+	}
+
+	for name := range feed.SourceStates {
+		ensureSource(name)
+	}
+	for name := range feed.Errors {
+		ensureSource(name)
+	}
+
+	for _, src := range ordered {
+		src.IsWaiting = !src.HasItems && !src.HasEverSucceeded && src.Error == "" && src.LastAttemptAt.IsZero()
+		src.ShowErrorPanel = !src.HasItems && src.Error != ""
+
+		switch {
+		case src.BlockedBy != "":
+			src.StatusText = "blocked by challenge"
+		case src.Stale && src.HasEverSucceeded:
+			src.StatusText = humanize.Time(src.LastSuccessAt)
+		case src.Stale && !src.LastAttemptAt.IsZero():
+			src.StatusText = "refresh failed"
+		case src.HasEverSucceeded:
+			src.StatusText = humanize.Time(src.LastSuccessAt)
+		case src.IsWaiting:
+			src.StatusText = "waiting"
+		case !src.LastAttemptAt.IsZero() && src.Error == "":
+			src.StatusText = "fetching"
+		default:
+			src.StatusText = "waiting"
+		}
+
+		if !src.HasItems && !src.ShowErrorPanel {
+			switch {
+			case src.IsWaiting:
+				src.EmptyText = "Waiting for first fetch..."
+			case src.HasEverSucceeded || !src.LastAttemptAt.IsZero():
+				src.EmptyText = "No recent items"
+			default:
+				src.EmptyText = "Waiting for first fetch..."
+			}
+		}
+	}
+
+	sources := make([]Tile, 0, len(ordered))
+	for _, src := range ordered {
+		sources = append(sources, *src)
+	}
+
+	sort.SliceStable(sources, func(i, j int) bool {
+		if sources[i].NewestItemAge.Equal(sources[j].NewestItemAge) {
+			return sources[i].Order < sources[j].Order
+		}
+		if sources[i].NewestItemAge.IsZero() {
+			return false
+		}
+		if sources[j].NewestItemAge.IsZero() {
+			return true
+		}
+		return sources[i].NewestItemAge.After(sources[j].NewestItemAge)
+	})
+
+	return Page{
+		Sources:   sources,
+		UpdatedAt: feed.UpdatedAt,
+		Title:     "Feedlet",
+	}
+}
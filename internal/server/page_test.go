@@ -0,0 +1,46 @@
+package server
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ppowo/feedlet/internal/models"
+	"github.com/ppowo/feedlet/web"
+)
+
+func TestIndexRendersItemsWithoutLink(t *testing.T) {
+	sources := []models.SourceConfig{{Name: "Newsletters", Type: "mailbox"}}
+	s, err := New(nil, web.IndexTemplate, 0, sources, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	published := time.Now().Add(-time.Hour)
+	feed := models.Feed{
+		Items: []models.Item{
+			{Title: "Weekly digest", Content: "<p>This week: <b>three</b> new releases.</p>", Published: published, SourceName: "Newsletters", SourceType: "mailbox"},
+			{Title: "Launch notes", Link: "https://news.example/launch", Content: "<p>Hidden body</p>", Published: published.Add(-time.Hour), SourceName: "Newsletters", SourceType: "mailbox"},
+		},
+		SourceStates: map[string]models.SourceState{"Newsletters": {Name: "Newsletters", LastSuccessAt: published}},
+	}
+
+	var b strings.Builder
+	if err := s.tmpl.Execute(&b, BuildPage(feed, sources, 10)); err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	page := b.String()
+
+	if strings.Contains(page, "ZgotmplZ") {
+		t.Error("page holds a link html/template rejected")
+	}
+	if !strings.Contains(page, `<span title="Weekly digest"`) {
+		t.Error("item without a link not rendered as plain text")
+	}
+	if !strings.Contains(page, "This week: three new releases.") {
+		t.Error("item without a link does not show its content")
+	}
+	if !strings.Contains(page, `href="https://news.example/launch"`) || strings.Contains(page, "Hidden body") {
+		t.Error("linked item should link out and keep its content hidden")
+	}
+}
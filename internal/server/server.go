@@ -4,15 +4,15 @@ import (
 	"context"
 	"fmt"
 	"html/template"
-	"log"
+	"log/slog"
 	"net"
 	"net/http"
 	"sort"
 	"time"
 
 	"github.com/dustin/go-humanize"
-	"github.com/ppowo/feedlet/internal/aggregator"
 	"github.com/ppowo/feedlet/internal/fetcher"
+	"github.com/ppowo/feedlet/internal/metrics"
 	"github.com/ppowo/feedlet/internal/models"
 )
 
@@ -22,6 +22,8 @@ type Server struct {
 	port          int
 	sourceConfigs []models.SourceConfig
 	defaultLimit  int
+	health        healthThreshold
+	mux           *http.ServeMux
 	httpServer    *http.Server
 }
 
@@ -30,6 +32,11 @@ func New(f *fetcher.Fetcher, templateContent string, port int, sourceConfigs []m
 	funcMap := template.FuncMap{
 		"formatTime":    func(t time.Time) string { return t.Format("Jan 2, 2006 3:04 PM") },
 		"formatTimeAgo": humanize.Time,
+		"formatCount":   humanize.Comma,
+		"formatDuration": func(d time.Duration) string {
+			return d.Round(time.Second).String()
+		},
+		"excerpt": excerpt,
 	}
 
 	tmpl, err := template.New("index.html").Funcs(funcMap).Parse(templateContent)
@@ -44,28 +51,41 @@ func New(f *fetcher.Fetcher, templateContent string, port int, sourceConfigs []m
 		sourceConfigs: append([]models.SourceConfig(nil), sourceConfigs...),
 		defaultLimit:  defaultLimit,
 	}
+	s.SetHealth(models.HealthConfig{})
 
-	mux := http.NewServeMux()
-	mux.HandleFunc("/", s.handleIndex)
-	mux.HandleFunc("/events", s.handleSSE)
+	s.mux = http.NewServeMux()
+	s.mux.HandleFunc("/", s.handleIndex)
+	s.mux.HandleFunc("/events", s.handleSSE)
+	s.mux.HandleFunc("GET /api/v1/source-types", s.handleSourceTypes)
+	s.mux.HandleFunc("GET /api/v1/sources", s.handleSources)
+	s.mux.HandleFunc("GET /api/v1/feed", s.handleFeed)
+	s.mux.Handle("GET /metrics", metrics.Default)
+	s.mux.HandleFunc("GET /healthz", s.handleHealthz)
+	s.mux.HandleFunc("GET /readyz", s.handleReadyz)
+	s.mux.HandleFunc("GET /status", s.handleStatus)
 
 	s.httpServer = &http.Server{
 		Addr:    fmt.Sprintf(":%d", port),
-		Handler: mux,
+		Handler: instrument(s.mux),
 	}
 
 	return s, nil
 }
 
+// Handle mounts an additional handler, such as the WebSub callback endpoint.
+func (s *Server) Handle(pattern string, handler http.Handler) {
+	s.mux.Handle(pattern, handler)
+}
+
 func (s *Server) Start() error {
-	log.Printf("Starting Feedlet")
-	log.Printf("Dashboard: http://localhost:%d", s.port)
+	slog.Info("Starting Feedlet")
+	slog.Info("Dashboard", "url", fmt.Sprintf("http://localhost:%d", s.port))
 
 	for _, url := range localAccessURLs(s.port) {
-		log.Printf("Dashboard (LAN): %s", url)
+		slog.Info("Dashboard (LAN)", "url", url)
 	}
 
-	log.Printf("Press Ctrl+C to stop")
+	slog.Info("Press Ctrl+C to stop")
 
 	err := s.httpServer.ListenAndServe()
 	if err != nil && err != http.ErrServerClosed {
@@ -76,7 +96,7 @@ func (s *Server) Start() error {
 }
 
 func (s *Server) Shutdown(ctx context.Context) error {
-	log.Println("Shutting down HTTP server...")
+	slog.Info("Shutting down HTTP server")
 	return s.httpServer.Shutdown(ctx)
 }
 
@@ -133,12 +153,15 @@ func (s *Server) handleSSE(w http.ResponseWriter, r *http.Request) {
 
 	updateCh, err := s.fetcher.Subscribe()
 	if err != nil {
-		log.Printf("Failed to subscribe: %v", err)
+		slog.Warn("Failed to subscribe", "error", err)
 		http.Error(w, "Server at capacity", http.StatusServiceUnavailable)
 		return
 	}
 	defer s.fetcher.Unsubscribe(updateCh)
 
+	sseSubscribers.Add(1)
+	defer sseSubscribers.Add(-1)
+
 	fmt.Fprintf(w, "data: ping\n\n")
 	if f, ok := w.(http.Flusher); ok {
 		f.Flush()
@@ -158,160 +181,9 @@ func (s *Server) handleSSE(w http.ResponseWriter, r *http.Request) {
 }
 
 func (s *Server) handleIndex(w http.ResponseWriter, r *http.Request) {
-	feed := s.fetcher.GetFeed()
-	grouped := aggregator.Process(feed).LimitPerSource(s.defaultLimit).GroupBySource()
-
-	type Source struct {
-		Name                string
-		HomeURL             string
-		Items               []any
-		HasItems            bool
-		NSFW                bool
-		NewestItemAge       time.Time
-		Error               string
-		Stale               bool
-		LastAttemptAt       time.Time
-		LastSuccessAt       time.Time
-		ConsecutiveFailures int
-		HasEverSucceeded    bool
-		IsWaiting           bool
-		StatusText          string
-		EmptyText           string
-		ShowErrorPanel      bool
-		Order               int
-	}
-
-	applyState := func(dst *Source, name string) {
-		if state, ok := feed.SourceStates[name]; ok {
-			dst.Error = state.LastError
-			dst.Stale = state.Stale
-			dst.LastAttemptAt = state.LastAttemptAt
-			dst.LastSuccessAt = state.LastSuccessAt
-			dst.ConsecutiveFailures = state.ConsecutiveFailures
-			dst.HasEverSucceeded = !state.LastSuccessAt.IsZero()
-		}
-		if dst.Error == "" {
-			if errMsg, ok := feed.Errors[name]; ok {
-				dst.Error = errMsg
-				dst.Stale = true
-			}
-		}
-	}
-
-	sourceByName := make(map[string]*Source, len(s.sourceConfigs))
-	ordered := make([]*Source, 0, len(s.sourceConfigs))
-
-	for i, cfg := range s.sourceConfigs {
-		src := &Source{
-			Name:    cfg.Name,
-			HomeURL: cfg.HomeURL,
-			Items:   []any{},
-			NSFW:    cfg.NSFW,
-			Order:   i,
-		}
-		applyState(src, cfg.Name)
-		sourceByName[cfg.Name] = src
-		ordered = append(ordered, src)
-	}
-
-	ensureSource := func(name string) *Source {
-		if src, ok := sourceByName[name]; ok {
-			return src
-		}
-
-		src := &Source{
-			Name:  name,
-			Items: []any{},
-			Order: len(ordered),
-		}
-
-		sourceByName[name] = src
-		ordered = append(ordered, src)
-		return src
-	}
-
-	for name, items := range grouped {
-		src := ensureSource(name)
-
-		src.Items = make([]any, len(items))
-		src.HasItems = len(items) > 0
-
-		for i, item := range items {
-			src.Items[i] = item
-			if i > 0 || src.NewestItemAge.Before(item.Published) {
-				src.NewestItemAge = item.Published
-			}
-		}
-	}
-
-	for name := range feed.SourceStates {
-		ensureSource(name)
-	}
-	for name := range feed.Errors {
-		ensureSource(name)
-	}
-
-	for _, src := range ordered {
-		src.IsWaiting = !src.HasItems && !src.HasEverSucceeded && src.Error == "" && src.LastAttemptAt.IsZero()
-		src.ShowErrorPanel = !src.HasItems && src.Error != ""
-
-		switch {
-		case src.Stale && src.HasEverSucceeded:
-			src.StatusText = humanize.Time(src.LastSuccessAt)
-		case src.Stale && !src.LastAttemptAt.IsZero():
-			src.StatusText = "refresh failed"
-		case src.HasEverSucceeded:
-			src.StatusText = humanize.Time(src.LastSuccessAt)
-		case src.IsWaiting:
-			src.StatusText = "waiting"
-		case !src.LastAttemptAt.IsZero() && src.Error == "":
-			src.StatusText = "fetching"
-		default:
-			src.StatusText = "waiting"
-		}
-
-		if !src.HasItems && !src.ShowErrorPanel {
-			switch {
-			case src.IsWaiting:
-				src.EmptyText = "Waiting for first fetch..."
-			case src.HasEverSucceeded || !src.LastAttemptAt.IsZero():
-				src.EmptyText = "No recent items"
-			default:
-				src.EmptyText = "Waiting for first fetch..."
-			}
-		}
-	}
-
-	sources := make([]Source, 0, len(ordered))
-	for _, src := range ordered {
-		sources = append(sources, *src)
-	}
-
-	sort.SliceStable(sources, func(i, j int) bool {
-		if sources[i].NewestItemAge.Equal(sources[j].NewestItemAge) {
-			return sources[i].Order < sources[j].Order
-		}
-		if sources[i].NewestItemAge.IsZero() {
-			return false
-		}
-		if sources[j].NewestItemAge.IsZero() {
-			return true
-		}
-		return sources[i].NewestItemAge.After(sources[j].NewestItemAge)
-	})
-
-	data := struct {
-		Sources   []Source
-		UpdatedAt time.Time
-		Title     string
-	}{
-		Sources:   sources,
-		UpdatedAt: feed.UpdatedAt,
-		Title:     "Feedlet",
-	}
-
-	if err := s.tmpl.Execute(w, data); err != nil {
-		log.Printf("Error rendering template: %v", err)
+	page := BuildPage(s.fetcher.GetFeed(), s.sourceConfigs, s.defaultLimit)
+	if err := s.tmpl.Execute(w, page); err != nil {
+		slog.Error("Error rendering template", "error", err)
 		http.Error(w, "Internal server error", http.StatusInternalServerError)
 	}
 }
@@ -4,28 +4,48 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	neturl "net/url"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/hashicorp/go-retryablehttp"
 	"github.com/ppowo/feedlet/internal/models"
+	"github.com/ppowo/feedlet/internal/source/fetcherr"
 	"github.com/ppowo/feedlet/internal/source/httpclient"
 )
 
-// ChanArchiveSource implements the Source interface for 4chan archive APIs
-// Supports desuarchive
+// ChanArchiveSource implements the Source interface for FoolFuuka-based 4chan
+// archives (desuarchive, archived.moe, ...).
 type ChanArchiveSource struct {
 	name        string
-	board       string
 	limit       int
 	nsfw        bool
-	archiveType string // "desuarchive"
-	subject     string // Search subject (e.g., "/film/", "/ptg/")
-	baseURL     string
-	minReplies  int           // Minimum reply count (0 = no filter)
-	minAge      time.Duration // Minimum age (0 = no filter)
+	archiveType string // "desuarchive" or "foolfuuka"
+	cfg         ChanArchiveConfig
+
+	clientHolder
+}
+
+// ChanArchiveConfig selects which archived threads a ChanArchiveSource lists.
+type ChanArchiveConfig struct {
+	BaseURL    string        // Archive root, e.g. https://desuarchive.org
+	Boards     []string      // Boards to search (e.g. "g", "tv")
+	Subject    string        // Subject search; also required as a substring of the title
+	Text       string        // Comment text search
+	ThreadType string        // FoolFuuka search type: "op", "posts", ...
+	MinReplies int           // Minimum reply count (0 = no filter)
+	MinAge     time.Duration // Minimum age (0 = no filter)
+	MaxAge     time.Duration // Maximum age (0 = no filter)
+	Pages      int           // Number of search result pages to walk
 }
 
+const (
+	defaultChanArchivePages = 1
+	maxChanArchivePages     = 10
+)
+
 // ChanArchivePost represents a post from the archive API
 type ChanArchivePost struct {
 	DocID            string `json:"doc_id"`
@@ -49,29 +69,151 @@ type ChanArchivePost struct {
 	NImages          *int   `json:"nimages"`
 	FourchanDate     string `json:"fourchan_date"`
 	CommentSanitized string `json:"comment_sanitized"`
+	Board            struct {
+		ShortName string `json:"shortname"`
+	} `json:"board"`
 }
 
+func init() {
+	Register(TypeInfo{
+		Type:        "desuarchive",
+		Description: "/ptg/ threads from DesuArchive",
+		Factory: func(cfg models.SourceConfig) (Source, error) {
+			board := strings.TrimSpace(cfg.Options.String("board", cfg.URL))
+			if board == "" {
+				return nil, fmt.Errorf("desuarchive source %q needs a board", cfg.Name)
+			}
+			return NewDesuArchiveSource(cfg.Name, board, cfg.Options.Int("limit", defaultItemLimit), cfg.IsNSFW()), nil
+		},
+		Params: []Param{
+			{Name: "url", Description: "Board name; shorthand for the board option"},
+			{Name: "board", Description: "Board to search, e.g. vt"},
+		},
+		Capabilities: Capabilities{NSFW: true},
+	})
+	Register(TypeInfo{
+		Type:        "foolfuuka",
+		Description: "Thread search on any FoolFuuka archive",
+		Factory: func(cfg models.SourceConfig) (Source, error) {
+			archiveCfg, err := ParseFoolFuukaURL(cfg.URL, cfg.Options)
+			if err != nil {
+				return nil, err
+			}
+			return NewChanArchiveSource(cfg.Name, cfg.Type, archiveCfg, cfg.Options.Int("limit", defaultItemLimit), cfg.IsNSFW()), nil
+		},
+		Params: []Param{
+			{Name: "url", Required: true, Description: "Archive and board, e.g. https://desuarchive.org/g/"},
+			{Name: "boards", Kind: models.OptionList, Description: "Boards to search (comma separated), instead of the path"},
+			{Name: "subject", Description: "Subject filter"},
+			{Name: "text", Description: "Text filter"},
+			{Name: "type", Default: "op", Description: "Post type"},
+			{Name: "min_replies", Kind: models.OptionInt, Description: "Minimum replies"},
+			{Name: "min_age", Kind: models.OptionDuration, Description: "Minimum thread age, e.g. 24h"},
+			{Name: "max_age", Kind: models.OptionDuration, Description: "Maximum thread age"},
+			{Name: "pages", Kind: models.OptionInt, Default: "1", Description: "Result pages to walk"},
+		},
+		Capabilities: Capabilities{NSFW: true},
+	})
+}
 
 // NewDesuArchiveSource creates a desuarchive source for /ptg/
 func NewDesuArchiveSource(name, board string, limit int, nsfw bool) *ChanArchiveSource {
+	return NewChanArchiveSource(name, "desuarchive", ChanArchiveConfig{
+		BaseURL:    "https://desuarchive.org",
+		Boards:     []string{board},
+		Subject:    "/ptg/",
+		ThreadType: "op",
+		MinReplies: 0,
+		MinAge:     24 * time.Hour,
+		Pages:      defaultChanArchivePages,
+	}, limit, nsfw)
+}
+
+// NewChanArchiveSource creates a source for any FoolFuuka archive.
+func NewChanArchiveSource(name, archiveType string, cfg ChanArchiveConfig, limit int, nsfw bool) *ChanArchiveSource {
+	cfg.BaseURL = strings.TrimRight(cfg.BaseURL, "/")
+	if cfg.ThreadType == "" {
+		cfg.ThreadType = "op"
+	}
+	if cfg.Pages <= 0 {
+		cfg.Pages = defaultChanArchivePages
+	}
 	return &ChanArchiveSource{
 		name:        name,
-		board:       board,
 		limit:       limit,
 		nsfw:        nsfw,
-		archiveType: "desuarchive",
-		subject:     "/ptg/",
-		baseURL:     "https://desuarchive.org",
-		minReplies:  0,
-		minAge:      24 * time.Hour,
+		archiveType: archiveType,
+		cfg:         cfg,
 	}
 }
 
-func (c *ChanArchiveSource) Fetch(ctx context.Context) ([]models.Item, error) {
-	searchURL := fmt.Sprintf("%s/_/api/chan/search/?subject=%s&boards=%s&type=op&page=1",
-		c.baseURL, c.subject, c.board)
+// ParseFoolFuukaURL builds a ChanArchiveConfig from an archive URL such as
+// https://desuarchive.org/g/?subject=/ptg/&min_age=24h.
+//
+// The board comes from the first path segment or the boards parameter (comma
+// separated). Other query parameters: subject, text, type, min_replies,
+// min_age, max_age (Go durations) and pages.
+func ParseFoolFuukaURL(rawURL string, options models.Options) (ChanArchiveConfig, error) {
+	parsed, err := neturl.Parse(strings.TrimSpace(rawURL))
+	if err != nil {
+		return ChanArchiveConfig{}, fmt.Errorf("invalid foolfuuka URL: %w", err)
+	}
+	if parsed.Scheme == "" || parsed.Host == "" {
+		return ChanArchiveConfig{}, fmt.Errorf("foolfuuka URL %q must include scheme and host", rawURL)
+	}
+
+	q := newSettings(options, parsed.Query())
+	cfg := ChanArchiveConfig{
+		BaseURL:    parsed.Scheme + "://" + parsed.Host,
+		Boards:     splitList(q.Get("boards")),
+		Subject:    strings.TrimSpace(q.Get("subject")),
+		Text:       strings.TrimSpace(q.Get("text")),
+		ThreadType: strings.TrimSpace(q.Get("type")),
+		Pages:      parseBoundedInt(q.Get("pages"), defaultChanArchivePages, 1, maxChanArchivePages),
+	}
+	if segments := strings.FieldsFunc(parsed.Path, func(r rune) bool { return r == '/' }); len(cfg.Boards) == 0 && len(segments) > 0 {
+		cfg.Boards = []string{segments[0]}
+	}
+	if len(cfg.Boards) == 0 {
+		return ChanArchiveConfig{}, fmt.Errorf("foolfuuka URL %q does not name a board", rawURL)
+	}
 
-	req, err := retryablehttp.NewRequestWithContext(ctx, "GET", searchURL, nil)
+	if n, ok := parsePositiveInt(q.Get("min_replies")); ok {
+		cfg.MinReplies = n
+	}
+	for key, dst := range map[string]*time.Duration{"min_age": &cfg.MinAge, "max_age": &cfg.MaxAge} {
+		value := strings.TrimSpace(q.Get(key))
+		if value == "" {
+			continue
+		}
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return ChanArchiveConfig{}, fmt.Errorf("invalid foolfuuka %s %q: %w", key, value, err)
+		}
+		*dst = d
+	}
+
+	return cfg, nil
+}
+
+func (c *ChanArchiveSource) searchURL(page int) string {
+	q := neturl.Values{}
+	q.Set("boards", strings.Join(c.cfg.Boards, "."))
+	q.Set("type", c.cfg.ThreadType)
+	q.Set("page", strconv.Itoa(page))
+	if c.cfg.Subject != "" {
+		q.Set("subject", c.cfg.Subject)
+	}
+	if c.cfg.Text != "" {
+		q.Set("text", c.cfg.Text)
+	}
+	return c.cfg.BaseURL + "/_/api/chan/search/?" + q.Encode()
+}
+
+// fetchPage returns the posts of one search result page in result order. An
+// empty slice means there are no further results.
+func (c *ChanArchiveSource) fetchPage(ctx context.Context, page int) ([]ChanArchivePost, error) {
+	req, err := retryablehttp.NewRequestWithContext(ctx, "GET", c.searchURL(page), nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
@@ -79,95 +221,168 @@ func (c *ChanArchiveSource) Fetch(ctx context.Context) ([]models.Item, error) {
 	req.Header.Set("User-Agent", httpclient.RandomUserAgent())
 	req.Header.Set("Accept", "application/json")
 
-	resp, err := httpclient.GetClient().Do(req)
+	resp, err := c.httpClient().Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch %s: %w", c.archiveType, err)
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode >= 400 {
-		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	// FoolFuuka answers past-the-end pages with 404 and an error body.
+	if resp.StatusCode == 404 && page > 1 {
+		return nil, nil
+	}
+	body, err := httpclient.ReadBody(resp, httpclient.ExpectJSON)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch %s: %w", c.archiveType, err)
 	}
 
 	var rawResponse map[string]json.RawMessage
-	if err := json.NewDecoder(resp.Body).Decode(&rawResponse); err != nil {
-		return nil, fmt.Errorf("failed to decode JSON response: %w", err)
+	if err := json.Unmarshal(body, &rawResponse); err != nil {
+		return nil, fetcherr.New(fetcherr.Parse, "failed to decode JSON response: %w", err)
+	}
+	if _, ok := rawResponse["error"]; ok {
+		return nil, nil
 	}
 
-	items := make([]models.Item, 0)
-
-	for key, value := range rawResponse {
-		if key == "meta" {
-			continue
+	// Result sets are keyed "0", "1", ...; walk them in numeric order so the
+	// item order does not depend on map iteration.
+	keys := make([]string, 0, len(rawResponse))
+	for key := range rawResponse {
+		if key != "meta" {
+			keys = append(keys, key)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		ki, erri := strconv.Atoi(keys[i])
+		kj, errj := strconv.Atoi(keys[j])
+		if erri != nil || errj != nil {
+			return keys[i] < keys[j]
 		}
+		return ki < kj
+	})
 
+	posts := make([]ChanArchivePost, 0, 32)
+	for _, key := range keys {
 		var resultSet struct {
 			Posts []ChanArchivePost `json:"posts"`
 		}
-		if err := json.Unmarshal(value, &resultSet); err != nil {
+		if err := json.Unmarshal(rawResponse[key], &resultSet); err != nil {
 			continue
 		}
+		posts = append(posts, resultSet.Posts...)
+	}
 
-		for _, post := range resultSet.Posts {
-			// Filter by subject in title
-			if !strings.Contains(post.Title, c.subject) {
-				continue
-			}
+	return posts, nil
+}
 
-			// Skip deleted posts
-			if fmt.Sprintf("%v", post.Deleted) == "1" {
-				continue
-			}
+func (c *ChanArchiveSource) Fetch(ctx context.Context) ([]models.Item, error) {
+	items := make([]models.Item, 0)
+	seen := make(map[string]bool)
 
-			published := time.Unix(post.Timestamp, 0)
+	for page := 1; page <= c.cfg.Pages; page++ {
+		posts, err := c.fetchPage(ctx, page)
+		if err != nil {
+			return nil, err
+		}
+		if len(posts) == 0 {
+			break
+		}
 
-			// Apply minimum age filter
-			if c.minAge > 0 && time.Since(published) <= c.minAge {
-				continue
+		allTooOld := true
+		for _, post := range posts {
+			published := time.Unix(post.Timestamp, 0).UTC()
+			if c.cfg.MaxAge <= 0 || time.Since(published) <= c.cfg.MaxAge {
+				allTooOld = false
 			}
 
-			// Apply minimum replies filter
-			replyCount := 0
-			if post.NReplies != nil {
-				replyCount = *post.NReplies
-			}
-			if c.minReplies > 0 && replyCount < c.minReplies {
+			item, ok := c.postItem(post, published)
+			if !ok || seen[item.Link] {
 				continue
 			}
+			seen[item.Link] = true
+			items = append(items, item)
+		}
 
-			// Extract title from comment
-			title := extractFirstLines(post.CommentSanitized, 2)
-			if title == "" {
-				title = c.subject + " - Thread"
-			}
-
-			threadURL := fmt.Sprintf("%s/%s/thread/%s/#%s",
-				c.baseURL, c.board, post.ThreadNum, post.ThreadNum)
-
-			description := strings.TrimSpace(post.CommentSanitized)
-			if len(description) > 500 {
-				description = description[:500] + "..."
-			}
+		// Results are newest first, so once a whole page is past max age the
+		// remaining pages are too.
+		if allTooOld {
+			break
+		}
+	}
 
-			items = append(items, models.Item{
-				Title:       title,
-				Link:        threadURL,
-				Description: description,
-				Content:     post.CommentSanitized,
-				Author:      post.Name,
-				Published:   published,
-				SourceName:  c.name,
+	sort.SliceStable(items, func(i, j int) bool {
+		return items[i].Published.After(items[j].Published)
 	})
 
-			if c.limit > 0 && len(items) >= c.limit {
-				return items, nil
-			}
-		}
+	if c.limit > 0 && len(items) > c.limit {
+		items = items[:c.limit]
 	}
 
 	return items, nil
 }
 
+func (c *ChanArchiveSource) postItem(post ChanArchivePost, published time.Time) (models.Item, bool) {
+	// Filter by subject in title
+	if c.cfg.Subject != "" && !strings.Contains(post.Title, c.cfg.Subject) {
+		return models.Item{}, false
+	}
+
+	// Skip deleted posts
+	if fmt.Sprintf("%v", post.Deleted) == "1" {
+		return models.Item{}, false
+	}
+
+	// Apply age filters
+	if c.cfg.MinAge > 0 && time.Since(published) <= c.cfg.MinAge {
+		return models.Item{}, false
+	}
+	if c.cfg.MaxAge > 0 && time.Since(published) > c.cfg.MaxAge {
+		return models.Item{}, false
+	}
+
+	// Apply minimum replies filter
+	replyCount := 0
+	if post.NReplies != nil {
+		replyCount = *post.NReplies
+	}
+	if c.cfg.MinReplies > 0 && replyCount < c.cfg.MinReplies {
+		return models.Item{}, false
+	}
+
+	// Extract title from comment
+	title := extractFirstLines(post.CommentSanitized, 2)
+	if title == "" {
+		title = strings.TrimSpace(post.Title)
+	}
+	if title == "" {
+		title = c.cfg.Subject + " - Thread"
+	}
+
+	board := post.Board.ShortName
+	if board == "" {
+		board = c.cfg.Boards[0]
+	}
+	threadURL := fmt.Sprintf("%s/%s/thread/%s/#%s",
+		c.cfg.BaseURL, board, post.ThreadNum, post.ThreadNum)
+
+	description := strings.TrimSpace(post.CommentSanitized)
+	if len(description) > 500 {
+		description = description[:500] + "..."
+	}
+
+	return models.Item{
+		Title:       title,
+		Link:        threadURL,
+		Description: description,
+		Content:     post.CommentSanitized,
+		Author:      post.Name,
+		Published:   published,
+		SourceName:  c.name,
+		SourceType:  c.archiveType,
+		Comments:    replyCount,
+	}, true
+}
+
 // extractFirstLines extracts the first n non-empty lines from a comment
 func extractFirstLines(comment string, n int) string {
 	lines := strings.Split(comment, "\n")
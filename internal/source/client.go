@@ -0,0 +1,27 @@
+package source
+
+import (
+	"github.com/hashicorp/go-retryablehttp"
+	"github.com/ppowo/feedlet/internal/source/httpclient"
+)
+
+// clientHolder lets the HTTP client of a source be replaced, e.g. by one that
+// records or replays fixtures. Embedding it implements HTTPSource's
+// SetHTTPClient; until a client is set the shared httpclient.GetClient() is
+// used.
+type clientHolder struct {
+	client *retryablehttp.Client
+}
+
+// SetHTTPClient replaces the client used for the source's requests. It must
+// be called before the first Fetch.
+func (h *clientHolder) SetHTTPClient(client *retryablehttp.Client) {
+	h.client = client
+}
+
+func (h *clientHolder) httpClient() *retryablehttp.Client {
+	if h.client != nil {
+		return h.client
+	}
+	return httpclient.GetClient()
+}
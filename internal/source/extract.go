@@ -0,0 +1,62 @@
+package source
+
+import (
+	"sync"
+
+	"github.com/ppowo/feedlet/internal/models"
+)
+
+// extractRecorder collects the extraction stats of one fetch. A nil recorder
+// records nothing, so parse helpers can be used without one.
+type extractRecorder struct {
+	stats models.ExtractStats
+}
+
+func (r *extractRecorder) page() {
+	if r != nil {
+		r.stats.Pages++
+	}
+}
+
+func (r *extractRecorder) seen() {
+	if r != nil {
+		r.stats.RowsSeen++
+	}
+}
+
+func (r *extractRecorder) parsed() {
+	if r != nil {
+		r.stats.RowsParsed++
+	}
+}
+
+// missing records a row skipped because field was missing or malformed.
+func (r *extractRecorder) missing(field string) {
+	if r == nil {
+		return
+	}
+	if r.stats.MissingFields == nil {
+		r.stats.MissingFields = make(map[string]int)
+	}
+	r.stats.MissingFields[field]++
+}
+
+// lastExtract keeps the stats of a scraper's latest fetch. Embedding it
+// implements StatsReporter's ExtractStats.
+type lastExtract struct {
+	mu    sync.Mutex
+	stats models.ExtractStats
+}
+
+func (l *lastExtract) store(r *extractRecorder) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.stats = r.stats
+}
+
+// ExtractStats returns the extraction stats of the latest fetch.
+func (l *lastExtract) ExtractStats() models.ExtractStats {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.stats
+}
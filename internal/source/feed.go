@@ -1,34 +1,88 @@
 package source
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
+	"encoding/xml"
 	"fmt"
 	"io"
 	"net/http"
+	neturl "net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/mmcdole/gofeed"
+	ext "github.com/mmcdole/gofeed/extensions"
 	"github.com/ppowo/feedlet/internal/models"
+	"github.com/ppowo/feedlet/internal/source/fetcherr"
 	"github.com/ppowo/feedlet/internal/source/httpclient"
 )
 
 // FeedSource implements the Source interface for RSS/Atom feeds
 // Consolidates rss, reddit, and lobsters sources
 type FeedSource struct {
-	name            string
-	url             string
-	sourceType      string
-	useGUID         bool // Use GUID instead of Link (for HN, Lobsters)
-	parser          *gofeed.Parser
+	name       string
+	url        string
+	sourceType string
+	useGUID    bool // Use GUID instead of Link (for HN, Lobsters)
+	parser     *gofeed.Parser
+
+	// WebSub hub and self (topic) URLs advertised by the last fetched feed.
+	pushMu    sync.Mutex
+	pushHub   string
+	pushTopic string
+
+	clientHolder
+}
+
+func init() {
+	feedParams := []Param{{Name: "url", Required: true, Description: "RSS, Atom or JSON Feed URL"}}
+
+	Register(TypeInfo{
+		Type:        "rss",
+		Description: "RSS, Atom or JSON Feed",
+		Factory: func(cfg models.SourceConfig) (Source, error) {
+			return NewFeedSource(cfg.Name, cfg.URL, "rss", false), nil
+		},
+		Params:       feedParams,
+		Capabilities: Capabilities{Push: true},
+	})
+	Register(TypeInfo{
+		Type:        "reddit",
+		Description: "Subreddit listing via its RSS feed",
+		Factory: func(cfg models.SourceConfig) (Source, error) {
+			return NewFeedSource(cfg.Name, cfg.URL, "reddit", false), nil
+		},
+		Params: feedParams,
+		Policy: Policy{
+			StartupStagger: 10 * time.Second,
+			BackoffCap:     2 * time.Hour,
+			HostSpacing:    3 * time.Second,
+		},
+		Capabilities: Capabilities{Push: true, NSFW: true},
+	})
+	Register(TypeInfo{
+		Type:        "lobsters",
+		Description: "Lobsters feed, linking to the discussion page",
+		Factory: func(cfg models.SourceConfig) (Source, error) {
+			return NewFeedSource(cfg.Name, cfg.URL, "lobsters", true), nil
+		},
+		Params:       feedParams,
+		Capabilities: Capabilities{Push: true},
+	})
 }
 
 // NewFeedSource creates a new feed source
 func NewFeedSource(name, url, sourceType string, useGUID bool) *FeedSource {
 	return &FeedSource{
-		name:            name,
-		url:             url,
-		sourceType:      sourceType,
-		useGUID:         useGUID,
-		parser:          gofeed.NewParser(),
+		name:       name,
+		url:        url,
+		sourceType: sourceType,
+		useGUID:    useGUID,
+		parser:     gofeed.NewParser(),
 	}
 }
 
@@ -40,25 +94,26 @@ func (f *FeedSource) fetchFeed(ctx context.Context) (*gofeed.Feed, error) {
 	}
 	req.Header.Set("User-Agent", httpclient.RandomUserAgent())
 
-	client := httpclient.GetClient()
+	client := f.httpClient()
 	resp, err := client.StandardClient().Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch feed %s: %w", f.name, err)
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("failed to fetch feed %s: http %d %s", f.name, resp.StatusCode, resp.Status)
-	}
-
-	body, err := io.ReadAll(resp.Body)
+	body, err := httpclient.ReadBody(resp, httpclient.ExpectFeed)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read feed %s: %w", f.name, err)
+		return nil, fmt.Errorf("failed to fetch feed %s: %w", f.name, err)
 	}
 
+	hub, topic := discoverHub(f.url, resp.Header, body)
+	f.pushMu.Lock()
+	f.pushHub, f.pushTopic = hub, topic
+	f.pushMu.Unlock()
+
 	feed, err := f.parser.ParseString(string(body))
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse feed %s: %w", f.name, err)
+		return nil, fetcherr.New(fetcherr.Parse, "failed to parse feed %s: %w", f.name, err)
 	}
 	return feed, nil
 }
@@ -70,6 +125,163 @@ func (f *FeedSource) Fetch(ctx context.Context) ([]models.Item, error) {
 		return nil, err
 	}
 
+	return feedItems(feed, f.name, f.sourceType, f.useGUID), nil
+}
+
+// PushHub returns the WebSub hub and topic advertised by the feed, if any.
+func (f *FeedSource) PushHub() (hub, topic string) {
+	f.pushMu.Lock()
+	defer f.pushMu.Unlock()
+	return f.pushHub, f.pushTopic
+}
+
+// ParsePush parses feed content delivered by a WebSub hub.
+func (f *FeedSource) ParsePush(body []byte) ([]models.Item, error) {
+	feed, err := gofeed.NewParser().Parse(bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse pushed feed %s: %w", f.name, err)
+	}
+	return feedItems(feed, f.name, f.sourceType, f.useGUID), nil
+}
+
+// discoverHub finds the WebSub hub and self URLs of a feed, from Link headers
+// first and then from the document: <link rel="hub"> / <atom:link rel="hub">
+// in RSS and Atom, or the hubs array of a JSON Feed. The topic defaults to the
+// feed URL when no self link is advertised. hub is empty when the feed does
+// not support WebSub.
+func discoverHub(feedURL string, header http.Header, body []byte) (hub, topic string) {
+	for _, value := range header.Values("Link") {
+		for _, part := range strings.Split(value, ",") {
+			target, params, ok := strings.Cut(part, ";")
+			if !ok {
+				continue
+			}
+			target = strings.Trim(strings.TrimSpace(target), "<>")
+			for _, param := range strings.Split(params, ";") {
+				key, val, _ := strings.Cut(strings.TrimSpace(param), "=")
+				if !strings.EqualFold(key, "rel") {
+					continue
+				}
+				for _, rel := range strings.Fields(strings.Trim(val, `"`)) {
+					switch strings.ToLower(rel) {
+					case "hub":
+						if hub == "" {
+							hub = target
+						}
+					case "self":
+						if topic == "" {
+							topic = target
+						}
+					}
+				}
+			}
+		}
+	}
+
+	if hub == "" || topic == "" {
+		docHub, docTopic := discoverHubInDocument(body)
+		if hub == "" {
+			hub = docHub
+		}
+		if topic == "" {
+			topic = docTopic
+		}
+	}
+
+	if hub == "" {
+		return "", ""
+	}
+	if topic == "" {
+		topic = feedURL
+	}
+	return resolveHubURL(feedURL, hub), resolveHubURL(feedURL, topic)
+}
+
+func discoverHubInDocument(body []byte) (hub, topic string) {
+	trimmed := bytes.TrimSpace(body)
+	if len(trimmed) == 0 {
+		return "", ""
+	}
+
+	if trimmed[0] == '{' {
+		var doc struct {
+			FeedURL string `json:"feed_url"`
+			Hubs    []struct {
+				Type string `json:"type"`
+				URL  string `json:"url"`
+			} `json:"hubs"`
+		}
+		if err := json.Unmarshal(trimmed, &doc); err != nil {
+			return "", ""
+		}
+		for _, h := range doc.Hubs {
+			if strings.EqualFold(h.Type, "websub") || strings.EqualFold(h.Type, "pubsubhubbub") {
+				return h.URL, doc.FeedURL
+			}
+		}
+		return "", ""
+	}
+
+	// Hub links live in the channel/feed header, so stop at the first entry.
+	decoder := xml.NewDecoder(bytes.NewReader(trimmed))
+	decoder.Strict = false
+	decoder.CharsetReader = func(_ string, input io.Reader) (io.Reader, error) { return input, nil }
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return hub, topic
+		}
+		start, ok := token.(xml.StartElement)
+		if !ok {
+			continue
+		}
+		switch start.Name.Local {
+		case "item", "entry":
+			return hub, topic
+		case "link":
+			var rel, href string
+			for _, attr := range start.Attr {
+				switch attr.Name.Local {
+				case "rel":
+					rel = attr.Value
+				case "href":
+					href = strings.TrimSpace(attr.Value)
+				}
+			}
+			if href == "" {
+				continue
+			}
+			for _, r := range strings.Fields(rel) {
+				switch strings.ToLower(r) {
+				case "hub":
+					if hub == "" {
+						hub = href
+					}
+				case "self":
+					if topic == "" {
+						topic = href
+					}
+				}
+			}
+		}
+	}
+}
+
+func resolveHubURL(base, ref string) string {
+	baseURL, err := neturl.Parse(base)
+	if err != nil {
+		return ref
+	}
+	refURL, err := neturl.Parse(ref)
+	if err != nil {
+		return ref
+	}
+	return baseURL.ResolveReference(refURL).String()
+}
+
+// feedItems maps parsed RSS/Atom/JSON Feed entries onto items, skipping
+// entries without a date.
+func feedItems(feed *gofeed.Feed, sourceName, sourceType string, useGUID bool) []models.Item {
 	items := make([]models.Item, 0, len(feed.Items))
 	for _, item := range feed.Items {
 		published := item.PublishedParsed
@@ -91,23 +303,132 @@ func (f *FeedSource) Fetch(ctx context.Context) ([]models.Item, error) {
 		}
 
 		link := item.Link
-		if f.useGUID && item.GUID != "" {
+		if useGUID && item.GUID != "" {
 			link = item.GUID
 		}
 
 		items = append(items, models.Item{
-			Title:           item.Title,
-			Link:            link,
-			Description:     item.Description,
-			Content:         content,
-			Author:          author,
-			Published:       *published,
-			SourceName:      f.name,
-			SourceType:      f.sourceType,
+			Title:       item.Title,
+			Link:        link,
+			Description: item.Description,
+			Content:     content,
+			Author:      author,
+			Published:   *published,
+			SourceName:  sourceName,
+			SourceType:  sourceType,
+			Thumbnail:   feedItemImage(item),
+			Attachments: feedItemAttachments(item),
 		})
 	}
 
-	return items, nil
+	return items
+}
+
+// feedItemImage returns the episode or item artwork, if any.
+func feedItemImage(item *gofeed.Item) string {
+	if item.Image != nil && item.Image.URL != "" {
+		return item.Image.URL
+	}
+	if item.ITunesExt != nil && item.ITunesExt.Image != "" {
+		return item.ITunesExt.Image
+	}
+	for _, thumb := range item.Extensions["media"]["thumbnail"] {
+		if url := thumb.Attrs["url"]; url != "" {
+			return url
+		}
+	}
+	return ""
+}
+
+// feedItemAttachments collects enclosures and media:content elements. The
+// iTunes duration applies to the enclosure, which is the episode file.
+func feedItemAttachments(item *gofeed.Item) []models.Attachment {
+	var itunesDuration time.Duration
+	if item.ITunesExt != nil {
+		itunesDuration = parseMediaDuration(item.ITunesExt.Duration)
+	}
+
+	attachments := make([]models.Attachment, 0, len(item.Enclosures))
+	seen := make(map[string]int)
+	add := func(a models.Attachment) {
+		if a.URL == "" {
+			return
+		}
+		// Feeds often list the same file as enclosure and media:content;
+		// keep one, with what either says about it
+		if i, ok := seen[a.URL]; ok {
+			kept := &attachments[i]
+			if kept.MIMEType == "" {
+				kept.MIMEType = a.MIMEType
+			}
+			if kept.Length == 0 {
+				kept.Length = a.Length
+			}
+			if kept.Duration == 0 {
+				kept.Duration = a.Duration
+			}
+			return
+		}
+		seen[a.URL] = len(attachments)
+		attachments = append(attachments, a)
+	}
+
+	for _, enclosure := range item.Enclosures {
+		length, _ := strconv.ParseInt(strings.TrimSpace(enclosure.Length), 10, 64)
+		add(models.Attachment{
+			URL:      strings.TrimSpace(enclosure.URL),
+			MIMEType: strings.TrimSpace(enclosure.Type),
+			Length:   length,
+			Duration: itunesDuration,
+		})
+	}
+
+	media := item.Extensions["media"]
+	contents := append([]ext.Extension(nil), media["content"]...)
+	for _, group := range media["group"] {
+		contents = append(contents, group.Children["content"]...)
+	}
+	for _, content := range contents {
+		length, _ := strconv.ParseInt(content.Attrs["fileSize"], 10, 64)
+		mimeType := content.Attrs["type"]
+		if mimeType == "" && content.Attrs["medium"] != "" {
+			mimeType = content.Attrs["medium"] + "/*"
+		}
+		add(models.Attachment{
+			URL:      strings.TrimSpace(content.Attrs["url"]),
+			MIMEType: mimeType,
+			Length:   length,
+			Duration: parseMediaDuration(content.Attrs["duration"]),
+		})
+	}
+
+	if len(attachments) == 0 {
+		return nil
+	}
+	return attachments
+}
+
+// parseMediaDuration parses iTunes/media durations: plain seconds ("3600")
+// or clock notation ("1:02:03", "62:03"). Anything else is 0.
+func parseMediaDuration(value string) time.Duration {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0
+	}
+
+	parts := strings.Split(value, ":")
+	if len(parts) > 3 {
+		return 0
+	}
+	var total float64
+	for _, part := range parts {
+		n, err := strconv.ParseFloat(part, 64)
+		if err != nil || n < 0 {
+			return 0
+		}
+		total = total*60 + n
+	}
+	return time.Duration(total * float64(time.Second))
 }
 
 // Name returns the source name
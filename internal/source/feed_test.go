@@ -0,0 +1,93 @@
+package source
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mmcdole/gofeed"
+	"github.com/ppowo/feedlet/internal/models"
+)
+
+func TestParseMediaDuration(t *testing.T) {
+	tests := []struct {
+		value string
+		want  time.Duration
+	}{
+		{"3600", time.Hour},
+		{" 90 ", 90 * time.Second},
+		{"12.5", 12500 * time.Millisecond},
+		{"1:02:03", time.Hour + 2*time.Minute + 3*time.Second},
+		{"62:03", 62*time.Minute + 3*time.Second},
+		{"00:45", 45 * time.Second},
+		{"", 0},
+		{"1:2:3:4", 0},
+		{"1:-5", 0},
+		{"half an hour", 0},
+		{"1::3", 0},
+	}
+	for _, tt := range tests {
+		if got := parseMediaDuration(tt.value); got != tt.want {
+			t.Errorf("parseMediaDuration(%q) = %s, want %s", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestFeedItemAttachments(t *testing.T) {
+	tests := []struct {
+		name string
+		item string
+		want []models.Attachment
+	}{
+		{
+			name: "none",
+			item: `<title>Text only</title>`,
+		},
+		{
+			name: "enclosure with itunes duration",
+			item: `<enclosure url="https://pod.example/1.mp3" type="audio/mpeg" length="1234"/>
+				<itunes:duration>1:02:03</itunes:duration>`,
+			want: []models.Attachment{
+				{URL: "https://pod.example/1.mp3", MIMEType: "audio/mpeg", Length: 1234, Duration: time.Hour + 2*time.Minute + 3*time.Second},
+			},
+		},
+		{
+			name: "media content duplicating the enclosure",
+			item: `<enclosure url="https://pod.example/2.mp3" type="audio/mpeg" length="0"/>
+				<media:content url="https://pod.example/2.mp3" type="audio/mpeg" fileSize="5678" duration="300"/>`,
+			want: []models.Attachment{
+				{URL: "https://pod.example/2.mp3", MIMEType: "audio/mpeg", Length: 5678, Duration: 5 * time.Minute},
+			},
+		},
+		{
+			name: "grouped media contents",
+			item: `<media:group>
+					<media:content url="https://video.example/3-720.mp4" type="video/mp4" duration="62:03"/>
+					<media:content url="https://video.example/3-1080.mp4" medium="video"/>
+					<media:content url="https://video.example/3-720.mp4" type="video/mp4"/>
+				</media:group>`,
+			want: []models.Attachment{
+				{URL: "https://video.example/3-720.mp4", MIMEType: "video/mp4", Duration: 62*time.Minute + 3*time.Second},
+				{URL: "https://video.example/3-1080.mp4", MIMEType: "video/*"},
+			},
+		},
+		{
+			name: "enclosure without a URL",
+			item: `<enclosure url=" " type="audio/mpeg"/>`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			feed, err := gofeed.NewParser().Parse(strings.NewReader(`<?xml version="1.0"?>
+<rss version="2.0" xmlns:itunes="http://www.itunes.com/dtds/podcast-1.0.dtd" xmlns:media="http://search.yahoo.com/mrss/">
+<channel><title>Test</title><item>` + tt.item + `</item></channel></rss>`))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := feedItemAttachments(feed.Items[0]); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("attachments = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,188 @@
+// Package fetcherr classifies source fetch failures so the fetcher can pick
+// a backoff and the dashboard can tell a rate limit from a broken scraper.
+package fetcherr
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"syscall"
+	"time"
+
+	"github.com/mmcdole/gofeed"
+	"github.com/ppowo/feedlet/internal/source/httpclient"
+)
+
+// Class is the kind of a fetch failure.
+type Class string
+
+const (
+	Unknown     Class = "unknown"
+	Network     Class = "network"      // Connection refused, reset, ...
+	Timeout     Class = "timeout"      // Request or fetch deadline exceeded
+	DNS         Class = "dns"          // Host name did not resolve
+	TLS         Class = "tls"          // Handshake or certificate failure
+	HTTPStatus  Class = "http_status"  // Non-2xx response
+	RateLimited Class = "rate_limited" // 429 Too Many Requests
+	Blocked     Class = "blocked"      // Captcha or anti-bot challenge
+	Parse       Class = "parse"        // Response could not be decoded
+	Empty       Class = "empty"        // Response held nothing to list
+	SchemaDrift Class = "schema_drift" // Page markup no longer matches the scraper
+	Command     Class = "command"      // Local command could not run or exited non-zero
+)
+
+// Error is a fetch failure tagged with its class.
+type Error struct {
+	Class Class
+	Err   error
+}
+
+func (e *Error) Error() string {
+	return e.Err.Error()
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// New returns an error of the given class, formatted like fmt.Errorf.
+func New(class Class, format string, args ...any) error {
+	return &Error{Class: class, Err: fmt.Errorf(format, args...)}
+}
+
+// Wrap tags err with class. It returns nil if err is nil.
+func Wrap(class Class, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &Error{Class: class, Err: err}
+}
+
+// Info is the classification of a fetch error.
+type Info struct {
+	Class      Class
+	StatusCode int           // HTTP status of the failed response, if any
+	RetryAfter time.Duration // Delay requested by the server, if any
+}
+
+// Classify returns the class of err. Errors tagged with New or Wrap keep
+// their class; others are classified by the errors they wrap.
+func Classify(err error) Info {
+	if err == nil {
+		return Info{}
+	}
+
+	var info Info
+	var statusErr *httpclient.StatusError
+	var challengeErr *httpclient.ChallengeError
+	switch {
+	case errors.As(err, &challengeErr):
+		info = Info{Class: Blocked, StatusCode: challengeErr.StatusCode}
+	case errors.As(err, &statusErr):
+		info = Info{Class: HTTPStatus, StatusCode: statusErr.StatusCode, RetryAfter: statusErr.RetryAfter}
+		if statusErr.StatusCode == 429 {
+			info.Class = RateLimited
+		}
+	}
+
+	var tagged *Error
+	if errors.As(err, &tagged) {
+		info.Class = tagged.Class
+		return info
+	}
+	if info.Class != "" {
+		return info
+	}
+
+	info.Class = classifyCause(err)
+	return info
+}
+
+func classifyCause(err error) Class {
+	var contentTypeErr *httpclient.ContentTypeError
+	var dnsErr *net.DNSError
+	var unknownAuthorityErr x509.UnknownAuthorityError
+	var hostnameErr x509.HostnameError
+	var certInvalidErr x509.CertificateInvalidError
+	var certVerifyErr *tls.CertificateVerificationError
+	var recordHeaderErr tls.RecordHeaderError
+	var alertErr tls.AlertError
+	var netErr net.Error
+	var opErr *net.OpError
+	var jsonSyntaxErr *json.SyntaxError
+	var jsonTypeErr *json.UnmarshalTypeError
+	var xmlSyntaxErr *xml.SyntaxError
+
+	switch {
+	case errors.As(err, &contentTypeErr), errors.Is(err, httpclient.ErrBodyTooLarge):
+		return Parse
+	case errors.As(err, &dnsErr):
+		return DNS
+	case errors.As(err, &unknownAuthorityErr), errors.As(err, &hostnameErr),
+		errors.As(err, &certInvalidErr), errors.As(err, &certVerifyErr),
+		errors.As(err, &recordHeaderErr), errors.As(err, &alertErr):
+		return TLS
+	case errors.Is(err, context.DeadlineExceeded), errors.As(err, &netErr) && netErr.Timeout():
+		return Timeout
+	case errors.As(err, &opErr), errors.Is(err, syscall.ECONNREFUSED), errors.Is(err, syscall.ECONNRESET),
+		errors.Is(err, io.ErrUnexpectedEOF):
+		return Network
+	case errors.As(err, &jsonSyntaxErr), errors.As(err, &jsonTypeErr), errors.As(err, &xmlSyntaxErr),
+		errors.Is(err, gofeed.ErrFeedTypeNotDetected):
+		return Parse
+	default:
+		return Unknown
+	}
+}
+
+// Transient reports whether failures of the class are likely to go away by
+// themselves and are worth retrying with exponential backoff.
+func (c Class) Transient() bool {
+	switch c {
+	case Network, Timeout, DNS, TLS, HTTPStatus, Command, Unknown:
+		return true
+	default:
+		return false
+	}
+}
+
+// Throttled reports whether the server is pushing back on our requests.
+func (c Class) Throttled() bool {
+	return c == RateLimited || c == Blocked
+}
+
+// Label returns a short human readable description of the class.
+func (c Class) Label() string {
+	switch c {
+	case Network:
+		return "network error"
+	case Timeout:
+		return "timed out"
+	case DNS:
+		return "DNS lookup failed"
+	case TLS:
+		return "TLS error"
+	case HTTPStatus:
+		return "HTTP error"
+	case RateLimited:
+		return "rate limited"
+	case Blocked:
+		return "blocked by challenge"
+	case Parse:
+		return "unreadable response"
+	case Empty:
+		return "empty result"
+	case SchemaDrift:
+		return "page layout changed"
+	case Command:
+		return "command failed"
+	default:
+		return "failed"
+	}
+}
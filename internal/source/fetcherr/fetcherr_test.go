@@ -0,0 +1,90 @@
+package fetcherr
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/mmcdole/gofeed"
+	"github.com/ppowo/feedlet/internal/source/httpclient"
+)
+
+func TestClassify(t *testing.T) {
+	jsonErr := json.Unmarshal([]byte("{oops"), &struct{}{})
+
+	tests := []struct {
+		name string
+		err  error
+		want Info
+	}{
+		{"nil", nil, Info{}},
+		{"deadline", fmt.Errorf("fetch: %w", context.DeadlineExceeded), Info{Class: Timeout}},
+		{"net timeout", &net.OpError{Op: "read", Net: "tcp", Err: os.ErrDeadlineExceeded}, Info{Class: Timeout}},
+		{"dns", fmt.Errorf("get: %w", &net.DNSError{Err: "no such host", Name: "feed.invalid", IsNotFound: true}), Info{Class: DNS}},
+		{"unknown authority", fmt.Errorf("get: %w", x509.UnknownAuthorityError{}), Info{Class: TLS}},
+		{"hostname mismatch", fmt.Errorf("get: %w", x509.HostnameError{Certificate: &x509.Certificate{}, Host: "feed.example"}), Info{Class: TLS}},
+		{"connection refused", &net.OpError{Op: "dial", Net: "tcp", Err: os.NewSyscallError("connect", syscall.ECONNREFUSED)}, Info{Class: Network}},
+		{"unexpected EOF", fmt.Errorf("read: %w", io.ErrUnexpectedEOF), Info{Class: Network}},
+		{"http status", fmt.Errorf("fetch: %w", &httpclient.StatusError{StatusCode: 503}), Info{Class: HTTPStatus, StatusCode: 503}},
+		{"rate limited", fmt.Errorf("fetch: %w", &httpclient.StatusError{StatusCode: 429, RetryAfter: time.Minute}), Info{Class: RateLimited, StatusCode: 429, RetryAfter: time.Minute}},
+		{"challenge", fmt.Errorf("fetch: %w", &httpclient.ChallengeError{Provider: "Cloudflare", StatusCode: 403}), Info{Class: Blocked, StatusCode: 403}},
+		{"content type", &httpclient.ContentTypeError{Want: httpclient.ExpectJSON, ContentType: "text/html"}, Info{Class: Parse}},
+		{"body too large", fmt.Errorf("%w (limit 10 bytes)", httpclient.ErrBodyTooLarge), Info{Class: Parse}},
+		{"json syntax", fmt.Errorf("decode: %w", jsonErr), Info{Class: Parse}},
+		{"not a feed", fmt.Errorf("parse: %w", gofeed.ErrFeedTypeNotDetected), Info{Class: Parse}},
+		{"tagged", New(SchemaDrift, "no rows parsed"), Info{Class: SchemaDrift}},
+		{"wrapped tag", fmt.Errorf("tildes: %w", New(Empty, "no topics")), Info{Class: Empty}},
+		{"tag over cause", Wrap(Command, context.DeadlineExceeded), Info{Class: Command}},
+		{"tag keeps status", New(Parse, "bad page: %w", &httpclient.StatusError{StatusCode: 500}), Info{Class: Parse, StatusCode: 500}},
+		{"unknown", errors.New("something else"), Info{Class: Unknown}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Classify(tt.err); got != tt.want {
+				t.Errorf("Classify(%v) = %+v, want %+v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWrapNil(t *testing.T) {
+	if err := Wrap(Parse, nil); err != nil {
+		t.Errorf("Wrap(nil) = %v, want nil", err)
+	}
+}
+
+func TestClassProperties(t *testing.T) {
+	tests := []struct {
+		class     Class
+		transient bool
+		throttled bool
+	}{
+		{Network, true, false},
+		{Timeout, true, false},
+		{HTTPStatus, true, false},
+		{Command, true, false},
+		{RateLimited, false, true},
+		{Blocked, false, true},
+		{Parse, false, false},
+		{SchemaDrift, false, false},
+	}
+	for _, tt := range tests {
+		if got := tt.class.Transient(); got != tt.transient {
+			t.Errorf("%s.Transient() = %v, want %v", tt.class, got, tt.transient)
+		}
+		if got := tt.class.Throttled(); got != tt.throttled {
+			t.Errorf("%s.Throttled() = %v, want %v", tt.class, got, tt.throttled)
+		}
+		if tt.class.Label() == "" {
+			t.Errorf("%s has no label", tt.class)
+		}
+	}
+}
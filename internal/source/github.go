@@ -0,0 +1,543 @@
+package source
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	neturl "net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/hashicorp/go-retryablehttp"
+	"github.com/ppowo/feedlet/internal/models"
+	"github.com/ppowo/feedlet/internal/source/fetcherr"
+	"github.com/ppowo/feedlet/internal/source/httpclient"
+)
+
+const (
+	githubAPIBaseURL        = "https://api.github.com"
+	githubWebBaseURL        = "https://github.com"
+	defaultForgeItemsPerReq = 10
+	maxForgeItemsPerReq     = 100
+	forgeExcerptLength      = 300
+)
+
+// Forge flavors understood by GitHubSource.
+const (
+	ForgeGitHub = "github"
+	ForgeGitea  = "gitea"
+)
+
+// Forge listing kinds understood by GitHubSource.
+const (
+	ForgeKindReleases = "releases"
+	ForgeKindTags     = "tags"
+	ForgeKindIssues   = "issues"
+	ForgeKindPulls    = "pulls"
+)
+
+// ForgeConfig configures a GitHubSource.
+type ForgeConfig struct {
+	Flavor             string   // ForgeGitHub or ForgeGitea
+	APIBaseURL         string   // e.g. https://api.github.com or https://codeberg.org/api/v1
+	WebBaseURL         string   // e.g. https://github.com or https://codeberg.org
+	Repos              []string // owner/repo
+	Kind               string   // releases, tags, issues or pulls
+	Labels             []string // issue/PR label filter
+	Query              string   // issue/PR text search
+	State              string   // open, closed or all
+	IncludePrereleases bool
+	Token              string
+	PerPage            int
+}
+
+// ParseForgeURL builds a ForgeConfig from a repository URL such as
+// https://github.com/owner/repo/releases or https://codeberg.org/owner/repo/issues.
+//
+// Settings come from options or, as a shorthand, the query string: repos
+// (extra owner/repo list, comma separated), prerelease (1 or true), labels, q, state,
+// count, token_env, api_base and kind.
+func ParseForgeURL(flavor, rawURL string, options models.Options) (ForgeConfig, error) {
+	parsed, err := neturl.Parse(strings.TrimSpace(rawURL))
+	if err != nil {
+		return ForgeConfig{}, fmt.Errorf("invalid %s URL: %w", flavor, err)
+	}
+
+	q := newSettings(options, parsed.Query())
+	cfg := ForgeConfig{
+		Flavor:             flavor,
+		Kind:               ForgeKindReleases,
+		State:              "open",
+		IncludePrereleases: q.Bool("prerelease", false),
+		Query:              strings.TrimSpace(q.Get("q")),
+		PerPage:            parseBoundedInt(q.Get("count"), defaultForgeItemsPerReq, 1, maxForgeItemsPerReq),
+	}
+
+	switch flavor {
+	case ForgeGitHub:
+		cfg.APIBaseURL = githubAPIBaseURL
+		cfg.WebBaseURL = githubWebBaseURL
+	case ForgeGitea:
+		if parsed.Scheme == "" || parsed.Host == "" {
+			return ForgeConfig{}, fmt.Errorf("gitea URL %q must include scheme and host", rawURL)
+		}
+		cfg.WebBaseURL = parsed.Scheme + "://" + parsed.Host
+		cfg.APIBaseURL = cfg.WebBaseURL + "/api/v1"
+	default:
+		return ForgeConfig{}, fmt.Errorf("unsupported forge flavor %q", flavor)
+	}
+	if apiBase := strings.TrimSpace(q.Get("api_base")); apiBase != "" {
+		cfg.APIBaseURL = strings.TrimRight(apiBase, "/")
+	}
+
+	segments := strings.FieldsFunc(parsed.Path, func(r rune) bool { return r == '/' })
+	if len(segments) >= 2 {
+		cfg.Repos = append(cfg.Repos, segments[0]+"/"+segments[1])
+	}
+	if len(segments) >= 3 {
+		cfg.Kind = segments[2]
+	}
+	if kind := strings.TrimSpace(q.Get("kind")); kind != "" {
+		cfg.Kind = kind
+	}
+	switch cfg.Kind {
+	case ForgeKindReleases, ForgeKindTags, ForgeKindIssues, ForgeKindPulls:
+	default:
+		return ForgeConfig{}, fmt.Errorf("unsupported %s listing %q", flavor, cfg.Kind)
+	}
+
+	cfg.Repos = append(cfg.Repos, splitList(q.Get("repos"))...)
+	if len(cfg.Repos) == 0 {
+		return ForgeConfig{}, fmt.Errorf("%s URL %q does not name a repository", flavor, rawURL)
+	}
+
+	cfg.Labels = splitList(q.Get("labels"))
+	if state := strings.TrimSpace(q.Get("state")); state != "" {
+		cfg.State = state
+	}
+
+	tokenEnv := strings.TrimSpace(q.Get("token_env"))
+	if tokenEnv == "" {
+		tokenEnv = strings.ToUpper(flavor) + "_TOKEN"
+	}
+	cfg.Token = os.Getenv(tokenEnv)
+
+	return cfg, nil
+}
+
+// GitHubSource lists releases, tags or issues/PRs of one or more repositories
+// on GitHub or on a Gitea/Forgejo instance.
+type GitHubSource struct {
+	name  string
+	cfg   ForgeConfig
+	cache *httpclient.ConditionalCache
+
+	commitDatesMu sync.Mutex
+	commitDates   map[string]time.Time
+}
+
+func init() {
+	forgeParams := []Param{
+		{Name: "url", Required: true, Description: "Repository listing, e.g. https://github.com/owner/repo/releases"},
+		{Name: "repos", Kind: models.OptionList, Description: "Additional repositories (owner/name, comma separated)"},
+		{Name: "prerelease", Kind: models.OptionBool, Default: "false", Description: "Include pre-releases"},
+		{Name: "labels", Kind: models.OptionList, Description: "Issue/PR label filter"},
+		{Name: "q", Description: "Issue/PR search query"},
+		{Name: "state", Default: "open", Description: "Issue/PR state: open, closed or all"},
+		{Name: "count", Kind: models.OptionInt, Default: "10", Description: "Number of items"},
+		{Name: "token_env", Description: "Environment variable holding an API token"},
+		{Name: "api_base", Description: "API base URL override"},
+		{Name: "kind", Description: "Listing kind override: releases, tags, issues or pulls"},
+	}
+
+	for _, flavor := range []string{ForgeGitHub, ForgeGitea} {
+		Register(TypeInfo{
+			Type:        flavor,
+			Description: "Releases, tags, issues or pull requests of a " + flavor + " repository",
+			Factory: func(cfg models.SourceConfig) (Source, error) {
+				forgeCfg, err := ParseForgeURL(cfg.Type, cfg.URL, cfg.Options)
+				if err != nil {
+					return nil, err
+				}
+				return NewGitHubSource(cfg.Name, forgeCfg), nil
+			},
+			Params:       forgeParams,
+			Policy:       Policy{Interval: time.Hour},
+			Capabilities: Capabilities{ConditionalGet: true},
+		})
+	}
+}
+
+// NewGitHubSource creates a new GitHub or Gitea source.
+func NewGitHubSource(name string, cfg ForgeConfig) *GitHubSource {
+	if cfg.PerPage <= 0 {
+		cfg.PerPage = defaultForgeItemsPerReq
+	}
+	return &GitHubSource{
+		name:        name,
+		cfg:         cfg,
+		cache:       httpclient.NewConditionalCache(),
+		commitDates: make(map[string]time.Time),
+	}
+}
+
+type forgeUser struct {
+	Login string `json:"login"`
+}
+
+type forgeRelease struct {
+	Name        string    `json:"name"`
+	TagName     string    `json:"tag_name"`
+	HTMLURL     string    `json:"html_url"`
+	Body        string    `json:"body"`
+	Draft       bool      `json:"draft"`
+	Prerelease  bool      `json:"prerelease"`
+	CreatedAt   time.Time `json:"created_at"`
+	PublishedAt time.Time `json:"published_at"`
+	Author      forgeUser `json:"author"`
+}
+
+type forgeTag struct {
+	Name   string `json:"name"`
+	Commit struct {
+		SHA     string    `json:"sha"`
+		Created time.Time `json:"created"`
+	} `json:"commit"`
+}
+
+type forgeCommit struct {
+	Created time.Time `json:"created"`
+	Commit  struct {
+		Committer struct {
+			Date time.Time `json:"date"`
+		} `json:"committer"`
+	} `json:"commit"`
+}
+
+type forgeIssue struct {
+	Number      int             `json:"number"`
+	Title       string          `json:"title"`
+	HTMLURL     string          `json:"html_url"`
+	Body        string          `json:"body"`
+	CreatedAt   time.Time       `json:"created_at"`
+	User        forgeUser       `json:"user"`
+	PullRequest json.RawMessage `json:"pull_request"`
+}
+
+func (i forgeIssue) isPull() bool {
+	raw := strings.TrimSpace(string(i.PullRequest))
+	return raw != "" && raw != "null"
+}
+
+// Fetch retrieves the configured listing for every repository.
+func (g *GitHubSource) Fetch(ctx context.Context) ([]models.Item, error) {
+	items := make([]models.Item, 0, g.cfg.PerPage*len(g.cfg.Repos))
+	for _, repo := range g.cfg.Repos {
+		var (
+			repoItems []models.Item
+			err       error
+		)
+		switch g.cfg.Kind {
+		case ForgeKindTags:
+			repoItems, err = g.fetchTags(ctx, repo)
+		case ForgeKindIssues, ForgeKindPulls:
+			repoItems, err = g.fetchIssues(ctx, repo)
+		default:
+			repoItems, err = g.fetchReleases(ctx, repo)
+		}
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, repoItems...)
+	}
+
+	sort.SliceStable(items, func(i, j int) bool {
+		return items[i].Published.After(items[j].Published)
+	})
+	if len(items) > g.cfg.PerPage {
+		items = items[:g.cfg.PerPage]
+	}
+
+	return items, nil
+}
+
+// Name returns the source name.
+func (g *GitHubSource) Name() string {
+	return g.name
+}
+
+// Type returns the source type.
+func (g *GitHubSource) Type() string {
+	return g.cfg.Flavor
+}
+
+// SetHTTPClient replaces the client used for API requests. It must be called
+// before the first Fetch.
+func (g *GitHubSource) SetHTTPClient(client *retryablehttp.Client) {
+	g.cache.SetClient(client)
+}
+
+func (g *GitHubSource) getJSON(ctx context.Context, endpoint string, query neturl.Values, dst any) error {
+	requestURL := g.cfg.APIBaseURL + endpoint
+	if len(query) > 0 {
+		requestURL += "?" + query.Encode()
+	}
+
+	header := http.Header{}
+	header.Set("Accept", "application/json")
+	if g.cfg.Flavor == ForgeGitHub {
+		header.Set("Accept", "application/vnd.github+json")
+		header.Set("X-GitHub-Api-Version", "2022-11-28")
+	}
+	if g.cfg.Token != "" {
+		if g.cfg.Flavor == ForgeGitHub {
+			header.Set("Authorization", "Bearer "+g.cfg.Token)
+		} else {
+			header.Set("Authorization", "token "+g.cfg.Token)
+		}
+	}
+
+	body, err := g.cache.Get(ctx, requestURL, header, httpclient.ExpectJSON)
+	if err != nil {
+		return fmt.Errorf("%s: failed to fetch %s: %w", g.cfg.Flavor, endpoint, err)
+	}
+	if err := json.Unmarshal(body, dst); err != nil {
+		return fetcherr.New(fetcherr.Parse, "%s: failed to decode %s: %w", g.cfg.Flavor, endpoint, err)
+	}
+	return nil
+}
+
+func (g *GitHubSource) pageQuery() neturl.Values {
+	q := neturl.Values{}
+	if g.cfg.Flavor == ForgeGitHub {
+		q.Set("per_page", strconv.Itoa(g.cfg.PerPage))
+	} else {
+		q.Set("limit", strconv.Itoa(g.cfg.PerPage))
+	}
+	return q
+}
+
+func (g *GitHubSource) itemTitle(repo, title string) string {
+	if len(g.cfg.Repos) > 1 {
+		return repo + ": " + title
+	}
+	return title
+}
+
+func (g *GitHubSource) fetchReleases(ctx context.Context, repo string) ([]models.Item, error) {
+	var releases []forgeRelease
+	if err := g.getJSON(ctx, "/repos/"+repo+"/releases", g.pageQuery(), &releases); err != nil {
+		return nil, err
+	}
+
+	items := make([]models.Item, 0, len(releases))
+	for _, release := range releases {
+		if release.Draft || (release.Prerelease && !g.cfg.IncludePrereleases) {
+			continue
+		}
+
+		published := release.PublishedAt
+		if published.IsZero() {
+			published = release.CreatedAt
+		}
+		if published.IsZero() {
+			continue
+		}
+
+		title := strings.TrimSpace(release.Name)
+		if title == "" {
+			title = release.TagName
+		}
+		if release.Prerelease {
+			title += " (pre-release)"
+		}
+
+		link := release.HTMLURL
+		if link == "" {
+			link = g.cfg.WebBaseURL + "/" + repo + "/releases/tag/" + neturl.PathEscape(release.TagName)
+		}
+
+		items = append(items, models.Item{
+			Title:       g.itemTitle(repo, title),
+			Link:        link,
+			Description: truncateText(release.Body, forgeExcerptLength),
+			Content:     release.Body,
+			Author:      release.Author.Login,
+			Published:   published,
+			SourceName:  g.name,
+			SourceType:  g.cfg.Flavor,
+		})
+	}
+
+	return items, nil
+}
+
+func (g *GitHubSource) fetchTags(ctx context.Context, repo string) ([]models.Item, error) {
+	var tags []forgeTag
+	if err := g.getJSON(ctx, "/repos/"+repo+"/tags", g.pageQuery(), &tags); err != nil {
+		return nil, err
+	}
+
+	items := make([]models.Item, 0, len(tags))
+	for _, tag := range tags {
+		published := tag.Commit.Created
+		if published.IsZero() {
+			var err error
+			published, err = g.commitDate(ctx, repo, tag.Commit.SHA)
+			if err != nil {
+				return nil, err
+			}
+		}
+		if published.IsZero() {
+			continue
+		}
+
+		items = append(items, models.Item{
+			Title:      g.itemTitle(repo, tag.Name),
+			Link:       g.cfg.WebBaseURL + "/" + repo + "/releases/tag/" + neturl.PathEscape(tag.Name),
+			Published:  published,
+			SourceName: g.name,
+			SourceType: g.cfg.Flavor,
+		})
+	}
+
+	return items, nil
+}
+
+// commitDate resolves and caches the committer date of a tagged commit, since
+// tag listings do not carry dates on GitHub.
+func (g *GitHubSource) commitDate(ctx context.Context, repo, sha string) (time.Time, error) {
+	if sha == "" {
+		return time.Time{}, nil
+	}
+
+	g.commitDatesMu.Lock()
+	date, ok := g.commitDates[sha]
+	g.commitDatesMu.Unlock()
+	if ok {
+		return date, nil
+	}
+
+	endpoint := "/repos/" + repo + "/commits/" + sha
+	if g.cfg.Flavor == ForgeGitea {
+		endpoint = "/repos/" + repo + "/git/commits/" + sha
+	}
+
+	var commit forgeCommit
+	if err := g.getJSON(ctx, endpoint, nil, &commit); err != nil {
+		return time.Time{}, err
+	}
+
+	date = commit.Commit.Committer.Date
+	if date.IsZero() {
+		date = commit.Created
+	}
+
+	g.commitDatesMu.Lock()
+	g.commitDates[sha] = date
+	g.commitDatesMu.Unlock()
+
+	return date, nil
+}
+
+func (g *GitHubSource) fetchIssues(ctx context.Context, repo string) ([]models.Item, error) {
+	wantPulls := g.cfg.Kind == ForgeKindPulls
+
+	var issues []forgeIssue
+	switch {
+	case g.cfg.Flavor == ForgeGitHub && g.cfg.Query != "":
+		terms := []string{"repo:" + repo, "is:issue"}
+		if wantPulls {
+			terms[1] = "is:pr"
+		}
+		if g.cfg.State != "all" {
+			terms = append(terms, "state:"+g.cfg.State)
+		}
+		for _, label := range g.cfg.Labels {
+			terms = append(terms, fmt.Sprintf("label:%q", label))
+		}
+		terms = append(terms, g.cfg.Query)
+
+		q := g.pageQuery()
+		q.Set("q", strings.Join(terms, " "))
+		q.Set("sort", "created")
+		q.Set("order", "desc")
+
+		var result struct {
+			Items []forgeIssue `json:"items"`
+		}
+		if err := g.getJSON(ctx, "/search/issues", q, &result); err != nil {
+			return nil, err
+		}
+		issues = result.Items
+	default:
+		q := g.pageQuery()
+		q.Set("state", g.cfg.State)
+		if len(g.cfg.Labels) > 0 {
+			q.Set("labels", strings.Join(g.cfg.Labels, ","))
+		}
+		if g.cfg.Flavor == ForgeGitHub {
+			q.Set("sort", "created")
+			q.Set("direction", "desc")
+		} else {
+			q.Set("type", ForgeKindIssues)
+			if wantPulls {
+				q.Set("type", ForgeKindPulls)
+			}
+			if g.cfg.Query != "" {
+				q.Set("q", g.cfg.Query)
+			}
+		}
+		if err := g.getJSON(ctx, "/repos/"+repo+"/issues", q, &issues); err != nil {
+			return nil, err
+		}
+	}
+
+	items := make([]models.Item, 0, len(issues))
+	for _, issue := range issues {
+		if issue.isPull() != wantPulls || issue.CreatedAt.IsZero() {
+			continue
+		}
+
+		items = append(items, models.Item{
+			Title:       g.itemTitle(repo, fmt.Sprintf("#%d %s", issue.Number, strings.TrimSpace(issue.Title))),
+			Link:        issue.HTMLURL,
+			Description: truncateText(issue.Body, forgeExcerptLength),
+			Content:     issue.Body,
+			Author:      issue.User.Login,
+			Published:   issue.CreatedAt,
+			SourceName:  g.name,
+			SourceType:  g.cfg.Flavor,
+		})
+	}
+
+	return items, nil
+}
+
+// splitList splits a comma separated list, dropping empty entries.
+func splitList(value string) []string {
+	parts := strings.Split(value, ",")
+	result := make([]string, 0, len(parts))
+	for _, part := range parts {
+		if part = strings.TrimSpace(part); part != "" {
+			result = append(result, part)
+		}
+	}
+	return result
+}
+
+// truncateText collapses whitespace and cuts text to at most n runes.
+func truncateText(text string, n int) string {
+	text = normalizeWhitespace(text)
+	if utf8.RuneCountInString(text) <= n {
+		return text
+	}
+	runes := []rune(text)
+	return strings.TrimSpace(string(runes[:n])) + "..."
+}
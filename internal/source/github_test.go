@@ -0,0 +1,32 @@
+package source
+
+import (
+	"testing"
+
+	"github.com/ppowo/feedlet/internal/models"
+)
+
+func TestParseForgeURLPrerelease(t *testing.T) {
+	tests := []struct {
+		url     string
+		options models.Options
+		want    bool
+	}{
+		{"https://github.com/owner/repo/releases", nil, false},
+		{"https://github.com/owner/repo/releases?prerelease=1", nil, true},
+		{"https://github.com/owner/repo/releases?prerelease=true", nil, true},
+		{"https://github.com/owner/repo/releases?prerelease=false", nil, false},
+		{"https://github.com/owner/repo/releases?prerelease=0", nil, false},
+		{"https://github.com/owner/repo/releases", models.Options{"prerelease": true}, true},
+		{"https://github.com/owner/repo/releases?prerelease=1", models.Options{"prerelease": false}, false},
+	}
+	for _, tt := range tests {
+		cfg, err := ParseForgeURL(ForgeGitHub, tt.url, tt.options)
+		if err != nil {
+			t.Fatalf("ParseForgeURL(%q): %v", tt.url, err)
+		}
+		if cfg.IncludePrereleases != tt.want {
+			t.Errorf("ParseForgeURL(%q, %v).IncludePrereleases = %t, want %t", tt.url, tt.options, cfg.IncludePrereleases, tt.want)
+		}
+	}
+}
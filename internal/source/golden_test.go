@@ -0,0 +1,103 @@
+package source
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ppowo/feedlet/internal/models"
+	"github.com/ppowo/feedlet/internal/source/httpclient"
+)
+
+var updateGolden = flag.Bool("update", false, "rewrite testdata/*/items.json from the replayed fetch")
+
+// goldenSources are replayed from testdata/<dir>/http.json and compared with
+// testdata/<dir>/items.json. The directories are named like the slugs that
+// feedlet record gives the source names, so fixtures can be refreshed with
+// it as well as with go test -update.
+var goldenSources = []struct {
+	dir string
+	cfg models.SourceConfig
+}{
+	{"go-blog", models.SourceConfig{Name: "Go Blog", Type: "rss", URL: "https://go.dev/blog/feed.atom"}},
+	{"tildes-tech", models.SourceConfig{Name: "Tildes ~tech", Type: "tildes", URL: "https://tildes.net/~tech?order=votes&period=90d", Options: models.Options{"pages": 2}}},
+	{"hn-350", models.SourceConfig{Name: "HN 350+", Type: "hnalgolia", URL: "https://hn.algolia.com/api/v1/search_by_date?tags=story&numericFilters=points%3E350"}},
+	{"ptg", models.SourceConfig{Name: "/ptg/", Type: "desuarchive", URL: "g"}},
+	{"meltzerwiki", models.SourceConfig{Name: "meltzerwiki", Type: "meltzerwiki"}},
+	{"youtube-handle", models.SourceConfig{Name: "Fireship", Type: "youtube", URL: "@Fireship", Options: models.Options{"shorts": false}}},
+	{"youtube-playlist", models.SourceConfig{Name: "Code Report", Type: "youtube", URL: "https://www.youtube.com/playlist?list=PLjsBkJRSZ5Pb_yt0gN4bL2yTNRsb0nlQH"}},
+}
+
+func TestGolden(t *testing.T) {
+	for _, tt := range goldenSources {
+		t.Run(tt.dir, func(t *testing.T) {
+			dir := filepath.Join("testdata", tt.dir)
+			cassette, err := httpclient.LoadCassette(filepath.Join(dir, "http.json"))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			src, err := New(tt.cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			client := httpclient.NewClient(httpclient.NewReplayer(cassette))
+			client.RetryMax = 0
+			src.(HTTPSource).SetHTTPClient(client)
+
+			items, err := src.Fetch(context.Background())
+			if err != nil {
+				t.Fatalf("Fetch: %v", err)
+			}
+
+			var got bytes.Buffer
+			enc := json.NewEncoder(&got)
+			enc.SetEscapeHTML(false)
+			enc.SetIndent("", "  ")
+			if err := enc.Encode(items); err != nil {
+				t.Fatal(err)
+			}
+
+			goldenPath := filepath.Join(dir, "items.json")
+			if *updateGolden {
+				if err := os.WriteFile(goldenPath, got.Bytes(), 0o644); err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+
+			want, err := os.ReadFile(goldenPath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got.Bytes(), want) {
+				t.Errorf("items differ from %s (rerun with -update to accept):\n%s", goldenPath, lineDiff(string(want), got.String()))
+			}
+		})
+	}
+}
+
+// lineDiff describes the first line at which got differs from want.
+func lineDiff(want, got string) string {
+	wantLines := strings.Split(want, "\n")
+	gotLines := strings.Split(got, "\n")
+	for i := 0; i < len(wantLines) || i < len(gotLines); i++ {
+		var w, g string
+		if i < len(wantLines) {
+			w = wantLines[i]
+		}
+		if i < len(gotLines) {
+			g = gotLines[i]
+		}
+		if w != g {
+			return fmt.Sprintf("line %d:\n  want: %s\n  got:  %s", i+1, strings.TrimSpace(w), strings.TrimSpace(g))
+		}
+	}
+	return ""
+}
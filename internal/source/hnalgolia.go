@@ -12,6 +12,7 @@ import (
 	"time"
 
 	"github.com/ppowo/feedlet/internal/models"
+	"github.com/ppowo/feedlet/internal/source/fetcherr"
 	"github.com/ppowo/feedlet/internal/source/httpclient"
 )
 
@@ -38,14 +39,40 @@ type HNAlgoliaSource struct {
 	name       string
 	url        string
 	sourceType string
+	options    models.Options
+
+	clientHolder
+}
+
+func init() {
+	Register(TypeInfo{
+		Type:        "hnalgolia",
+		Description: "Hacker News search via hn.algolia.com",
+		Factory: func(cfg models.SourceConfig) (Source, error) {
+			if strings.TrimSpace(cfg.URL) == "" && !cfg.Options.Has("feed") {
+				return nil, fmt.Errorf("hnalgolia source %q needs a url or a feed option", cfg.Name)
+			}
+			return NewHNAlgoliaSource(cfg.Name, cfg.URL, cfg.Type, cfg.Options), nil
+		},
+		Params: []Param{
+			{Name: "url", Description: "Algolia search URL, or an HN feed-style URL as a shorthand for feed"},
+			{Name: "feed", Description: "frontpage, newest, ask, show, polls or newcomments"},
+			{Name: "count", Kind: models.OptionInt, Default: "20", Description: "Number of items"},
+			{Name: "q", Description: "Search query"},
+			{Name: "points", Kind: models.OptionInt, Description: "Minimum points"},
+			{Name: "comments", Kind: models.OptionInt, Description: "Minimum comment count"},
+			{Name: "description", Kind: models.OptionBool, Default: "true", Description: "Set to false to omit item descriptions"},
+		},
+	})
 }
 
 // NewHNAlgoliaSource creates a new direct Hacker News Algolia source.
-func NewHNAlgoliaSource(name, rawURL, sourceType string) *HNAlgoliaSource {
+func NewHNAlgoliaSource(name, rawURL, sourceType string, options models.Options) *HNAlgoliaSource {
 	return &HNAlgoliaSource{
 		name:       name,
 		url:        rawURL,
 		sourceType: sourceType,
+		options:    options,
 	}
 }
 
@@ -80,20 +107,21 @@ func (h *HNAlgoliaSource) Fetch(ctx context.Context) ([]models.Item, error) {
 	req.Header.Set("Accept", "application/json")
 	req.Header.Set("User-Agent", httpclient.RandomUserAgent())
 
-	client := httpclient.GetClient()
+	client := h.httpClient()
 	resp, err := client.StandardClient().Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch HN Algolia data for %s: %w", h.name, err)
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("failed to fetch HN Algolia data for %s: http %d %s", h.name, resp.StatusCode, resp.Status)
+	body, err := httpclient.ReadBody(resp, httpclient.ExpectJSON)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch HN Algolia data for %s: %w", h.name, err)
 	}
 
 	var payload hnAlgoliaResponse
-	if err := json.NewDecoder(resp.Body).Decode(&payload); err != nil {
-		return nil, fmt.Errorf("failed to decode HN Algolia response for %s: %w", h.name, err)
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, fetcherr.New(fetcherr.Parse, "failed to decode HN Algolia response for %s: %w", h.name, err)
 	}
 
 	items := make([]models.Item, 0, len(payload.Hits))
@@ -159,16 +187,19 @@ func (h *HNAlgoliaSource) buildRequestURL() (string, bool, error) {
 			q.Set("hitsPerPage", strconv.Itoa(defaultHNItemsPerReq))
 		}
 		srcURL.RawQuery = q.Encode()
-		return srcURL.String(), shouldIncludeDescription(srcURL.Query()), nil
+		return srcURL.String(), shouldIncludeDescription(newSettings(h.options, srcURL.Query())), nil
 	}
 
 	path := strings.Trim(srcURL.Path, "/")
+	if feed := h.options.String("feed", ""); feed != "" {
+		path = feed
+	}
 	tags, ok := hnFeedTagByPath[path]
 	if !ok {
 		return "", false, fmt.Errorf("unsupported HN feed path %q for %s", path, h.name)
 	}
 
-	sourceQuery := srcURL.Query()
+	sourceQuery := newSettings(h.options, srcURL.Query())
 	requestQuery := url.Values{}
 	requestQuery.Set("tags", tags)
 	requestQuery.Set("hitsPerPage", strconv.Itoa(parseBoundedInt(sourceQuery.Get("count"), defaultHNItemsPerReq, 1, maxHNItemsPerReq)))
@@ -258,6 +289,6 @@ func parseBoundedInt(value string, fallback, min, max int) int {
 	return fallback
 }
 
-func shouldIncludeDescription(q url.Values) bool {
-	return strings.TrimSpace(q.Get("description")) != "0"
+func shouldIncludeDescription(q settings) bool {
+	return q.Bool("description", true)
 }
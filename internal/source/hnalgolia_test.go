@@ -0,0 +1,34 @@
+package source
+
+import (
+	"testing"
+
+	"github.com/ppowo/feedlet/internal/models"
+)
+
+func TestHNAlgoliaDescriptionOption(t *testing.T) {
+	tests := []struct {
+		url     string
+		options models.Options
+		want    bool
+	}{
+		{"https://news.ycombinator.com/newest", nil, true},
+		{"https://news.ycombinator.com/newest?description=false", nil, false},
+		{"https://news.ycombinator.com/newest?description=0", nil, false},
+		{"https://news.ycombinator.com/newest?description=true", nil, true},
+		{"https://news.ycombinator.com/newest", models.Options{"description": false}, false},
+		{"https://news.ycombinator.com/newest?description=false", models.Options{"description": true}, true},
+		{"https://hn.algolia.com/api/v1/search?tags=story&description=false", nil, false},
+		{"https://hn.algolia.com/api/v1/search?tags=story", nil, true},
+	}
+	for _, tt := range tests {
+		src := NewHNAlgoliaSource("HN", tt.url, "hnalgolia", tt.options)
+		_, got, err := src.buildRequestURL()
+		if err != nil {
+			t.Fatalf("buildRequestURL(%q): %v", tt.url, err)
+		}
+		if got != tt.want {
+			t.Errorf("buildRequestURL(%q, %v) includes descriptions = %t, want %t", tt.url, tt.options, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,328 @@
+package source
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"html"
+	"net/http"
+	neturl "net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ppowo/feedlet/internal/models"
+	"github.com/ppowo/feedlet/internal/source/fetcherr"
+	"github.com/ppowo/feedlet/internal/source/httpclient"
+)
+
+const (
+	hnFirebaseBaseURL       = "https://hacker-news.firebaseio.com/v0"
+	defaultHNFirebaseCount  = 30
+	maxHNFirebaseCount      = 100
+	maxHNFirebaseComments   = 10
+	hnFirebaseMaxConcurrent = 8
+)
+
+var hnFirebaseListByPath = map[string]string{
+	"":       "topstories",
+	"news":   "topstories",
+	"best":   "beststories",
+	"ask":    "askstories",
+	"show":   "showstories",
+	"jobs":   "jobstories",
+	"newest": "newstories",
+}
+
+// HNFirebaseSource fetches ranked Hacker News listings from the official
+// Firebase API, preserving the order shown on the site.
+//
+// The URL is either an API list (https://hacker-news.firebaseio.com/v0/topstories.json)
+// or a site page (https://news.ycombinator.com/, /best, /ask, /show, /jobs,
+// /newest). Query parameters: count (stories, default 30) and comments (number
+// of top-level comments to include as Content, default 0).
+type HNFirebaseSource struct {
+	name     string
+	list     string
+	count    int
+	comments int
+
+	clientHolder
+	lastExtract
+}
+
+func init() {
+	Register(TypeInfo{
+		Type:        "hnfirebase",
+		Description: "Ranked Hacker News listings from the official API",
+		Factory: func(cfg models.SourceConfig) (Source, error) {
+			return NewHNFirebaseSource(cfg.Name, cfg.URL, cfg.Options)
+		},
+		Params: []Param{
+			{Name: "url", Required: true, Description: "https://news.ycombinator.com/ (or /best, /ask, /show, /jobs, /newest) or an API list URL"},
+			{Name: "count", Kind: models.OptionInt, Default: "30", Description: "Number of stories"},
+			{Name: "comments", Kind: models.OptionInt, Default: "0", Description: "Top comments to include per story"},
+		},
+		Capabilities: Capabilities{Scores: true},
+	})
+}
+
+// NewHNFirebaseSource creates a new Hacker News Firebase source.
+func NewHNFirebaseSource(name, rawURL string, options models.Options) (*HNFirebaseSource, error) {
+	parsed, err := neturl.Parse(strings.TrimSpace(rawURL))
+	if err != nil {
+		return nil, fmt.Errorf("invalid HN source URL for %s: %w", name, err)
+	}
+
+	path := strings.Trim(parsed.Path, "/")
+	list := ""
+	if strings.EqualFold(parsed.Host, "hacker-news.firebaseio.com") {
+		list = strings.TrimSuffix(strings.TrimPrefix(path, "v0/"), ".json")
+	} else {
+		var ok bool
+		if list, ok = hnFirebaseListByPath[path]; !ok {
+			return nil, fmt.Errorf("unsupported HN listing %q for %s", path, name)
+		}
+	}
+
+	q := newSettings(options, parsed.Query())
+	return &HNFirebaseSource{
+		name:     name,
+		list:     list,
+		count:    parseBoundedInt(q.Get("count"), defaultHNFirebaseCount, 1, maxHNFirebaseCount),
+		comments: parseBoundedInt(q.Get("comments"), 0, 0, maxHNFirebaseComments),
+	}, nil
+}
+
+type hnFirebaseItem struct {
+	ID          int    `json:"id"`
+	Type        string `json:"type"`
+	By          string `json:"by"`
+	Time        int64  `json:"time"`
+	Title       string `json:"title"`
+	URL         string `json:"url"`
+	Text        string `json:"text"`
+	Score       int    `json:"score"`
+	Descendants int    `json:"descendants"`
+	Kids        []int  `json:"kids"`
+	Deleted     bool   `json:"deleted"`
+	Dead        bool   `json:"dead"`
+}
+
+// Fetch retrieves the ranked stories and, optionally, their top comments.
+func (h *HNFirebaseSource) Fetch(ctx context.Context) ([]models.Item, error) {
+	var ids []int
+	if err := h.getJSON(ctx, hnFirebaseBaseURL+"/"+h.list+".json", &ids); err != nil {
+		return nil, err
+	}
+	if len(ids) > h.count {
+		ids = ids[:h.count]
+	}
+
+	// Items that fail are skipped, but the first error is kept in case
+	// none come back.
+	var (
+		errMu    sync.Mutex
+		firstErr error
+		failed   int
+	)
+	fail := func(err error) {
+		errMu.Lock()
+		defer errMu.Unlock()
+		if firstErr == nil {
+			firstErr = err
+		}
+		failed++
+	}
+
+	stories := make([]*hnFirebaseItem, len(ids))
+	commentSets := make([][]*hnFirebaseItem, len(ids))
+	h.forEach(ctx, len(ids), func(i int) {
+		story, err := h.getItem(ctx, ids[i])
+		if err != nil {
+			fail(err)
+			return
+		}
+		stories[i] = story
+	})
+
+	recorder := &extractRecorder{}
+	recorder.page()
+	for range ids {
+		recorder.seen()
+	}
+	for range failed {
+		recorder.missing("item")
+	}
+
+	if h.comments > 0 {
+		type commentRef struct{ story, pos, id int }
+		refs := make([]commentRef, 0, len(ids)*h.comments)
+		for i, story := range stories {
+			if story == nil {
+				continue
+			}
+			kids := story.Kids
+			if len(kids) > h.comments {
+				kids = kids[:h.comments]
+			}
+			commentSets[i] = make([]*hnFirebaseItem, len(kids))
+			for pos, id := range kids {
+				refs = append(refs, commentRef{story: i, pos: pos, id: id})
+			}
+		}
+
+		h.forEach(ctx, len(refs), func(i int) {
+			ref := refs[i]
+			comment, err := h.getItem(ctx, ref.id)
+			if err != nil {
+				// A missing comment leaves the story usable
+				return
+			}
+			commentSets[ref.story][ref.pos] = comment
+		})
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("failed to fetch HN %s for %s: %w", h.list, h.name, err)
+	}
+
+	items := make([]models.Item, 0, len(stories))
+	for i, story := range stories {
+		if story == nil || story.Deleted || story.Dead || story.Time == 0 {
+			continue
+		}
+
+		description := h.buildDescription(story)
+		content := strings.TrimSpace(story.Text)
+		if comments := h.buildComments(commentSets[i]); comments != "" {
+			content = comments
+		}
+		if content == "" {
+			content = description
+		}
+
+		recorder.parsed()
+		items = append(items, models.Item{
+			Title:       strings.TrimSpace(story.Title),
+			Link:        hnItemLink(story.ID),
+			Description: description,
+			Content:     content,
+			Author:      story.By,
+			Published:   time.Unix(story.Time, 0).UTC(),
+			SourceName:  h.name,
+			SourceType:  "hnfirebase",
+			Rank:        i + 1,
+			Score:       story.Score,
+			Comments:    story.Descendants,
+		})
+	}
+
+	h.store(recorder)
+
+	if len(items) == 0 && len(ids) > 0 {
+		if firstErr != nil {
+			return nil, fmt.Errorf("failed to fetch any HN %s items for %s: %w", h.list, h.name, firstErr)
+		}
+		return nil, fetcherr.New(fetcherr.Empty, "no live HN %s items for %s", h.list, h.name)
+	}
+
+	return items, nil
+}
+
+// Name returns the source name.
+func (h *HNFirebaseSource) Name() string {
+	return h.name
+}
+
+// Type returns the source type.
+func (h *HNFirebaseSource) Type() string {
+	return "hnfirebase"
+}
+
+// forEach calls fn for 0..n-1 with at most hnFirebaseMaxConcurrent calls in flight.
+func (h *HNFirebaseSource) forEach(ctx context.Context, n int, fn func(i int)) {
+	sem := make(chan struct{}, hnFirebaseMaxConcurrent)
+	var wg sync.WaitGroup
+
+	for i := 0; i < n; i++ {
+		select {
+		case <-ctx.Done():
+			wg.Wait()
+			return
+		case sem <- struct{}{}:
+		}
+
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			fn(i)
+		}(i)
+	}
+
+	wg.Wait()
+}
+
+func (h *HNFirebaseSource) getItem(ctx context.Context, id int) (*hnFirebaseItem, error) {
+	var item hnFirebaseItem
+	if err := h.getJSON(ctx, fmt.Sprintf("%s/item/%d.json", hnFirebaseBaseURL, id), &item); err != nil {
+		return nil, err
+	}
+	return &item, nil
+}
+
+func (h *HNFirebaseSource) getJSON(ctx context.Context, requestURL string, dst any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, requestURL, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create HN request for %s: %w", h.name, err)
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", httpclient.RandomUserAgent())
+
+	resp, err := h.httpClient().StandardClient().Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to fetch HN data for %s: %w", h.name, err)
+	}
+	defer resp.Body.Close()
+
+	body, err := httpclient.ReadBody(resp, httpclient.ExpectJSON)
+	if err != nil {
+		return fmt.Errorf("failed to fetch HN data for %s: %w", h.name, err)
+	}
+
+	if err := json.Unmarshal(body, dst); err != nil {
+		return fetcherr.New(fetcherr.Parse, "failed to decode HN response for %s: %w", h.name, err)
+	}
+	return nil
+}
+
+func (h *HNFirebaseSource) buildDescription(story *hnFirebaseItem) string {
+	commentsURL := html.EscapeString(hnItemLink(story.ID))
+	var b strings.Builder
+
+	if story.URL != "" {
+		escapedURL := html.EscapeString(story.URL)
+		fmt.Fprintf(&b, "<p>Article URL: <a href=\"%s\">%s</a></p>\n", escapedURL, escapedURL)
+	}
+	fmt.Fprintf(&b, "<p>Comments URL: <a href=\"%s\">%s</a></p>\n", commentsURL, commentsURL)
+	fmt.Fprintf(&b, "<p>Points: %d</p>\n", story.Score)
+	fmt.Fprintf(&b, "<p># Comments: %d</p>\n", story.Descendants)
+
+	return b.String()
+}
+
+func (h *HNFirebaseSource) buildComments(comments []*hnFirebaseItem) string {
+	var b strings.Builder
+	for _, comment := range comments {
+		if comment == nil || comment.Deleted || comment.Dead || strings.TrimSpace(comment.Text) == "" {
+			continue
+		}
+		fmt.Fprintf(&b, "<blockquote><p><b>%s</b>:</p>\n<p>%s</p></blockquote>\n", html.EscapeString(comment.By), comment.Text)
+	}
+	return b.String()
+}
+
+func hnItemLink(id int) string {
+	return fmt.Sprintf("https://news.ycombinator.com/item?id=%d", id)
+}
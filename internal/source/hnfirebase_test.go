@@ -0,0 +1,94 @@
+package source
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/ppowo/feedlet/internal/source/fetcherr"
+	"github.com/ppowo/feedlet/internal/source/httpclient"
+)
+
+func hnFirebaseTestSource(t *testing.T, interactions []httpclient.Interaction) *HNFirebaseSource {
+	t.Helper()
+	src, err := NewHNFirebaseSource("HN", "https://news.ycombinator.com/?count=3", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cassette := &httpclient.Cassette{Interactions: interactions}
+	src.SetHTTPClient(httpclient.NewClient(httpclient.NewReplayer(cassette)))
+	return src
+}
+
+func hnJSON(url, body string) httpclient.Interaction {
+	return httpclient.Interaction{
+		Method: http.MethodGet,
+		URL:    url,
+		Status: http.StatusOK,
+		Header: http.Header{"Content-Type": {"application/json"}},
+		Body:   body,
+	}
+}
+
+func hnNotFound(url string) httpclient.Interaction {
+	return httpclient.Interaction{
+		Method: http.MethodGet,
+		URL:    url,
+		Status: http.StatusNotFound,
+		Header: http.Header{"Content-Type": {"application/json"}},
+		Body:   "null",
+	}
+}
+
+func TestHNFirebasePartialFailure(t *testing.T) {
+	src := hnFirebaseTestSource(t, []httpclient.Interaction{
+		hnJSON(hnFirebaseBaseURL+"/topstories.json", "[1,2,3]"),
+		hnJSON(hnFirebaseBaseURL+"/item/1.json", `{"id":1,"type":"story","by":"a","time":1700000000,"title":"One","score":10}`),
+		hnNotFound(hnFirebaseBaseURL + "/item/2.json"),
+		hnJSON(hnFirebaseBaseURL+"/item/3.json", `{"id":3,"type":"story","by":"c","time":1700000100,"title":"Three","score":30}`),
+	})
+
+	items, err := src.Fetch(context.Background())
+	if err != nil {
+		t.Fatalf("Fetch: %v", err)
+	}
+	if len(items) != 2 || items[0].Title != "One" || items[1].Title != "Three" {
+		t.Fatalf("items = %+v, want One and Three", items)
+	}
+	if items[1].Rank != 3 {
+		t.Errorf("Three has rank %d, want its listing position 3", items[1].Rank)
+	}
+
+	stats := src.ExtractStats()
+	if stats.RowsSeen != 3 || stats.RowsParsed != 2 || stats.MissingFields["item"] != 1 {
+		t.Errorf("stats = %+v, want 3 seen, 2 parsed, 1 missing item", stats)
+	}
+}
+
+func TestHNFirebaseAllItemsFail(t *testing.T) {
+	src := hnFirebaseTestSource(t, []httpclient.Interaction{
+		hnJSON(hnFirebaseBaseURL+"/topstories.json", "[1,2]"),
+		hnNotFound(hnFirebaseBaseURL + "/item/1.json"),
+		hnNotFound(hnFirebaseBaseURL + "/item/2.json"),
+	})
+
+	_, err := src.Fetch(context.Background())
+	if err == nil {
+		t.Fatal("Fetch succeeded, want an error")
+	}
+	if info := fetcherr.Classify(err); info.Class != fetcherr.HTTPStatus || info.StatusCode != http.StatusNotFound {
+		t.Errorf("error %q classified as %+v, want http_status 404", err, info)
+	}
+}
+
+func TestHNFirebaseAllItemsDead(t *testing.T) {
+	src := hnFirebaseTestSource(t, []httpclient.Interaction{
+		hnJSON(hnFirebaseBaseURL+"/topstories.json", "[1]"),
+		hnJSON(hnFirebaseBaseURL+"/item/1.json", `{"id":1,"type":"story","time":1700000000,"dead":true}`),
+	})
+
+	_, err := src.Fetch(context.Background())
+	if class := fetcherr.Classify(err).Class; class != fetcherr.Empty {
+		t.Errorf("error %v classified as %s, want empty", err, class)
+	}
+}
@@ -0,0 +1,222 @@
+package httpclient
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/hashicorp/go-retryablehttp"
+)
+
+// cassetteHeaders are the response headers kept in a cassette. Everything
+// else, notably Set-Cookie, is dropped so fixtures hold no credentials.
+var cassetteHeaders = []string{
+	"Content-Type", "Etag", "Last-Modified", "Link", "Location", "Retry-After", "Server", "Cf-Mitigated",
+}
+
+// Interaction is one recorded request and its response or transport error.
+type Interaction struct {
+	Method       string      `json:"method"`
+	URL          string      `json:"url"`
+	Status       int         `json:"status,omitempty"`
+	Header       http.Header `json:"header,omitempty"`
+	Body         string      `json:"body,omitempty"`
+	BodyEncoding string      `json:"body_encoding,omitempty"` // "base64" for non-UTF-8 bodies
+	Error        string      `json:"error,omitempty"`
+}
+
+// Cassette is a sequence of recorded HTTP interactions, stored as JSON.
+type Cassette struct {
+	Interactions []Interaction `json:"interactions"`
+}
+
+// LoadCassette reads a cassette written by Recorder.Save.
+func LoadCassette(path string) (*Cassette, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var c Cassette
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, fmt.Errorf("invalid cassette %s: %w", path, err)
+	}
+	return &c, nil
+}
+
+// NewClient returns a client with the retry settings of the shared client
+// that sends requests through transport.
+func NewClient(transport http.RoundTripper) *retryablehttp.Client {
+	c := retryablehttp.NewClient()
+	c.HTTPClient.Transport = transport
+	c.RetryMax = client.RetryMax
+	c.RetryWaitMin = client.RetryWaitMin
+	c.RetryWaitMax = client.RetryWaitMax
+	c.Logger = nil
+	c.CheckRetry = client.CheckRetry
+	c.ErrorHandler = client.ErrorHandler
+	return c
+}
+
+// Transport returns the transport of the shared client, which applies the
+// HTTP profile and headers found in the request context.
+func Transport() http.RoundTripper {
+	return dispatchTransport{}
+}
+
+// Recorder is a transport that records every interaction passing through it.
+type Recorder struct {
+	next http.RoundTripper
+
+	mu       sync.Mutex
+	cassette Cassette
+}
+
+// NewRecorder records the interactions of next.
+func NewRecorder(next http.RoundTripper) *Recorder {
+	return &Recorder{next: next}
+}
+
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	interaction := Interaction{Method: req.Method, URL: req.URL.String()}
+
+	resp, err := r.next.RoundTrip(req)
+	if err != nil {
+		interaction.Error = err.Error()
+		r.add(interaction)
+		return nil, err
+	}
+
+	body, readErr := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	if readErr != nil {
+		interaction.Error = readErr.Error()
+		r.add(interaction)
+		return nil, readErr
+	}
+
+	interaction.Status = resp.StatusCode
+	for _, key := range cassetteHeaders {
+		if values := resp.Header.Values(key); len(values) > 0 {
+			if interaction.Header == nil {
+				interaction.Header = make(http.Header)
+			}
+			interaction.Header[key] = values
+		}
+	}
+	if utf8.Valid(body) {
+		interaction.Body = string(body)
+	} else {
+		interaction.Body = base64.StdEncoding.EncodeToString(body)
+		interaction.BodyEncoding = "base64"
+	}
+	r.add(interaction)
+	return resp, nil
+}
+
+func (r *Recorder) add(interaction Interaction) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.cassette.Interactions = append(r.cassette.Interactions, interaction)
+}
+
+// Len returns the number of recorded interactions.
+func (r *Recorder) Len() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.cassette.Interactions)
+}
+
+// Save writes the recorded interactions to path as JSON.
+func (r *Recorder) Save(path string) error {
+	// Bodies are mostly markup, so keep it readable in diffs.
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	r.mu.Lock()
+	err := enc.Encode(r.cassette)
+	r.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, buf.Bytes(), 0o644)
+}
+
+// Replayer is a transport that answers requests from a cassette without
+// touching the network. Requests are matched by method and URL; repeated
+// requests get the recorded responses in order, then the last one again.
+type Replayer struct {
+	mu           sync.Mutex
+	interactions map[string][]Interaction
+	next         map[string]int
+}
+
+// NewReplayer replays the interactions of c.
+func NewReplayer(c *Cassette) *Replayer {
+	r := &Replayer{
+		interactions: make(map[string][]Interaction),
+		next:         make(map[string]int),
+	}
+	for _, interaction := range c.Interactions {
+		key := interaction.Method + " " + interaction.URL
+		r.interactions[key] = append(r.interactions[key], interaction)
+	}
+	return r
+}
+
+func (r *Replayer) RoundTrip(req *http.Request) (*http.Response, error) {
+	key := req.Method + " " + req.URL.String()
+
+	r.mu.Lock()
+	recorded := r.interactions[key]
+	i := r.next[key]
+	if i < len(recorded)-1 {
+		r.next[key] = i + 1
+	}
+	r.mu.Unlock()
+
+	if len(recorded) == 0 {
+		return nil, fmt.Errorf("replay: no recorded response for %s", key)
+	}
+	interaction := recorded[i]
+	if interaction.Error != "" {
+		return nil, errors.New(interaction.Error)
+	}
+
+	body := []byte(interaction.Body)
+	if interaction.BodyEncoding == "base64" {
+		decoded, err := base64.StdEncoding.DecodeString(interaction.Body)
+		if err != nil {
+			return nil, fmt.Errorf("replay: invalid body for %s: %w", key, err)
+		}
+		body = decoded
+	}
+
+	header := interaction.Header.Clone()
+	if header == nil {
+		header = make(http.Header)
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", interaction.Status, http.StatusText(interaction.Status)),
+		StatusCode:    interaction.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}, nil
+}
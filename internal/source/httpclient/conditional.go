@@ -0,0 +1,101 @@
+package httpclient
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+
+	"github.com/hashicorp/go-retryablehttp"
+)
+
+// ConditionalCache remembers the validators and body of the last successful
+// response per URL so repeat requests can be sent as conditional GETs.
+// A 304 Not Modified answer is served from the cached body.
+type ConditionalCache struct {
+	mu      sync.Mutex
+	entries map[string]conditionalEntry
+	client  *retryablehttp.Client
+}
+
+type conditionalEntry struct {
+	etag         string
+	lastModified string
+	body         []byte
+}
+
+// NewConditionalCache creates an empty conditional request cache.
+func NewConditionalCache() *ConditionalCache {
+	return &ConditionalCache{
+		entries: make(map[string]conditionalEntry),
+	}
+}
+
+// SetClient makes the cache send its requests through client instead of the
+// shared one.
+func (c *ConditionalCache) SetClient(client *retryablehttp.Client) {
+	c.client = client
+}
+
+// Get fetches rawURL with the given extra headers. The returned body is either
+// fresh or, on 304 Not Modified, the body of the previous 200 response. Fresh
+// bodies are checked by ReadBody against want.
+func (c *ConditionalCache) Get(ctx context.Context, rawURL string, header http.Header, want Expect) ([]byte, error) {
+	req, err := retryablehttp.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	for key, values := range header {
+		for _, value := range values {
+			req.Header.Add(key, value)
+		}
+	}
+	if req.Header.Get("User-Agent") == "" {
+		req.Header.Set("User-Agent", RandomUserAgent())
+	}
+
+	c.mu.Lock()
+	entry, cached := c.entries[rawURL]
+	c.mu.Unlock()
+
+	if cached {
+		if entry.etag != "" {
+			req.Header.Set("If-None-Match", entry.etag)
+		}
+		if entry.lastModified != "" {
+			req.Header.Set("If-Modified-Since", entry.lastModified)
+		}
+	}
+
+	client := c.client
+	if client == nil {
+		client = GetClient()
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotModified && cached {
+		return entry.body, nil
+	}
+	body, err := ReadBody(resp, want)
+	if err != nil {
+		return nil, err
+	}
+
+	etag := resp.Header.Get("ETag")
+	lastModified := resp.Header.Get("Last-Modified")
+	if etag != "" || lastModified != "" {
+		c.mu.Lock()
+		c.entries[rawURL] = conditionalEntry{
+			etag:         etag,
+			lastModified: lastModified,
+			body:         body,
+		}
+		c.mu.Unlock()
+	}
+
+	return body, nil
+}
@@ -2,7 +2,6 @@ package httpclient
 
 import (
 	"context"
-	"crypto/tls"
 	"errors"
 	"math/rand"
 	"net"
@@ -34,29 +33,18 @@ var (
 func init() {
 	rng = rand.New(rand.NewSource(time.Now().UnixNano()))
 
-	// Use a custom transport that disables HTTP/2.
-	// Go's default HTTP/2 TLS fingerprint is well-known and blocked
-	// by anti-bot services (e.g. Reddit/Cloudflare).
-	transport := &http.Transport{
-		TLSNextProto: make(map[string]func(authority string, c *tls.Conn) http.RoundTripper),
-		DialContext: (&net.Dialer{
-			Timeout:   30 * time.Second,
-			KeepAlive: 30 * time.Second,
-		}).DialContext,
-		TLSHandshakeTimeout: 10 * time.Second,
-		MaxIdleConns:        100,
-		MaxIdleConnsPerHost: 10,
-		IdleConnTimeout:     90 * time.Second,
-	}
-
+	// Requests go through the transport of the HTTP profile named in their
+	// context (see WithProfile), which also enforces the request timeout.
 	client = retryablehttp.NewClient()
-	client.HTTPClient.Timeout = 30 * time.Second
-	client.HTTPClient.Transport = transport
+	client.HTTPClient.Transport = dispatchTransport{}
 	client.RetryMax = 1
 	client.RetryWaitMin = 1 * time.Second
 	client.RetryWaitMax = 30 * time.Second
 	client.Logger = nil
 	client.CheckRetry = shouldRetryHTTP
+	// Hand the last response to the caller once retries are exhausted so
+	// ReadBody can still classify it (e.g. a 503 challenge page).
+	client.ErrorHandler = retryablehttp.PassthroughErrorHandler
 }
 
 func shouldRetryHTTP(ctx context.Context, resp *http.Response, err error) (bool, error) {
@@ -81,14 +69,27 @@ func shouldRetryHTTP(ctx context.Context, resp *http.Response, err error) (bool,
 		return false, nil
 	}
 
+	// 429 is not retried here: the fetcher backs the source off instead,
+	// honouring Retry-After.
 	switch resp.StatusCode {
-	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
 		return true, nil
 	default:
 		return false, nil
 	}
 }
 
+type headersKey struct{}
+
+// WithHeaders returns a context whose requests through the shared client carry
+// the given headers, overriding those set by the source and its profile.
+func WithHeaders(ctx context.Context, headers http.Header) context.Context {
+	if len(headers) == 0 {
+		return ctx
+	}
+	return context.WithValue(ctx, headersKey{}, headers)
+}
+
 func GetClient() *retryablehttp.Client {
 	return client
 }
@@ -0,0 +1,311 @@
+package httpclient
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ppowo/feedlet/internal/models"
+)
+
+const (
+	defaultRequestTimeout = 30 * time.Second
+	defaultDialTimeout    = 30 * time.Second
+	defaultTLSTimeout     = 10 * time.Second
+	defaultMaxBodyBytes   = 32 << 20
+)
+
+// ErrBodyTooLarge is returned while reading a response body that exceeds the
+// profile's MaxBodyBytes.
+var ErrBodyTooLarge = errors.New("response body too large")
+
+// profile is the runtime form of a models.HTTPProfile.
+type profile struct {
+	transport http.RoundTripper
+	headers   http.Header
+	userAgent string
+	jar       http.CookieJar
+	timeout   time.Duration
+	maxBody   int64
+}
+
+var (
+	profilesMu     sync.RWMutex
+	profiles       = make(map[string]*profile)
+	defaultProfile = mustNewProfile(models.HTTPProfile{})
+)
+
+type profileKey struct{}
+
+// Configure builds the named HTTP profiles from the config, replacing any
+// configured before. It is meant to be called once at startup.
+func Configure(cfgs map[string]models.HTTPProfile) error {
+	built := make(map[string]*profile, len(cfgs))
+	for name, cfg := range cfgs {
+		p, err := newProfile(cfg)
+		if err != nil {
+			return fmt.Errorf("http profile %q: %w", name, err)
+		}
+		built[name] = p
+	}
+
+	profilesMu.Lock()
+	profiles = built
+	profilesMu.Unlock()
+	return nil
+}
+
+// WithProfile returns a context whose requests through the shared client use
+// the named profile. Unknown names fall back to the default profile.
+func WithProfile(ctx context.Context, name string) context.Context {
+	if name == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, profileKey{}, name)
+}
+
+func profileFor(ctx context.Context) *profile {
+	name, _ := ctx.Value(profileKey{}).(string)
+	if name == "" {
+		return defaultProfile
+	}
+
+	profilesMu.RLock()
+	defer profilesMu.RUnlock()
+	if p, ok := profiles[name]; ok {
+		return p
+	}
+	return defaultProfile
+}
+
+func mustNewProfile(cfg models.HTTPProfile) *profile {
+	p, err := newProfile(cfg)
+	if err != nil {
+		panic(err)
+	}
+	return p
+}
+
+func newProfile(cfg models.HTTPProfile) (*profile, error) {
+	dialTimeout := defaultDialTimeout
+	tlsTimeout := defaultTLSTimeout
+	if cfg.ConnectTimeout > 0 {
+		dialTimeout = time.Duration(cfg.ConnectTimeout) * time.Second
+		tlsTimeout = dialTimeout
+	}
+
+	transport := &http.Transport{
+		DialContext: (&net.Dialer{
+			Timeout:   dialTimeout,
+			KeepAlive: 30 * time.Second,
+		}).DialContext,
+		TLSHandshakeTimeout: tlsTimeout,
+		MaxIdleConns:        100,
+		MaxIdleConnsPerHost: 10,
+		IdleConnTimeout:     90 * time.Second,
+	}
+
+	// HTTP/2 stays off unless asked for: Go's HTTP/2 TLS fingerprint is
+	// well-known and blocked by anti-bot services (e.g. Reddit/Cloudflare).
+	if cfg.HTTP2 {
+		transport.ForceAttemptHTTP2 = true
+	} else {
+		transport.TLSNextProto = make(map[string]func(authority string, c *tls.Conn) http.RoundTripper)
+	}
+
+	if cfg.Proxy != "" {
+		proxyURL, err := url.Parse(cfg.Proxy)
+		if err != nil {
+			return nil, fmt.Errorf("invalid proxy URL: %w", err)
+		}
+		switch proxyURL.Scheme {
+		case "http", "https", "socks5", "socks5h":
+		default:
+			return nil, fmt.Errorf("unsupported proxy scheme %q", proxyURL.Scheme)
+		}
+		transport.Proxy = http.ProxyURL(proxyURL)
+	}
+
+	if cfg.CABundle != "" {
+		pem, err := os.ReadFile(cfg.CABundle)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA bundle: %w", err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("CA bundle %s contains no certificates", cfg.CABundle)
+		}
+		transport.TLSClientConfig = &tls.Config{RootCAs: pool}
+	}
+
+	p := &profile{
+		transport: transport,
+		userAgent: strings.TrimSpace(cfg.UserAgent),
+		timeout:   defaultRequestTimeout,
+		maxBody:   defaultMaxBodyBytes,
+	}
+	if cfg.Timeout > 0 {
+		p.timeout = time.Duration(cfg.Timeout) * time.Second
+	}
+	if cfg.MaxBodyBytes > 0 {
+		p.maxBody = cfg.MaxBodyBytes
+	}
+	if len(cfg.Headers) > 0 {
+		p.headers = make(http.Header, len(cfg.Headers))
+		for key, value := range cfg.Headers {
+			p.headers.Set(key, value)
+		}
+	}
+	if cfg.CookieFile != "" {
+		jar, err := loadCookieFile(cfg.CookieFile)
+		if err != nil {
+			return nil, err
+		}
+		p.jar = jar
+	}
+
+	return p, nil
+}
+
+// dispatchTransport is the transport of the shared client. It applies the
+// profile and per-source headers found in the request context.
+type dispatchTransport struct{}
+
+func (dispatchTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	p := profileFor(req.Context())
+
+	ctx, cancel := context.WithTimeout(req.Context(), p.timeout)
+	req = req.Clone(ctx)
+
+	if p.userAgent != "" {
+		req.Header.Set("User-Agent", p.userAgent)
+	}
+	for key, values := range p.headers {
+		req.Header[key] = values
+	}
+	if headers, ok := req.Context().Value(headersKey{}).(http.Header); ok {
+		for key, values := range headers {
+			req.Header[key] = values
+		}
+	}
+	if p.jar != nil {
+		for _, cookie := range p.jar.Cookies(req.URL) {
+			req.AddCookie(cookie)
+		}
+	}
+
+	resp, err := p.transport.RoundTrip(req)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	if p.jar != nil {
+		if cookies := resp.Cookies(); len(cookies) > 0 {
+			p.jar.SetCookies(req.URL, cookies)
+		}
+	}
+	resp.Body = &limitedBody{body: resp.Body, max: p.maxBody, cancel: cancel}
+	return resp, nil
+}
+
+// limitedBody fails reads past max bytes and releases the request's timeout
+// context when closed.
+type limitedBody struct {
+	body   io.ReadCloser
+	max    int64
+	read   int64
+	cancel context.CancelFunc
+}
+
+func (b *limitedBody) Read(p []byte) (int, error) {
+	if b.read > b.max {
+		return 0, ErrBodyTooLarge
+	}
+
+	n, err := b.body.Read(p)
+	b.read += int64(n)
+	if b.read > b.max {
+		return n - int(b.read-b.max), ErrBodyTooLarge
+	}
+	return n, err
+}
+
+func (b *limitedBody) Close() error {
+	b.cancel()
+	return b.body.Close()
+}
+
+// loadCookieFile reads a Netscape/curl cookies.txt file, as exported by
+// browser extensions, into a cookie jar.
+func loadCookieFile(path string) (http.CookieJar, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open cookie file: %w", err)
+	}
+	defer file.Close()
+
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		return nil, err
+	}
+
+	scanner := bufio.NewScanner(file)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := strings.TrimSpace(scanner.Text())
+		httpOnly := strings.HasPrefix(line, "#HttpOnly_")
+		line = strings.TrimPrefix(line, "#HttpOnly_")
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Split(line, "\t")
+		if len(fields) != 7 {
+			return nil, fmt.Errorf("cookie file %s line %d: expected 7 tab-separated fields", path, lineNo)
+		}
+
+		domain := fields[0]
+		host := strings.TrimPrefix(domain, ".")
+		secure := strings.EqualFold(fields[3], "TRUE")
+		cookie := &http.Cookie{
+			Name:     fields[5],
+			Value:    fields[6],
+			Path:     fields[2],
+			Secure:   secure,
+			HttpOnly: httpOnly,
+		}
+		if strings.EqualFold(fields[1], "TRUE") {
+			cookie.Domain = host
+		}
+		if expires, err := strconv.ParseInt(fields[4], 10, 64); err == nil && expires > 0 {
+			cookie.Expires = time.Unix(expires, 0)
+		}
+
+		scheme := "http"
+		if secure {
+			scheme = "https"
+		}
+		jar.SetCookies(&url.URL{Scheme: scheme, Host: host, Path: fields[2]}, []*http.Cookie{cookie})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read cookie file: %w", err)
+	}
+
+	return jar, nil
+}
@@ -0,0 +1,178 @@
+package httpclient
+
+import (
+	"context"
+	"encoding/pem"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ppowo/feedlet/internal/models"
+)
+
+// writeFile writes content to a file in a fresh temp dir and returns its path.
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestConfigure(t *testing.T) {
+	t.Cleanup(func() { Configure(nil) })
+
+	srv := httptest.NewTLSServer(http.NotFoundHandler())
+	defer srv.Close()
+	bundle := writeFile(t, "ca.pem", string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw})))
+	cookies := writeFile(t, "cookies.txt", "# Netscape HTTP Cookie File\n.example.com\tTRUE\t/\tFALSE\t0\tsession\tabc\n")
+
+	tests := []struct {
+		name    string
+		cfg     models.HTTPProfile
+		wantErr string
+	}{
+		{"empty", models.HTTPProfile{}, ""},
+		{"http proxy", models.HTTPProfile{Proxy: "http://proxy.example:8080"}, ""},
+		{"https proxy", models.HTTPProfile{Proxy: "https://proxy.example:8443"}, ""},
+		{"socks5 proxy", models.HTTPProfile{Proxy: "socks5://127.0.0.1:1080"}, ""},
+		{"socks5h proxy", models.HTTPProfile{Proxy: "socks5h://127.0.0.1:1080"}, ""},
+		{"socks4 proxy", models.HTTPProfile{Proxy: "socks4://127.0.0.1:1080"}, "unsupported proxy scheme"},
+		{"proxy without scheme", models.HTTPProfile{Proxy: "proxy.example:8080"}, "unsupported proxy scheme"},
+		{"malformed proxy", models.HTTPProfile{Proxy: "http://[::1"}, "invalid proxy URL"},
+		{"CA bundle", models.HTTPProfile{CABundle: bundle}, ""},
+		{"missing CA bundle", models.HTTPProfile{CABundle: filepath.Join(t.TempDir(), "none.pem")}, "failed to read CA bundle"},
+		{"CA bundle without certificates", models.HTTPProfile{CABundle: writeFile(t, "empty.pem", "not a certificate\n")}, "contains no certificates"},
+		{"cookie file", models.HTTPProfile{CookieFile: cookies}, ""},
+		{"missing cookie file", models.HTTPProfile{CookieFile: filepath.Join(t.TempDir(), "none.txt")}, "failed to open cookie file"},
+		{"malformed cookie file", models.HTTPProfile{CookieFile: writeFile(t, "bad.txt", "example.com\tTRUE\t/\n")}, "line 1: expected 7 tab-separated fields"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Configure(map[string]models.HTTPProfile{"test": tt.cfg})
+			switch {
+			case tt.wantErr == "" && err != nil:
+				t.Errorf("Configure: %v", err)
+			case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+				t.Errorf("Configure error = %v, want one containing %q", err, tt.wantErr)
+			case tt.wantErr != "" && !strings.Contains(err.Error(), `http profile "test"`):
+				t.Errorf("Configure error %q does not name the profile", err)
+			}
+		})
+	}
+}
+
+func TestCABundleIsTrusted(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+	bundle := writeFile(t, "ca.pem", string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw})))
+
+	p, err := newProfile(models.HTTPProfile{CABundle: bundle})
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := (&http.Client{Transport: p.transport}).Get(srv.URL)
+	if err != nil {
+		t.Fatalf("request with the bundle: %v", err)
+	}
+	resp.Body.Close()
+
+	if _, err := (&http.Client{Transport: defaultProfile.transport}).Get(srv.URL); err == nil {
+		t.Error("test server trusted without the bundle")
+	}
+}
+
+func TestLoadCookieFile(t *testing.T) {
+	future := strconv.FormatInt(time.Now().Add(24*time.Hour).Unix(), 10)
+	past := strconv.FormatInt(time.Now().Add(-24*time.Hour).Unix(), 10)
+	path := writeFile(t, "cookies.txt", strings.Join([]string{
+		"# Netscape HTTP Cookie File",
+		"",
+		".example.com\tTRUE\t/\tFALSE\t" + future + "\tshared\t1",
+		"example.com\tFALSE\t/\tFALSE\t0\thost_only\t2",
+		"#HttpOnly_example.com\tFALSE\t/account\tTRUE\t" + future + "\tsecure_http_only\t3",
+		"example.com\tFALSE\t/\tFALSE\t" + past + "\texpired\t4",
+		"other.example\tFALSE\t/\tFALSE\t0\tother\t5",
+	}, "\n"))
+
+	jar, err := loadCookieFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		url  string
+		want []string
+	}{
+		{"http://example.com/", []string{"host_only=2", "shared=1"}},
+		{"https://example.com/account/settings", []string{"host_only=2", "secure_http_only=3", "shared=1"}},
+		{"http://example.com/account/settings", []string{"host_only=2", "shared=1"}},
+		{"http://www.example.com/", []string{"shared=1"}},
+		{"http://other.example/", []string{"other=5"}},
+		{"http://unrelated.example/", nil},
+	}
+	for _, tt := range tests {
+		u, _ := url.Parse(tt.url)
+		var got []string
+		for _, cookie := range jar.Cookies(u) {
+			got = append(got, cookie.Name+"="+cookie.Value)
+		}
+		slices.Sort(got)
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("cookies for %s = %v, want %v", tt.url, got, tt.want)
+		}
+	}
+}
+
+func TestProfileSelection(t *testing.T) {
+	t.Cleanup(func() { Configure(nil) })
+	if err := Configure(map[string]models.HTTPProfile{
+		"wiki": {UserAgent: "feedlet-test/1.0", Headers: map[string]string{"X-Profile": "wiki"}},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.Header.Get("User-Agent") + "|" + r.Header.Get("X-Profile") + "|" + r.Header.Get("X-Source")))
+	}))
+	defer srv.Close()
+	client := &http.Client{Transport: dispatchTransport{}}
+
+	tests := []struct {
+		name string
+		ctx  context.Context
+		want string
+	}{
+		{"no profile", context.Background(), "browser||"},
+		{"named profile", WithProfile(context.Background(), "wiki"), "feedlet-test/1.0|wiki|"},
+		{"unknown profile", WithProfile(context.Background(), "missing"), "browser||"},
+		{"source headers over the profile", WithHeaders(WithProfile(context.Background(), "wiki"), http.Header{"X-Profile": {"source"}, "X-Source": {"1"}}), "feedlet-test/1.0|source|1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequestWithContext(tt.ctx, http.MethodGet, srv.URL, nil)
+			req.Header.Set("User-Agent", "browser")
+			resp, err := client.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer resp.Body.Close()
+			body, err := io.ReadAll(resp.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(body) != tt.want {
+				t.Errorf("server saw %q, want %q", body, tt.want)
+			}
+		})
+	}
+}
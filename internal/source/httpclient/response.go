@@ -0,0 +1,251 @@
+package httpclient
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// sniffLen is how much of a response is inspected for challenge markers and
+// content sniffing, and how much of an error response is read at all.
+const sniffLen = 64 << 10
+
+// Expect is the kind of body a caller expects from a response.
+type Expect int
+
+const (
+	ExpectAny  Expect = iota
+	ExpectFeed        // RSS, Atom or JSON Feed
+	ExpectJSON
+	ExpectHTML
+)
+
+func (e Expect) String() string {
+	switch e {
+	case ExpectFeed:
+		return "feed"
+	case ExpectJSON:
+		return "JSON"
+	case ExpectHTML:
+		return "HTML"
+	default:
+		return "any"
+	}
+}
+
+// StatusError is returned for responses with a non-2xx status.
+type StatusError struct {
+	StatusCode int
+	Status     string
+	RetryAfter time.Duration // From the Retry-After header, if any
+}
+
+func (e *StatusError) Error() string {
+	if e.Status != "" {
+		return "http " + e.Status
+	}
+	return fmt.Sprintf("http %d", e.StatusCode)
+}
+
+// ChallengeError is returned when a server answers with a captcha or
+// anti-bot challenge page (Cloudflare, DDoS-Guard, ...) instead of content.
+type ChallengeError struct {
+	Provider   string
+	StatusCode int
+}
+
+func (e *ChallengeError) Error() string {
+	return fmt.Sprintf("blocked by %s challenge (http %d)", e.Provider, e.StatusCode)
+}
+
+// ContentTypeError is returned when a response is not of the expected kind,
+// typically an HTML error page where a feed or JSON was expected.
+type ContentTypeError struct {
+	Want        Expect
+	ContentType string
+}
+
+func (e *ContentTypeError) Error() string {
+	return fmt.Sprintf("unexpected content type %q, want %s", e.ContentType, e.Want)
+}
+
+// ReadBody checks the status of resp, reads its body within the profile's
+// size limit and verifies that it is of the expected kind. Error responses
+// and challenge pages are reported as *StatusError and *ChallengeError.
+// It does not close the body.
+func ReadBody(resp *http.Response, want Expect) ([]byte, error) {
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		sniff, _ := io.ReadAll(io.LimitReader(resp.Body, sniffLen))
+		if provider := detectChallenge(resp, sniff, want); provider != "" {
+			return nil, &ChallengeError{Provider: provider, StatusCode: resp.StatusCode}
+		}
+		return nil, &StatusError{
+			StatusCode: resp.StatusCode,
+			Status:     resp.Status,
+			RetryAfter: retryAfter(resp.Header.Get("Retry-After")),
+		}
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		if errors.Is(err, ErrBodyTooLarge) && resp.Request != nil {
+			return nil, fmt.Errorf("%w (limit %d bytes)", ErrBodyTooLarge, profileFor(resp.Request.Context()).maxBody)
+		}
+		return nil, err
+	}
+
+	if provider := detectChallenge(resp, body, want); provider != "" {
+		return nil, &ChallengeError{Provider: provider, StatusCode: resp.StatusCode}
+	}
+	if err := checkContentType(resp.Header.Get("Content-Type"), body, want); err != nil {
+		return nil, err
+	}
+	return body, nil
+}
+
+// retryAfter parses a Retry-After header given in seconds or as a date.
+func retryAfter(value string) time.Duration {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		if d := time.Until(at); d > 0 {
+			return d
+		}
+	}
+	return 0
+}
+
+// challengeMarkers are lowercase snippets of well-known interstitial pages.
+var challengeMarkers = []struct {
+	provider string
+	marker   string
+}{
+	{"Cloudflare", "<title>just a moment...</title>"},
+	{"Cloudflare", "/cdn-cgi/challenge-platform/"},
+	{"Cloudflare", "cf-browser-verification"},
+	{"Cloudflare", "attention required! | cloudflare"},
+	{"DDoS-Guard", "<title>ddos-guard</title>"},
+	{"Sucuri", "sucuri website firewall"},
+	{"Reddit", "whoa there, pardner!"},
+	{"Reddit", "blocked by network security"},
+}
+
+// captchaMarkers only count on error responses or where HTML was not
+// expected: a regular page may embed a captcha in e.g. a login form.
+var captchaMarkers = []string{"g-recaptcha", "h-captcha", "hcaptcha.com", "cf-turnstile"}
+
+func detectChallenge(resp *http.Response, body []byte, want Expect) string {
+	if resp.Header.Get("Cf-Mitigated") == "challenge" {
+		return "Cloudflare"
+	}
+	if looksFeed(body) {
+		return ""
+	}
+	if !isHTMLType(resp.Header.Get("Content-Type")) && !looksHTML(body) {
+		return ""
+	}
+
+	if len(body) > sniffLen {
+		body = body[:sniffLen]
+	}
+	page := strings.ToLower(string(body))
+	for _, m := range challengeMarkers {
+		if strings.Contains(page, m.marker) {
+			return m.provider
+		}
+	}
+
+	failed := resp.StatusCode >= 400
+	if failed && strings.EqualFold(resp.Header.Get("Server"), "ddos-guard") {
+		return "DDoS-Guard"
+	}
+	if failed || want != ExpectHTML {
+		for _, marker := range captchaMarkers {
+			if strings.Contains(page, marker) {
+				return "captcha"
+			}
+		}
+	}
+	return ""
+}
+
+func checkContentType(contentType string, body []byte, want Expect) error {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	mediaType = strings.ToLower(mediaType)
+
+	var ok bool
+	switch want {
+	case ExpectFeed:
+		// Feeds are served with all sorts of types; only reject what is
+		// clearly something else and does not look like a feed anyway.
+		ok = looksFeed(body) || !(isHTMLType(mediaType) || isBinaryType(mediaType))
+	case ExpectJSON:
+		ok = looksJSON(body) || mediaType == "" || strings.Contains(mediaType, "json") || strings.Contains(mediaType, "javascript")
+	case ExpectHTML:
+		ok = mediaType == "" || strings.HasPrefix(mediaType, "text/") || strings.Contains(mediaType, "html") || strings.Contains(mediaType, "xml")
+	default:
+		ok = true
+	}
+	if ok {
+		return nil
+	}
+	return &ContentTypeError{Want: want, ContentType: contentType}
+}
+
+func isHTMLType(contentType string) bool {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	return mediaType == "text/html" || mediaType == "application/xhtml+xml"
+}
+
+func isBinaryType(mediaType string) bool {
+	for _, prefix := range []string{"image/", "audio/", "video/", "font/"} {
+		if strings.HasPrefix(mediaType, prefix) {
+			return true
+		}
+	}
+	return mediaType == "application/pdf" || mediaType == "application/zip" || mediaType == "application/gzip"
+}
+
+// bodyStart returns the beginning of body without a BOM or leading space.
+func bodyStart(body []byte) []byte {
+	body = bytes.TrimPrefix(body, []byte("\xef\xbb\xbf"))
+	body = bytes.TrimLeft(body, " \t\r\n")
+	if len(body) > 1024 {
+		body = body[:1024]
+	}
+	return body
+}
+
+func looksHTML(body []byte) bool {
+	start := bytes.ToLower(bodyStart(body))
+	return bytes.HasPrefix(start, []byte("<!doctype html")) || bytes.HasPrefix(start, []byte("<html"))
+}
+
+func looksJSON(body []byte) bool {
+	start := bodyStart(body)
+	return len(start) > 0 && (start[0] == '{' || start[0] == '[')
+}
+
+func looksFeed(body []byte) bool {
+	start := bodyStart(body)
+	if looksJSON(start) {
+		return true
+	}
+	for _, root := range []string{"<rss", "<feed", "<rdf:rdf"} {
+		if bytes.Contains(bytes.ToLower(start), []byte(root)) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,204 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ppowo/feedlet/internal/models"
+)
+
+// response describes what the test server answers with.
+type response struct {
+	status int
+	header map[string]string
+	body   string
+}
+
+func TestReadBody(t *testing.T) {
+	t.Cleanup(func() { Configure(nil) })
+	if err := Configure(map[string]models.HTTPProfile{"small": {MaxBodyBytes: 64}}); err != nil {
+		t.Fatal(err)
+	}
+
+	const (
+		feed       = `<?xml version="1.0"?><rss version="2.0"><channel><title>t</title></channel></rss>`
+		errorPage  = `<!DOCTYPE html><html><head><title>Error</title></head><body>Something went wrong</body></html>`
+		cloudflare = `<!DOCTYPE html><html><head><title>Just a moment...</title></head><body><script src="/cdn-cgi/challenge-platform/h/b/orchestrate/jsch/v1"></script></body></html>`
+		captcha    = `<!DOCTYPE html><html><body><form><div class="g-recaptcha" data-sitekey="x"></div></form></body></html>`
+	)
+	html := map[string]string{"Content-Type": "text/html; charset=utf-8"}
+
+	tests := []struct {
+		name    string
+		profile string
+		resp    response
+		want    Expect
+		check   func(t *testing.T, body []byte, err error)
+	}{
+		{
+			name: "feed",
+			resp: response{200, map[string]string{"Content-Type": "application/rss+xml"}, feed},
+			want: ExpectFeed,
+			check: func(t *testing.T, body []byte, err error) {
+				if err != nil || string(body) != feed {
+					t.Errorf("got %q, %v", body, err)
+				}
+			},
+		},
+		{
+			name: "feed served as text/html",
+			resp: response{200, html, feed},
+			want: ExpectFeed,
+			check: func(t *testing.T, body []byte, err error) {
+				if err != nil {
+					t.Errorf("feed rejected for its content type: %v", err)
+				}
+			},
+		},
+		{
+			name:    "oversized body",
+			profile: "small",
+			resp:    response{200, map[string]string{"Content-Type": "application/json"}, `{"items":"` + strings.Repeat("x", 100) + `"}`},
+			want:    ExpectJSON,
+			check: func(t *testing.T, body []byte, err error) {
+				if !errors.Is(err, ErrBodyTooLarge) || !strings.Contains(err.Error(), "limit 64 bytes") {
+					t.Errorf("err = %v, want ErrBodyTooLarge naming the limit", err)
+				}
+			},
+		},
+		{
+			name: "HTML page where JSON is expected",
+			resp: response{200, html, errorPage},
+			want: ExpectJSON,
+			check: func(t *testing.T, body []byte, err error) {
+				var typeErr *ContentTypeError
+				if !errors.As(err, &typeErr) || typeErr.Want != ExpectJSON || typeErr.ContentType != html["Content-Type"] {
+					t.Errorf("err = %v, want a ContentTypeError for JSON", err)
+				}
+			},
+		},
+		{
+			name: "image where a feed is expected",
+			resp: response{200, map[string]string{"Content-Type": "image/png"}, "\x89PNG\r\n"},
+			want: ExpectFeed,
+			check: func(t *testing.T, body []byte, err error) {
+				var typeErr *ContentTypeError
+				if !errors.As(err, &typeErr) {
+					t.Errorf("err = %v, want a ContentTypeError", err)
+				}
+			},
+		},
+		{
+			name: "Cloudflare challenge",
+			resp: response{403, html, cloudflare},
+			want: ExpectFeed,
+			check: func(t *testing.T, body []byte, err error) {
+				var challengeErr *ChallengeError
+				if !errors.As(err, &challengeErr) || challengeErr.Provider != "Cloudflare" || challengeErr.StatusCode != 403 {
+					t.Errorf("err = %v, want a Cloudflare ChallengeError", err)
+				}
+			},
+		},
+		{
+			name: "Cloudflare mitigation header",
+			resp: response{200, map[string]string{"Cf-Mitigated": "challenge"}, ""},
+			want: ExpectHTML,
+			check: func(t *testing.T, body []byte, err error) {
+				var challengeErr *ChallengeError
+				if !errors.As(err, &challengeErr) || challengeErr.Provider != "Cloudflare" {
+					t.Errorf("err = %v, want a Cloudflare ChallengeError", err)
+				}
+			},
+		},
+		{
+			name: "captcha where JSON is expected",
+			resp: response{200, html, captcha},
+			want: ExpectJSON,
+			check: func(t *testing.T, body []byte, err error) {
+				var challengeErr *ChallengeError
+				if !errors.As(err, &challengeErr) || challengeErr.Provider != "captcha" {
+					t.Errorf("err = %v, want a captcha ChallengeError", err)
+				}
+			},
+		},
+		{
+			name: "captcha on an expected HTML page",
+			resp: response{200, html, captcha},
+			want: ExpectHTML,
+			check: func(t *testing.T, body []byte, err error) {
+				if err != nil {
+					t.Errorf("page with a login captcha rejected: %v", err)
+				}
+			},
+		},
+		{
+			name: "server error",
+			resp: response{503, html, errorPage},
+			want: ExpectHTML,
+			check: func(t *testing.T, body []byte, err error) {
+				var statusErr *StatusError
+				if !errors.As(err, &statusErr) || statusErr.StatusCode != 503 || statusErr.Status != "503 Service Unavailable" {
+					t.Errorf("err = %v, want a 503 StatusError", err)
+				}
+			},
+		},
+		{
+			name: "rate limited",
+			resp: response{429, map[string]string{"Retry-After": "120"}, ""},
+			want: ExpectJSON,
+			check: func(t *testing.T, body []byte, err error) {
+				var statusErr *StatusError
+				if !errors.As(err, &statusErr) || statusErr.StatusCode != 429 || statusErr.RetryAfter != 2*time.Minute {
+					t.Errorf("err = %+v, want a 429 StatusError retrying after 2m", err)
+				}
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				for key, value := range tt.resp.header {
+					w.Header().Set(key, value)
+				}
+				w.WriteHeader(tt.resp.status)
+				w.Write([]byte(tt.resp.body))
+			}))
+			defer srv.Close()
+
+			req, _ := http.NewRequestWithContext(WithProfile(context.Background(), tt.profile), http.MethodGet, srv.URL, nil)
+			resp, err := (&http.Client{Transport: dispatchTransport{}}).Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer resp.Body.Close()
+
+			body, err := ReadBody(resp, tt.want)
+			tt.check(t, body, err)
+		})
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	tests := []struct {
+		value string
+		min   time.Duration
+		max   time.Duration
+	}{
+		{"", 0, 0},
+		{"30", 30 * time.Second, 30 * time.Second},
+		{"-5", 0, 0},
+		{"soon", 0, 0},
+		{time.Now().Add(time.Hour).UTC().Format(http.TimeFormat), 59 * time.Minute, time.Hour},
+		{time.Now().Add(-time.Hour).UTC().Format(http.TimeFormat), 0, 0},
+	}
+	for _, tt := range tests {
+		if got := retryAfter(tt.value); got < tt.min || got > tt.max {
+			t.Errorf("retryAfter(%q) = %s, want between %s and %s", tt.value, got, tt.min, tt.max)
+		}
+	}
+}
@@ -0,0 +1,440 @@
+package source
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	neturl "net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mmcdole/gofeed"
+	"github.com/ppowo/feedlet/internal/models"
+	"github.com/ppowo/feedlet/internal/source/fetcherr"
+)
+
+const defaultExecTimeout = 20 * time.Second
+
+// Formats understood by the exec and file sources.
+const (
+	LocalFormatFeed  = "feed" // RSS, Atom or JSON Feed
+	LocalFormatJSONL = "jsonl"
+)
+
+// localFeedExtensions are the files a directory-backed FileSource reads.
+var localFeedExtensions = []string{".xml", ".rss", ".atom", ".json", ".jsonl"}
+
+// localLineItem is one line of the simple JSON-lines item format:
+//
+//	{"title": "...", "link": "...", "published": "2006-01-02T15:04:05Z", ...}
+type localLineItem struct {
+	Title       string    `json:"title"`
+	Link        string    `json:"link"`
+	URL         string    `json:"url"`
+	Description string    `json:"description"`
+	Content     string    `json:"content"`
+	Author      string    `json:"author"`
+	Published   time.Time `json:"published"`
+	Tags        []string  `json:"tags"`
+}
+
+// parseLocalFormat checks a format setting, returning fallback if it is unset.
+func parseLocalFormat(value, fallback string) (string, error) {
+	switch value = strings.ToLower(strings.TrimSpace(value)); value {
+	case "":
+		return fallback, nil
+	case LocalFormatFeed, LocalFormatJSONL:
+		return value, nil
+	default:
+		return "", fmt.Errorf("unsupported format %q (want %s or %s)", value, LocalFormatFeed, LocalFormatJSONL)
+	}
+}
+
+// parseLocalItems parses RSS/Atom/JSON Feed or JSON-lines data. JSON lines
+// without a date are returned with a zero Published for firstSeenDates.
+func parseLocalItems(data []byte, format, sourceName, sourceType string) ([]models.Item, error) {
+	trimmed := bytes.TrimSpace(data)
+	if len(trimmed) == 0 {
+		return []models.Item{}, nil
+	}
+
+	switch format {
+	case LocalFormatFeed:
+		feed, err := gofeed.NewParser().Parse(bytes.NewReader(trimmed))
+		if err != nil {
+			return nil, fetcherr.New(fetcherr.Parse, "failed to parse feed: %w", err)
+		}
+		return feedItems(feed, sourceName, sourceType, false), nil
+	case LocalFormatJSONL:
+		items := make([]models.Item, 0, 16)
+		scanner := bufio.NewScanner(bytes.NewReader(trimmed))
+		scanner.Buffer(make([]byte, 0, 64*1024), 4*1024*1024)
+		for lineNo := 1; scanner.Scan(); lineNo++ {
+			line := bytes.TrimSpace(scanner.Bytes())
+			if len(line) == 0 {
+				continue
+			}
+
+			var entry localLineItem
+			if err := json.Unmarshal(line, &entry); err != nil {
+				return nil, fetcherr.New(fetcherr.Parse, "failed to parse line %d: %w", lineNo, err)
+			}
+			if entry.Title == "" {
+				continue
+			}
+
+			link := entry.Link
+			if link == "" {
+				link = entry.URL
+			}
+			content := entry.Content
+			if content == "" {
+				content = entry.Description
+			}
+
+			items = append(items, models.Item{
+				Title:       entry.Title,
+				Link:        link,
+				Description: entry.Description,
+				Content:     content,
+				Author:      entry.Author,
+				Published:   entry.Published,
+				SourceName:  sourceName,
+				SourceType:  sourceType,
+				Tags:        entry.Tags,
+			})
+		}
+		if err := scanner.Err(); err != nil {
+			return nil, fmt.Errorf("failed to read items: %w", err)
+		}
+		return items, nil
+	default:
+		return nil, fmt.Errorf("unsupported format %q", format)
+	}
+}
+
+// firstSeenDates dates undated items by when they were first listed, so
+// they keep their place across fetches instead of moving to the top on each
+// one. Items that stop being listed are forgotten.
+type firstSeenDates struct {
+	mu   sync.Mutex
+	seen map[string]time.Time
+}
+
+// stamp sets the date of every undated item in items to its first-seen time,
+// using now for items not seen before. Items are keyed by link, or by title
+// when they have none.
+func (d *firstSeenDates) stamp(items []models.Item, now time.Time) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	seen := make(map[string]time.Time, len(d.seen))
+	for i := range items {
+		if !items[i].Published.IsZero() {
+			continue
+		}
+		key := items[i].Link
+		if key == "" {
+			key = "title:" + items[i].Title
+		}
+		first, ok := seen[key]
+		if !ok {
+			if first, ok = d.seen[key]; !ok {
+				first = now
+			}
+			seen[key] = first
+		}
+		items[i].Published = first
+	}
+	d.seen = seen
+}
+
+// ExecSource runs a local command and parses the items it prints.
+//
+// The URL has the form exec:///path/to/command?arg=--json&arg=x&timeout=10s&format=jsonl.
+// Each arg parameter is passed as one argument, without a shell. format is
+// feed (RSS/Atom/JSON Feed, the default) or jsonl. JSON lines without a date
+// are dated when they are first printed.
+type ExecSource struct {
+	name    string
+	command string
+	args    []string
+	timeout time.Duration
+	format  string
+	dates   firstSeenDates
+}
+
+func init() {
+	Register(TypeInfo{
+		Type:        "exec",
+		Description: "Items printed by a local command",
+		Factory: func(cfg models.SourceConfig) (Source, error) {
+			return NewExecSource(cfg.Name, cfg.URL, cfg.Options)
+		},
+		Params: []Param{
+			{Name: "url", Required: true, Description: "exec:///path/to/command"},
+			{Name: "arg", Kind: models.OptionList, Description: "Command argument, repeatable"},
+			{Name: "timeout", Kind: models.OptionDuration, Default: "20s", Description: "Maximum run time, at most 30s"},
+			{Name: "format", Default: LocalFormatFeed, Description: "feed (RSS, Atom or JSON Feed) or jsonl"},
+		},
+	})
+	Register(TypeInfo{
+		Type:        "file",
+		Description: "Local feed or JSON-lines file, or a directory of them",
+		Factory: func(cfg models.SourceConfig) (Source, error) {
+			return NewFileSource(cfg.Name, cfg.URL, cfg.Options)
+		},
+		Params: []Param{
+			{Name: "url", Required: true, Description: "file:///path/to/feed.xml or file:///path/to/dir/"},
+			{Name: "format", Description: "feed or jsonl; by default .jsonl files are JSON lines and others feeds"},
+		},
+		Policy: Policy{Interval: 5 * time.Minute},
+	})
+}
+
+// NewExecSource creates a new command-backed source.
+func NewExecSource(name, rawURL string, options models.Options) (*ExecSource, error) {
+	parsed, err := neturl.Parse(strings.TrimSpace(rawURL))
+	if err != nil {
+		return nil, fmt.Errorf("invalid exec URL: %w", err)
+	}
+
+	command := parsed.Path
+	if command == "" {
+		command = parsed.Opaque
+	}
+	if command == "" {
+		return nil, fmt.Errorf("exec URL %q does not name a command", rawURL)
+	}
+
+	q := newSettings(options, parsed.Query())
+	timeout := defaultExecTimeout
+	if value := strings.TrimSpace(q.Get("timeout")); value != "" {
+		if timeout, err = time.ParseDuration(value); err != nil {
+			return nil, fmt.Errorf("invalid exec timeout %q: %w", value, err)
+		}
+		// The command runs within the fetch, so it cannot outlast it
+		if timeout <= 0 || timeout > FetchTimeout {
+			return nil, fmt.Errorf("exec timeout %s is not between 0 and %s", timeout, FetchTimeout)
+		}
+	}
+	format, err := parseLocalFormat(q.Get("format"), LocalFormatFeed)
+	if err != nil {
+		return nil, fmt.Errorf("exec source %s: %w", name, err)
+	}
+
+	return &ExecSource{
+		name:    name,
+		command: command,
+		args:    q.Values("arg"),
+		timeout: timeout,
+		format:  format,
+	}, nil
+}
+
+// Fetch runs the command and parses its standard output.
+func (e *ExecSource) Fetch(ctx context.Context) ([]models.Item, error) {
+	runCtx, cancel := context.WithTimeout(ctx, e.timeout)
+	defer cancel()
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(runCtx, e.command, e.args...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	// Children of a killed command may hold its output open; stop waiting for them
+	cmd.WaitDelay = time.Second
+
+	startedAt := time.Now()
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() != nil {
+			return nil, fmt.Errorf("exec: %s stopped with the fetch: %w", e.command, ctx.Err())
+		}
+		if errors.Is(runCtx.Err(), context.DeadlineExceeded) {
+			return nil, fetcherr.New(fetcherr.Timeout, "exec: %s timed out after %s: %w", e.command, e.timeout, runCtx.Err())
+		}
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fetcherr.New(fetcherr.Command, "exec: %s failed: %w: %s", e.command, err, truncateText(msg, 200))
+		}
+		return nil, fetcherr.New(fetcherr.Command, "exec: %s failed: %w", e.command, err)
+	}
+
+	items, err := parseLocalItems(stdout.Bytes(), e.format, e.name, "exec")
+	if err != nil {
+		return nil, fmt.Errorf("exec: %s: %w", e.command, err)
+	}
+	e.dates.stamp(items, startedAt)
+	return items, nil
+}
+
+// Name returns the source name.
+func (e *ExecSource) Name() string {
+	return e.name
+}
+
+// Type returns the source type.
+func (e *ExecSource) Type() string {
+	return "exec"
+}
+
+// FileSource reads items from a local RSS/Atom/JSON Feed or JSON-lines file,
+// or from every such file in a directory. The path is polled on the source's
+// interval rather than watched, and files are only re-parsed when their size
+// or modification time changes. JSON lines without a date are dated when
+// they are first read.
+//
+// The URL has the form file:///path/to/feed.xml or file:///path/to/dir/.
+// format=feed|jsonl sets the format of every file; without it .jsonl files
+// are read as JSON lines and all others as feeds.
+type FileSource struct {
+	name   string
+	path   string
+	format string
+	dates  firstSeenDates
+
+	mu    sync.Mutex
+	cache map[string]fileCacheEntry
+}
+
+type fileCacheEntry struct {
+	modTime time.Time
+	size    int64
+	items   []models.Item
+}
+
+// NewFileSource creates a new file-backed source.
+func NewFileSource(name, rawURL string, options models.Options) (*FileSource, error) {
+	parsed, err := neturl.Parse(strings.TrimSpace(rawURL))
+	if err != nil {
+		return nil, fmt.Errorf("invalid file URL: %w", err)
+	}
+	path := parsed.Path
+	if path == "" {
+		path = parsed.Opaque
+	}
+	if path == "" {
+		return nil, fmt.Errorf("file URL %q does not name a path", rawURL)
+	}
+
+	format, err := parseLocalFormat(newSettings(options, parsed.Query()).Get("format"), "")
+	if err != nil {
+		return nil, fmt.Errorf("file source %s: %w", name, err)
+	}
+
+	return &FileSource{
+		name:   name,
+		path:   filepath.Clean(path),
+		format: format,
+		cache:  make(map[string]fileCacheEntry),
+	}, nil
+}
+
+// Fetch returns the items of the file, or of all feed files in the directory.
+func (f *FileSource) Fetch(ctx context.Context) ([]models.Item, error) {
+	info, err := os.Stat(f.path)
+	if err != nil {
+		return nil, fmt.Errorf("file: %w", err)
+	}
+
+	paths := []string{f.path}
+	if info.IsDir() {
+		entries, err := os.ReadDir(f.path)
+		if err != nil {
+			return nil, fmt.Errorf("file: %w", err)
+		}
+		paths = paths[:0]
+		for _, entry := range entries {
+			if entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+				continue
+			}
+			if slices.Contains(localFeedExtensions, strings.ToLower(filepath.Ext(entry.Name()))) {
+				paths = append(paths, filepath.Join(f.path, entry.Name()))
+			}
+		}
+	}
+
+	now := time.Now()
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	items := make([]models.Item, 0, 32)
+	seen := make(map[string]bool, len(paths))
+	for _, path := range paths {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		fileItems, err := f.readFile(path)
+		if err != nil {
+			return nil, err
+		}
+		seen[path] = true
+		items = append(items, fileItems...)
+	}
+
+	for path := range f.cache {
+		if !seen[path] {
+			delete(f.cache, path)
+		}
+	}
+
+	f.dates.stamp(items, now)
+	sort.SliceStable(items, func(i, j int) bool {
+		return items[i].Published.After(items[j].Published)
+	})
+	return items, nil
+}
+
+func (f *FileSource) readFile(path string) ([]models.Item, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("file: %w", err)
+	}
+
+	if entry, ok := f.cache[path]; ok && entry.modTime.Equal(info.ModTime()) && entry.size == info.Size() {
+		return entry.items, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("file: %w", err)
+	}
+
+	format := f.format
+	if format == "" {
+		format = LocalFormatFeed
+		if strings.EqualFold(filepath.Ext(path), ".jsonl") {
+			format = LocalFormatJSONL
+		}
+	}
+
+	items, err := parseLocalItems(data, format, f.name, "file")
+	if err != nil {
+		return nil, fmt.Errorf("file: %s: %w", path, err)
+	}
+
+	f.cache[path] = fileCacheEntry{
+		modTime: info.ModTime(),
+		size:    info.Size(),
+		items:   items,
+	}
+	return items, nil
+}
+
+// Name returns the source name.
+func (f *FileSource) Name() string {
+	return f.name
+}
+
+// Type returns the source type.
+func (f *FileSource) Type() string {
+	return "file"
+}
@@ -0,0 +1,179 @@
+package source
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ppowo/feedlet/internal/models"
+	"github.com/ppowo/feedlet/internal/source/fetcherr"
+)
+
+func TestFileSourceUndatedLinesKeepFirstSeenDate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "status.jsonl")
+	if err := os.WriteFile(path, []byte(`{"title":"Build 1","link":"https://ci.example/1"}`+"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	src, err := NewFileSource("status", "file://"+path, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	first, err := src.Fetch(context.Background())
+	if err != nil {
+		t.Fatalf("Fetch: %v", err)
+	}
+	if len(first) != 1 || first[0].Published.IsZero() {
+		t.Fatalf("first fetch = %+v, want one dated item", first)
+	}
+
+	time.Sleep(10 * time.Millisecond)
+	data := `{"title":"Build 1","link":"https://ci.example/1"}` + "\n" +
+		`{"title":"Build 2","link":"https://ci.example/2","published":"2025-01-02T15:04:05Z"}` + "\n"
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	// Make sure the change is seen even on coarse mtime filesystems
+	if err := os.Chtimes(path, time.Now(), time.Now().Add(time.Second)); err != nil {
+		t.Fatal(err)
+	}
+
+	second, err := src.Fetch(context.Background())
+	if err != nil {
+		t.Fatalf("Fetch: %v", err)
+	}
+	if len(second) != 2 {
+		t.Fatalf("second fetch = %+v, want two items", second)
+	}
+	for _, item := range second {
+		if item.Title == "Build 1" && !item.Published.Equal(first[0].Published) {
+			t.Errorf("Build 1 moved from %s to %s", first[0].Published, item.Published)
+		}
+	}
+}
+
+func TestFileSourceFormat(t *testing.T) {
+	dir := t.TempDir()
+	// A JSON Feed in a .json file is read as a feed without any sniffing
+	feed := `{"version":"https://jsonfeed.org/version/1.1","title":"x","items":[{"id":"1","title":"Feed item","url":"https://example.com/1","date_published":"2025-01-02T15:04:05Z"}]}`
+	if err := os.WriteFile(filepath.Join(dir, "feed.json"), []byte(feed), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "lines.jsonl"), []byte(`{"title":"Line item","published":"2025-01-01T00:00:00Z"}`+"\n"+`{"title":"Undated"}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	src, err := NewFileSource("dir", "file://"+dir, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	items, err := src.Fetch(context.Background())
+	if err != nil {
+		t.Fatalf("Fetch: %v", err)
+	}
+	if len(items) != 3 || items[1].Title != "Feed item" || items[2].Title != "Line item" {
+		t.Fatalf("items = %+v, want the undated line, the feed item and the dated line", items)
+	}
+
+	// An explicit format applies to every file
+	src, err = NewFileSource("dir", "file://"+dir, models.Options{"format": "feed"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := src.Fetch(context.Background()); fetcherr.Classify(err).Class != fetcherr.Parse {
+		t.Errorf("JSON lines read as a feed: err = %v, want a parse error", err)
+	}
+
+	if _, err := NewFileSource("dir", "file://"+dir+"?format=auto", nil); err == nil {
+		t.Error("format=auto was accepted")
+	}
+}
+
+// shellSource returns an exec source running script with sh.
+func shellSource(t *testing.T, script string, options models.Options) *ExecSource {
+	t.Helper()
+	sh, err := exec.LookPath("sh")
+	if err != nil {
+		t.Skip("no sh in PATH")
+	}
+	if options == nil {
+		options = models.Options{}
+	}
+	options["arg"] = []string{"-c", script}
+	src, err := NewExecSource("cmd", "exec://"+sh, options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return src
+}
+
+func TestExecSourceErrorsAreClassified(t *testing.T) {
+	missing, err := NewExecSource("cmd", "exec:///nonexistent/feedlet-command", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		src   *ExecSource
+		class fetcherr.Class
+	}{
+		{"exit status", shellSource(t, "echo broken >&2; exit 3", nil), fetcherr.Command},
+		{"missing command", missing, fetcherr.Command},
+		{"timeout", shellSource(t, "sleep 5", models.Options{"timeout": "50ms"}), fetcherr.Timeout},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.src.Fetch(context.Background())
+			if got := fetcherr.Classify(err).Class; got != tt.class {
+				t.Errorf("err = %v classified as %q, want %q", err, got, tt.class)
+			}
+		})
+	}
+}
+
+func TestExecSourceTimeoutBounds(t *testing.T) {
+	for _, timeout := range []string{"0s", "-1s", "31s"} {
+		if _, err := NewExecSource("cmd", "exec:///bin/true", models.Options{"timeout": timeout}); err == nil {
+			t.Errorf("timeout %s accepted", timeout)
+		}
+	}
+	if _, err := NewExecSource("cmd", "exec:///bin/true", models.Options{"timeout": "30s"}); err != nil {
+		t.Errorf("timeout 30s: %v", err)
+	}
+}
+
+func TestExecSourceReportsFetchDeadline(t *testing.T) {
+	src := shellSource(t, "sleep 5", models.Options{"timeout": "10s"})
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	_, err := src.Fetch(ctx)
+	if !errors.Is(err, context.DeadlineExceeded) || strings.Contains(err.Error(), "10s") {
+		t.Errorf("err = %v, want the fetch deadline rather than the command timeout", err)
+	}
+	if got := fetcherr.Classify(err).Class; got != fetcherr.Timeout {
+		t.Errorf("classified as %q, want %q", got, fetcherr.Timeout)
+	}
+}
+
+func TestExecSourceUndatedLinesKeepFirstSeenDate(t *testing.T) {
+	src := shellSource(t, `echo '{"title":"Nightly","link":"https://ci.example/n"}'`, models.Options{"format": "jsonl"})
+
+	first, err := src.Fetch(context.Background())
+	if err != nil {
+		t.Fatalf("Fetch: %v", err)
+	}
+	second, err := src.Fetch(context.Background())
+	if err != nil {
+		t.Fatalf("Fetch: %v", err)
+	}
+	if len(first) != 1 || len(second) != 1 || !first[0].Published.Equal(second[0].Published) {
+		t.Fatalf("undated line not stable across runs: %+v then %+v", first, second)
+	}
+}
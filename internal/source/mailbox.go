@@ -0,0 +1,494 @@
+package source
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"fmt"
+	"html"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	neturl "net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/emersion/go-imap"
+	imapclient "github.com/emersion/go-imap/client"
+	"github.com/ppowo/feedlet/internal/models"
+	"golang.org/x/net/html/charset"
+)
+
+const (
+	defaultMailboxLimit  = 20
+	maxMailboxLimit      = 200
+	mailboxExcerptLength = 300
+	mailboxDialTimeout   = 15 * time.Second
+)
+
+var mailboxBrowserLinkRe = regexp.MustCompile(`(?i)(view|read|open|see)\b.{0,30}\b(browser|online|web)|web version`)
+
+var mailboxWordDecoder = &mime.WordDecoder{CharsetReader: charset.NewReaderLabel}
+
+// MailboxSource turns newsletter emails into items. It reads either a local
+// Maildir or an IMAP folder and marks the messages it has read as seen.
+//
+// URL forms:
+//
+//	maildir:///home/me/Maildir/.Newsletters
+//	imaps://user@imap.example.com/Newsletters?password_env=IMAP_PASSWORD
+//	imap://user@localhost:1143/INBOX  (plaintext, for local stand-in servers)
+//
+// Query parameters: limit (newest messages to list, default 20), mark_seen=false
+// to leave messages untouched and, for IMAP, password_env naming the
+// environment variable that holds the password.
+type MailboxSource struct {
+	name     string
+	scheme   string
+	path     string // Maildir directory or IMAP folder
+	addr     string // IMAP host:port
+	username string
+	password string
+	limit    int
+	markSeen bool
+
+	mu    sync.Mutex
+	cache map[string]models.Item // parsed messages by Maildir unique name or IMAP UID
+}
+
+func init() {
+	Register(TypeInfo{
+		Type:        "mailbox",
+		Description: "Newsletters from a Maildir or IMAP folder",
+		Factory: func(cfg models.SourceConfig) (Source, error) {
+			return NewMailboxSource(cfg.Name, cfg.URL, cfg.Options)
+		},
+		Params: []Param{
+			{Name: "url", Required: true, Description: "maildir:///path, imap://user@host/Folder or imaps://user@host/Folder"},
+			{Name: "mark_seen", Kind: models.OptionBool, Default: "true", Description: "Mark listed messages as seen; false leaves them unread"},
+			{Name: "password_env", Description: "Environment variable holding the IMAP password"},
+		},
+		Policy: Policy{Interval: 15 * time.Minute},
+	})
+}
+
+// NewMailboxSource creates a new Maildir or IMAP source.
+func NewMailboxSource(name, rawURL string, options models.Options) (*MailboxSource, error) {
+	parsed, err := neturl.Parse(strings.TrimSpace(rawURL))
+	if err != nil {
+		return nil, fmt.Errorf("invalid mailbox URL: %w", err)
+	}
+
+	q := newSettings(options, parsed.Query())
+	m := &MailboxSource{
+		name:     name,
+		scheme:   strings.ToLower(parsed.Scheme),
+		limit:    parseBoundedInt(q.Get("limit"), defaultMailboxLimit, 1, maxMailboxLimit),
+		markSeen: q.Bool("mark_seen", true),
+		cache:    make(map[string]models.Item),
+	}
+
+	switch m.scheme {
+	case "maildir":
+		m.path = filepath.Clean(parsed.Path)
+		if parsed.Path == "" {
+			return nil, fmt.Errorf("maildir URL %q does not name a directory", rawURL)
+		}
+	case "imap", "imaps":
+		if parsed.Host == "" {
+			return nil, fmt.Errorf("imap URL %q does not name a server", rawURL)
+		}
+		m.addr = parsed.Host
+		if parsed.Port() == "" {
+			port := "993"
+			if m.scheme == "imap" {
+				port = "143"
+			}
+			m.addr = net.JoinHostPort(parsed.Hostname(), port)
+		}
+		m.path = strings.Trim(parsed.Path, "/")
+		if m.path == "" {
+			m.path = "INBOX"
+		}
+		if parsed.User != nil {
+			m.username = parsed.User.Username()
+			m.password, _ = parsed.User.Password()
+		}
+		if env := strings.TrimSpace(q.Get("password_env")); env != "" {
+			m.password = os.Getenv(env)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported mailbox scheme %q", parsed.Scheme)
+	}
+
+	return m, nil
+}
+
+// Fetch lists the newest messages of the mailbox.
+func (m *MailboxSource) Fetch(ctx context.Context) ([]models.Item, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var (
+		items []models.Item
+		err   error
+	)
+	if m.scheme == "maildir" {
+		items, err = m.fetchMaildir(ctx)
+	} else {
+		items, err = m.fetchIMAP(ctx)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(items, func(i, j int) bool {
+		return items[i].Published.After(items[j].Published)
+	})
+	return items, nil
+}
+
+// Name returns the source name.
+func (m *MailboxSource) Name() string {
+	return m.name
+}
+
+// Type returns the source type.
+func (m *MailboxSource) Type() string {
+	return "mailbox"
+}
+
+type maildirMessage struct {
+	dir     string // "new" or "cur"
+	name    string
+	modTime time.Time
+}
+
+// maildirKey is the unique part of a Maildir file name, stable across the
+// new/ -> cur/ move and flag changes.
+func maildirKey(name string) string {
+	if i := strings.Index(name, ":"); i >= 0 {
+		return name[:i]
+	}
+	return name
+}
+
+func (m *MailboxSource) fetchMaildir(ctx context.Context) ([]models.Item, error) {
+	messages := make([]maildirMessage, 0, 64)
+	for _, dir := range []string{"new", "cur"} {
+		entries, err := os.ReadDir(filepath.Join(m.path, dir))
+		if err != nil {
+			return nil, fmt.Errorf("mailbox: %w", err)
+		}
+		for _, entry := range entries {
+			if entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+				continue
+			}
+			info, err := entry.Info()
+			if err != nil {
+				continue
+			}
+			messages = append(messages, maildirMessage{dir: dir, name: entry.Name(), modTime: info.ModTime()})
+		}
+	}
+
+	sort.SliceStable(messages, func(i, j int) bool {
+		return messages[i].modTime.After(messages[j].modTime)
+	})
+	if len(messages) > m.limit {
+		messages = messages[:m.limit]
+	}
+
+	items := make([]models.Item, 0, len(messages))
+	keep := make(map[string]bool, len(messages))
+	for _, msg := range messages {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		key := maildirKey(msg.name)
+		keep[key] = true
+		path := filepath.Join(m.path, msg.dir, msg.name)
+
+		item, ok := m.cache[key]
+		if !ok {
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return nil, fmt.Errorf("mailbox: %w", err)
+			}
+			item, err = m.parseMessage(data, msg.modTime)
+			if err != nil {
+				continue
+			}
+			m.cache[key] = item
+		}
+		items = append(items, item)
+
+		// Delivering to cur/ with the Seen flag is how Maildir readers mark a
+		// message as processed.
+		if m.markSeen && msg.dir == "new" {
+			target := filepath.Join(m.path, "cur", key+":2,S")
+			if err := os.Rename(path, target); err != nil {
+				return nil, fmt.Errorf("mailbox: failed to mark %s as seen: %w", msg.name, err)
+			}
+		}
+	}
+
+	m.pruneCache(keep)
+	return items, nil
+}
+
+func (m *MailboxSource) dialIMAP(ctx context.Context) (*imapclient.Client, error) {
+	dialer := &net.Dialer{Timeout: mailboxDialTimeout}
+	conn, err := dialer.DialContext(ctx, "tcp", m.addr)
+	if err != nil {
+		return nil, fmt.Errorf("mailbox: failed to connect to %s: %w", m.addr, err)
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+	if m.scheme == "imaps" {
+		host, _, _ := net.SplitHostPort(m.addr)
+		conn = tls.Client(conn, &tls.Config{ServerName: host})
+	}
+
+	c, err := imapclient.New(conn)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("mailbox: failed to greet %s: %w", m.addr, err)
+	}
+	return c, nil
+}
+
+func (m *MailboxSource) fetchIMAP(ctx context.Context) ([]models.Item, error) {
+	c, err := m.dialIMAP(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer c.Logout()
+
+	if m.username != "" {
+		if err := c.Login(m.username, m.password); err != nil {
+			return nil, fmt.Errorf("mailbox: login failed: %w", err)
+		}
+	}
+
+	if _, err := c.Select(m.path, !m.markSeen); err != nil {
+		return nil, fmt.Errorf("mailbox: failed to select %s: %w", m.path, err)
+	}
+
+	uids, err := c.UidSearch(imap.NewSearchCriteria())
+	if err != nil {
+		return nil, fmt.Errorf("mailbox: search failed: %w", err)
+	}
+	sort.Slice(uids, func(i, j int) bool { return uids[i] < uids[j] })
+	if len(uids) > m.limit {
+		uids = uids[len(uids)-m.limit:]
+	}
+
+	items := make([]models.Item, 0, len(uids))
+	keep := make(map[string]bool, len(uids))
+	missing := new(imap.SeqSet)
+	for _, uid := range uids {
+		key := strconv.FormatUint(uint64(uid), 10)
+		keep[key] = true
+		if item, ok := m.cache[key]; ok {
+			items = append(items, item)
+		} else {
+			missing.AddNum(uid)
+		}
+	}
+
+	if !missing.Empty() {
+		section := &imap.BodySectionName{Peek: true}
+		fetchItems := []imap.FetchItem{imap.FetchUid, imap.FetchInternalDate, section.FetchItem()}
+
+		messages := make(chan *imap.Message, 16)
+		done := make(chan error, 1)
+		go func() {
+			done <- c.UidFetch(missing, fetchItems, messages)
+		}()
+
+		for msg := range messages {
+			body := msg.GetBody(section)
+			if body == nil {
+				continue
+			}
+			data, err := io.ReadAll(body)
+			if err != nil {
+				continue
+			}
+			item, err := m.parseMessage(data, msg.InternalDate)
+			if err != nil {
+				continue
+			}
+			m.cache[strconv.FormatUint(uint64(msg.Uid), 10)] = item
+			items = append(items, item)
+		}
+
+		if err := <-done; err != nil {
+			return nil, fmt.Errorf("mailbox: fetch failed: %w", err)
+		}
+
+		if m.markSeen {
+			flags := []interface{}{imap.SeenFlag}
+			if err := c.UidStore(missing, imap.FormatFlagsOp(imap.AddFlags, true), flags, nil); err != nil {
+				return nil, fmt.Errorf("mailbox: failed to mark messages as seen: %w", err)
+			}
+		}
+	}
+
+	m.pruneCache(keep)
+	return items, nil
+}
+
+func (m *MailboxSource) pruneCache(keep map[string]bool) {
+	for key := range m.cache {
+		if !keep[key] {
+			delete(m.cache, key)
+		}
+	}
+}
+
+// parseMessage turns a raw RFC 5322 message into an item.
+func (m *MailboxSource) parseMessage(data []byte, fallbackTime time.Time) (models.Item, error) {
+	msg, err := mail.ReadMessage(bytes.NewReader(data))
+	if err != nil {
+		return models.Item{}, fmt.Errorf("mailbox: invalid message: %w", err)
+	}
+
+	subject, err := mailboxWordDecoder.DecodeHeader(msg.Header.Get("Subject"))
+	if err != nil {
+		subject = msg.Header.Get("Subject")
+	}
+	subject = normalizeWhitespace(subject)
+	if subject == "" {
+		subject = "(no subject)"
+	}
+
+	author := msg.Header.Get("From")
+	parser := mail.AddressParser{WordDecoder: mailboxWordDecoder}
+	if from, err := parser.Parse(author); err == nil {
+		author = from.Name
+		if author == "" {
+			author = from.Address
+		}
+	}
+
+	published, err := msg.Header.Date()
+	if err != nil {
+		published = fallbackTime
+	}
+
+	htmlBody, textBody := mailboxBodies(msg.Header.Get("Content-Type"), msg.Header.Get("Content-Transfer-Encoding"), msg.Body)
+
+	content := htmlBody
+	excerpt := textBody
+	link := ""
+	if htmlBody != "" {
+		if doc, err := goquery.NewDocumentFromReader(strings.NewReader(htmlBody)); err == nil {
+			link = mailboxBrowserLink(doc)
+			if excerpt == "" {
+				doc.Find("style, script, head").Remove()
+				excerpt = doc.Text()
+			}
+		}
+	} else {
+		content = "<pre>" + html.EscapeString(textBody) + "</pre>"
+	}
+
+	return models.Item{
+		Title:       subject,
+		Link:        link,
+		Description: truncateText(excerpt, mailboxExcerptLength),
+		Content:     content,
+		Author:      author,
+		Published:   published,
+		SourceName:  m.name,
+		SourceType:  "mailbox",
+	}, nil
+}
+
+// mailboxBrowserLink finds the "view in browser" link most newsletters carry.
+func mailboxBrowserLink(doc *goquery.Document) string {
+	link := ""
+	doc.Find("a[href]").EachWithBreak(func(_ int, a *goquery.Selection) bool {
+		href := strings.TrimSpace(a.AttrOr("href", ""))
+		if !strings.HasPrefix(href, "http") {
+			return true
+		}
+		if mailboxBrowserLinkRe.MatchString(normalizeWhitespace(a.Text())) || mailboxBrowserLinkRe.MatchString(a.AttrOr("title", "")) {
+			link = href
+			return false
+		}
+		return true
+	})
+	return link
+}
+
+// mailboxBodies walks a MIME entity and returns its first text/html and
+// text/plain parts, decoded to UTF-8.
+func mailboxBodies(contentType, encoding string, body io.Reader) (htmlBody, textBody string) {
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		mediaType = "text/plain"
+		params = map[string]string{}
+	}
+
+	if strings.HasPrefix(mediaType, "multipart/") {
+		reader := multipart.NewReader(body, params["boundary"])
+		for {
+			part, err := reader.NextRawPart()
+			if err != nil {
+				break
+			}
+			if strings.HasPrefix(strings.ToLower(part.Header.Get("Content-Disposition")), "attachment") {
+				continue
+			}
+			partHTML, partText := mailboxBodies(part.Header.Get("Content-Type"), part.Header.Get("Content-Transfer-Encoding"), part)
+			if htmlBody == "" {
+				htmlBody = partHTML
+			}
+			if textBody == "" {
+				textBody = partText
+			}
+		}
+		return htmlBody, textBody
+	}
+
+	if mediaType != "text/html" && mediaType != "text/plain" {
+		return "", ""
+	}
+
+	switch strings.ToLower(strings.TrimSpace(encoding)) {
+	case "quoted-printable":
+		body = quotedprintable.NewReader(body)
+	case "base64":
+		body = base64.NewDecoder(base64.StdEncoding, body)
+	}
+	if cs := params["charset"]; cs != "" {
+		if decoded, err := charset.NewReaderLabel(cs, body); err == nil {
+			body = decoded
+		}
+	}
+
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return "", ""
+	}
+	if mediaType == "text/html" {
+		return string(data), ""
+	}
+	return "", strings.TrimSpace(string(data))
+}
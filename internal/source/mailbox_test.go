@@ -0,0 +1,166 @@
+package source
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/emersion/go-imap"
+	"github.com/emersion/go-imap/backend/memory"
+	"github.com/emersion/go-imap/server"
+	"github.com/ppowo/feedlet/internal/models"
+)
+
+func testNewsletter(n int) []byte {
+	return fmt.Appendf(nil, "From: Weekly <news@example.com>\r\n"+
+		"Subject: Issue %d\r\n"+
+		"Date: Mon, %02d Jun 2025 09:00:00 +0000\r\n"+
+		"Message-ID: <issue-%d@example.com>\r\n"+
+		"Content-Type: text/html; charset=utf-8\r\n"+
+		"\r\n"+
+		"<p><a href=\"https://news.example.com/%d\">View in browser</a></p><p>Hello</p>\r\n", n, n, n, n)
+}
+
+// checkListedOnce fetches src twice and checks that every fetch lists want,
+// each message once.
+func checkListedOnce(t *testing.T, src *MailboxSource, want ...string) {
+	t.Helper()
+	for fetch := 1; fetch <= 2; fetch++ {
+		items, err := src.Fetch(context.Background())
+		if err != nil {
+			t.Fatalf("fetch %d: %v", fetch, err)
+		}
+		titles := make([]string, 0, len(items))
+		for _, item := range items {
+			titles = append(titles, item.Title)
+		}
+		if !slices.Equal(titles, want) {
+			t.Fatalf("fetch %d listed %q, want %q", fetch, titles, want)
+		}
+	}
+}
+
+func TestMailboxMaildir(t *testing.T) {
+	dir := t.TempDir()
+	for _, sub := range []string{"new", "cur", "tmp"} {
+		if err := os.Mkdir(filepath.Join(dir, sub), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for n := 1; n <= 2; n++ {
+		path := filepath.Join(dir, "new", fmt.Sprintf("1700000000.M%dP1.host", n))
+		if err := os.WriteFile(path, testNewsletter(n), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	src, err := NewMailboxSource("news", "maildir://"+dir, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkListedOnce(t, src, "Issue 2", "Issue 1")
+
+	if entries, _ := os.ReadDir(filepath.Join(dir, "new")); len(entries) != 0 {
+		t.Errorf("new/ still holds %d messages", len(entries))
+	}
+	for n := 1; n <= 2; n++ {
+		seen := filepath.Join(dir, "cur", fmt.Sprintf("1700000000.M%dP1.host:2,S", n))
+		if _, err := os.Stat(seen); err != nil {
+			t.Errorf("message %d not marked seen: %v", n, err)
+		}
+	}
+}
+
+func TestMailboxMaildirMarkSeenFalse(t *testing.T) {
+	dir := t.TempDir()
+	for _, sub := range []string{"new", "cur", "tmp"} {
+		if err := os.Mkdir(filepath.Join(dir, sub), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	path := filepath.Join(dir, "new", "1700000000.M1P1.host")
+	if err := os.WriteFile(path, testNewsletter(1), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	src, err := NewMailboxSource("news", "maildir://"+dir+"?mark_seen=false", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkListedOnce(t, src, "Issue 1")
+	if _, err := os.Stat(path); err != nil {
+		t.Errorf("mark_seen=false moved the message: %v", err)
+	}
+}
+
+// startTestIMAP serves an in-memory mailbox whose INBOX holds the backend's
+// sample message (already seen) and newsletters 1 and 2.
+func startTestIMAP(t *testing.T) (addr string, inbox *memory.Mailbox) {
+	t.Helper()
+	be := memory.New()
+	user, err := be.Login(nil, "username", "password")
+	if err != nil {
+		t.Fatal(err)
+	}
+	mbox, err := user.GetMailbox("INBOX")
+	if err != nil {
+		t.Fatal(err)
+	}
+	inbox = mbox.(*memory.Mailbox)
+	for n := 1; n <= 2; n++ {
+		if err := inbox.CreateMessage(nil, time.Now(), bytes.NewBuffer(testNewsletter(n))); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := server.New(be)
+	srv.AllowInsecureAuth = true
+	go srv.Serve(ln)
+	t.Cleanup(func() { srv.Close() })
+	return ln.Addr().String(), inbox
+}
+
+func imapSeen(inbox *memory.Mailbox) []bool {
+	seen := make([]bool, 0, len(inbox.Messages))
+	for _, msg := range inbox.Messages {
+		seen = append(seen, slices.Contains(msg.Flags, imap.SeenFlag))
+	}
+	return seen
+}
+
+func TestMailboxIMAP(t *testing.T) {
+	addr, inbox := startTestIMAP(t)
+	t.Setenv("FEEDLET_TEST_IMAP_PASSWORD", "password")
+	src, err := NewMailboxSource("news", "imap://username@"+addr+"/INBOX", models.Options{"password_env": "FEEDLET_TEST_IMAP_PASSWORD"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	checkListedOnce(t, src, "Issue 2", "Issue 1", "A little message, just for you")
+	if seen := imapSeen(inbox); !slices.Equal(seen, []bool{true, true, true}) {
+		t.Errorf("seen flags = %v, want all messages seen", seen)
+	}
+}
+
+func TestMailboxIMAPMarkSeenFalse(t *testing.T) {
+	addr, inbox := startTestIMAP(t)
+	src, err := NewMailboxSource("news", "imap://username:password@"+addr+"/INBOX?mark_seen=false", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	checkListedOnce(t, src, "Issue 2", "Issue 1", "A little message, just for you")
+	if seen := imapSeen(inbox); !slices.Equal(seen, []bool{true, false, false}) {
+		t.Errorf("seen flags = %v, want only the sample message seen", seen)
+	}
+}
@@ -0,0 +1,61 @@
+package source
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/ppowo/feedlet/internal/source/httpclient"
+)
+
+const meltzerWikiTestPage = `<div class="mw-parser-output">
+<table class="wikitable sortable">
+<tr><th>No.</th><th>Wrestlers</th><th>Date</th><th>Match</th><th>Promotion</th><th>Event</th><th>Rating</th></tr>
+<tr><td>1</td><td>A vs. B</td><td>January 4, 2017</td><td>Kazuchika Okada vs. Kenny Omega</td><td>NJPW</td><td><a href="/wiki/Wrestle_Kingdom_11">Wrestle Kingdom 11</a></td><td>6<sup class="reference">[1]</sup></td></tr>
+<tr><td>2</td><td>C vs. D</td><td rowspan="2">June 11, 2017</td><td>Kazuchika Okada vs. Kenny Omega II</td><td>NJPW</td><td><a href="/wiki/Dominion_6.11">Dominion 6.11</a></td><td>6.25</td></tr>
+<tr><td>3</td><td>E vs. F</td><td>Tag match</td><td>NJPW</td><td>Dominion 6.11</td><td></td></tr>
+</table>
+</div>`
+
+func TestMeltzerWikiPreset(t *testing.T) {
+	src := NewMeltzerWikiSource("Meltzer", 10)
+	src.SetHTTPClient(httpclient.NewClient(httpclient.NewReplayer(&httpclient.Cassette{
+		Interactions: []httpclient.Interaction{{
+			Method: http.MethodGet,
+			URL:    meltzerWikiConfig.renderURL(),
+			Status: http.StatusOK,
+			Header: http.Header{"Content-Type": {"text/html; charset=UTF-8"}},
+			Body:   meltzerWikiTestPage,
+		}},
+	})))
+
+	items, err := src.Fetch(context.Background())
+	if err != nil {
+		t.Fatalf("Fetch: %v", err)
+	}
+	if len(items) != 2 {
+		t.Fatalf("got %d items, want 2 (the unrated row is skipped): %+v", len(items), items)
+	}
+
+	newest := items[0]
+	if newest.Title != "Kazuchika Okada vs. Kenny Omega II" {
+		t.Errorf("newest title = %q", newest.Title)
+	}
+	if newest.Link != "https://en.wikipedia.org/wiki/Dominion_6.11" {
+		t.Errorf("link = %q, want the event's article", newest.Link)
+	}
+	if want := "★6.25 | NJPW | Dominion 6.11 | June 11, 2017"; newest.Description != want {
+		t.Errorf("description = %q, want %q", newest.Description, want)
+	}
+	if newest.SourceType != "meltzerwiki" || src.Type() != "meltzerwiki" {
+		t.Errorf("type = %q / %q, want meltzerwiki", newest.SourceType, src.Type())
+	}
+	if items[1].Description != "★6 | NJPW | Wrestle Kingdom 11 | January 4, 2017" {
+		t.Errorf("reference markers should be dropped, got %q", items[1].Description)
+	}
+
+	stats := src.ExtractStats()
+	if stats.RowsSeen != 3 || stats.RowsParsed != 2 || stats.MissingFields["rating"] != 1 {
+		t.Errorf("stats = %+v, want 3 seen, 2 parsed, 1 missing rating", stats)
+	}
+}
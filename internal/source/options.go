@@ -0,0 +1,129 @@
+package source
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	neturl "net/url"
+	"strconv"
+
+	"github.com/ppowo/feedlet/internal/models"
+	"github.com/ppowo/feedlet/internal/source/httpclient"
+)
+
+// GenericParams are the options every source type accepts. The fetcher
+// applies them, except nsfw and the display options which the server reads.
+var GenericParams = []Param{
+	{Name: "limit", Kind: models.OptionInt, Description: "Maximum number of items kept per fetch"},
+	{Name: "min_score", Kind: models.OptionInt, Description: "Drop items scoring below this (types with scores only)"},
+	{Name: "nsfw", Kind: models.OptionBool, Default: "false", Description: "Mark the source as NSFW"},
+	{Name: "headers", Kind: models.OptionMap, Description: "Extra HTTP request headers"},
+	{Name: "http_profile", Kind: models.OptionString, Description: "Name of the HTTP profile to make requests with"},
+	{Name: "display_limit", Kind: models.OptionInt, Description: "Items shown on the dashboard, if different from the default"},
+	{Name: "tile_cols", Kind: models.OptionInt, Default: "1", Description: "Dashboard columns the tile spans (1 or 2)"},
+	{Name: "tile_rows", Kind: models.OptionInt, Default: "1", Description: "Dashboard rows the tile spans (1 or 2)"},
+	{Name: "show_description", Kind: models.OptionBool, Default: "false", Description: "Show an excerpt under each item title"},
+	{Name: "debug", Kind: models.OptionBool, Default: "false", Description: "Log the source at debug level whatever the log level"},
+}
+
+// settings resolves a source setting from its typed options first and from
+// the query string of its URL second, which is the older shorthand. Options
+// have been normalized by New, so their text form matches the query form.
+type settings struct {
+	options models.Options
+	query   neturl.Values
+}
+
+// RequestContext returns ctx carrying the HTTP profile and extra headers
+// selected by the http_profile and headers options.
+func RequestContext(ctx context.Context, options models.Options) context.Context {
+	var headers http.Header
+	if extra := options.StringMap("headers"); len(extra) > 0 {
+		headers = make(http.Header, len(extra))
+		for key, value := range extra {
+			headers.Set(key, value)
+		}
+	}
+	return httpclient.WithHeaders(httpclient.WithProfile(ctx, options.String("http_profile", "")), headers)
+}
+
+func newSettings(options models.Options, query neturl.Values) settings {
+	return settings{options: options, query: query}
+}
+
+// Get returns a single-valued setting.
+func (s settings) Get(key string) string {
+	if text, ok := s.options.Text(key); ok {
+		return text
+	}
+	return s.query.Get(key)
+}
+
+// Bool returns a boolean setting. Query values are parsed with
+// strconv.ParseBool, so both 1 and true are accepted.
+func (s settings) Bool(key string, fallback bool) bool {
+	if s.options.Has(key) {
+		return s.options.Bool(key, fallback)
+	}
+	if value := s.query.Get(key); value != "" {
+		if b, err := strconv.ParseBool(value); err == nil {
+			return b
+		}
+	}
+	return fallback
+}
+
+// Values returns a repeatable setting, such as exec's arg.
+func (s settings) Values(key string) []string {
+	if s.options.Has(key) {
+		return s.options.Strings(key)
+	}
+	return s.query[key]
+}
+
+// normalizeOptions checks options against the generic and type-specific
+// params and converts each value to its kind's Go type.
+func normalizeOptions(info TypeInfo, options models.Options) (models.Options, error) {
+	if len(options) == 0 {
+		return options, nil
+	}
+
+	kinds := make(map[string]models.OptionKind, len(GenericParams)+len(info.Params))
+	for _, param := range GenericParams {
+		kinds[param.Name] = param.kind()
+	}
+	for _, param := range info.Params {
+		if param.Name == "url" || param.Name == "home_url" {
+			continue
+		}
+		kinds[param.Name] = param.kind()
+	}
+
+	normalized := make(models.Options, len(options))
+	for _, key := range options.Keys() {
+		kind, ok := kinds[key]
+		if !ok {
+			return nil, fmt.Errorf("unknown option %q for %s sources", key, info.Type)
+		}
+		if key == "min_score" && !info.Capabilities.Scores {
+			return nil, fmt.Errorf("option min_score needs a source type with scores, %s has none", info.Type)
+		}
+
+		value, err := models.NormalizeOption(options[key], kind)
+		if err != nil {
+			return nil, fmt.Errorf("option %q: %w", key, err)
+		}
+		switch key {
+		case "limit", "display_limit", "min_score":
+			if value.(int) < 0 {
+				return nil, fmt.Errorf("option %q must not be negative", key)
+			}
+		case "tile_cols", "tile_rows":
+			if n := value.(int); n < 1 || n > 2 {
+				return nil, fmt.Errorf("option %q must be 1 or 2, got %d", key, n)
+			}
+		}
+		normalized[key] = value
+	}
+	return normalized, nil
+}
@@ -0,0 +1,180 @@
+package source
+
+import (
+	"fmt"
+	neturl "net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ppowo/feedlet/internal/models"
+)
+
+// defaultItemLimit is how many items scraper-style sources keep per fetch.
+const defaultItemLimit = 4
+
+// Factory builds a source from its config.
+type Factory func(cfg models.SourceConfig) (Source, error)
+
+// Param describes one setting of a source type. Name is "url", "home_url"
+// or an option, which may also be given as a query parameter of the URL.
+// Kind defaults to string.
+type Param struct {
+	Name        string            `json:"name"`
+	Kind        models.OptionKind `json:"kind,omitempty"`
+	Required    bool              `json:"required"`
+	Default     string            `json:"default,omitempty"`
+	Description string            `json:"description"`
+}
+
+func (p Param) kind() models.OptionKind {
+	if p.Kind == "" {
+		return models.OptionString
+	}
+	return p.Kind
+}
+
+// Policy is the default scheduling behaviour of a source type. Zero values
+// disable the corresponding behaviour.
+type Policy struct {
+	// Interval is used when the config does not set one
+	Interval time.Duration
+
+	// StartupStagger spreads first fetches randomly over this window
+	StartupStagger time.Duration
+
+	// BackoffCap enables exponential backoff after repeated failures, up to
+	// this delay
+	BackoffCap time.Duration
+
+	// HostSpacing is the minimum time between requests to the same host
+	// across all sources of the type
+	HostSpacing time.Duration
+}
+
+// Capabilities describe optional features of a source type.
+type Capabilities struct {
+	ConditionalGet bool `json:"conditional_get"` // Revalidates with ETag/Last-Modified
+	Scores         bool `json:"scores"`          // Items carry points/votes
+	NSFW           bool `json:"nsfw"`            // May contain adult content
+	Push           bool `json:"push"`            // Can receive WebSub pushes
+}
+
+// TypeInfo describes a registered source type.
+type TypeInfo struct {
+	Type         string
+	Description  string
+	Factory      Factory
+	Params       []Param
+	Policy       Policy
+	Capabilities Capabilities
+}
+
+var (
+	registryMu sync.RWMutex
+	registry   = make(map[string]TypeInfo)
+)
+
+// Register adds a source type. It is meant to be called from init functions
+// and panics on an incomplete or duplicate registration.
+func Register(info TypeInfo) {
+	if info.Type == "" || info.Factory == nil {
+		panic("source: Register requires a type and a factory")
+	}
+
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	if _, exists := registry[info.Type]; exists {
+		panic(fmt.Sprintf("source: type %q registered twice", info.Type))
+	}
+	registry[info.Type] = info
+}
+
+// Lookup returns the registration of a source type.
+func Lookup(sourceType string) (TypeInfo, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	info, ok := registry[sourceType]
+	return info, ok
+}
+
+// MustLookup is like Lookup but panics if the type is not registered.
+func MustLookup(sourceType string) TypeInfo {
+	info, ok := Lookup(sourceType)
+	if !ok {
+		panic(fmt.Sprintf("source: unknown type %q", sourceType))
+	}
+	return info
+}
+
+// Types returns all registered source types sorted by name.
+func Types() []TypeInfo {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	types := make([]TypeInfo, 0, len(registry))
+	for _, info := range registry {
+		types = append(types, info)
+	}
+	sort.Slice(types, func(i, j int) bool {
+		return types[i].Type < types[j].Type
+	})
+	return types
+}
+
+// New validates cfg against its type's schema and builds the source. The
+// factory receives the config with normalized options.
+func New(cfg models.SourceConfig) (Source, error) {
+	info, ok := Lookup(cfg.Type)
+	if !ok {
+		return nil, fmt.Errorf("unknown source type %q", cfg.Type)
+	}
+
+	options, err := normalizeOptions(info, cfg.Options)
+	if err != nil {
+		return nil, err
+	}
+	cfg.Options = options
+
+	var query neturl.Values
+	if parsed, err := neturl.Parse(cfg.URL); err == nil {
+		query = parsed.Query()
+	}
+	settings := newSettings(cfg.Options, query)
+
+	for _, param := range info.Params {
+		if !param.Required {
+			continue
+		}
+		var missing bool
+		switch param.Name {
+		case "url":
+			missing = strings.TrimSpace(cfg.URL) == ""
+		case "home_url":
+			missing = strings.TrimSpace(cfg.HomeURL) == ""
+		default:
+			missing = strings.TrimSpace(settings.Get(param.Name)) == ""
+		}
+		if missing {
+			return nil, fmt.Errorf("%s source %q requires %s", cfg.Type, cfg.Name, param.Name)
+		}
+	}
+
+	src, err := info.Factory(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return src, nil
+}
+
+// Validate reports whether cfg describes a source that can be built.
+func Validate(cfg models.SourceConfig) error {
+	if strings.TrimSpace(cfg.Name) == "" {
+		return fmt.Errorf("%s source has no name", cfg.Type)
+	}
+	_, err := New(cfg)
+	return err
+}
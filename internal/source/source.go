@@ -2,9 +2,15 @@ package source
 
 import (
 	"context"
+	"time"
+
+	"github.com/hashicorp/go-retryablehttp"
 	"github.com/ppowo/feedlet/internal/models"
 )
 
+// FetchTimeout is the deadline the fetcher gives each call to Fetch.
+const FetchTimeout = 30 * time.Second
+
 // Source is the interface that all source types must implement
 // This allows easy extension to non-RSS sources (Reddit API, HN API, etc.)
 type Source interface {
@@ -17,3 +23,31 @@ type Source interface {
 	// Type returns the source type (rss, reddit, etc.)
 	Type() string
 }
+
+// Pushable is implemented by sources whose upstream can push updates over
+// WebSub. PushHub returns the hub and topic discovered on the last fetch, or
+// an empty hub if the upstream does not advertise one.
+type Pushable interface {
+	Source
+
+	PushHub() (hub, topic string)
+
+	// ParsePush turns content delivered by the hub into items
+	ParsePush(body []byte) ([]models.Item, error)
+}
+
+// HTTPSource is implemented by sources that fetch over HTTP. SetHTTPClient
+// replaces the shared client, e.g. with one that records or replays fixtures.
+type HTTPSource interface {
+	Source
+
+	SetHTTPClient(client *retryablehttp.Client)
+}
+
+// StatsReporter is implemented by scrapers. ExtractStats returns the stats of
+// the last fetch; Pages is zero if it failed before any page was parsed.
+type StatsReporter interface {
+	Source
+
+	ExtractStats() models.ExtractStats
+}
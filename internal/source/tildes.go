@@ -1,34 +1,89 @@
 package source
 
 import (
+	"bytes"
 	"context"
 	"fmt"
+	"html"
 	"net/http"
 	neturl "net/url"
+	"slices"
 	"sort"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/PuerkitoBio/goquery"
 	"github.com/hashicorp/go-retryablehttp"
 	"github.com/ppowo/feedlet/internal/models"
+	"github.com/ppowo/feedlet/internal/source/fetcherr"
 	"github.com/ppowo/feedlet/internal/source/httpclient"
 )
 
+const (
+	defaultTildesPages = 1
+	maxTildesPages     = 10
+	maxTildesComments  = 10
+)
+
+// Query parameters consumed by Feedlet rather than sent to Tildes.
+var tildesOptionParams = []string{"pages", "tags", "exclude_tags", "comments"}
+
 // TildesSource fetches topic listings directly from Tildes HTML pages.
+//
+// Besides Tildes' own listing parameters (order, period, tag, ...) the URL
+// accepts: pages (listing pages to follow, default 1), tags / exclude_tags
+// (comma separated topic tag filters) and comments (number of top comments of
+// each topic to include as Content).
 type TildesSource struct {
-	name  string
-	url   string
-	limit int
+	name        string
+	url         string
+	limit       int
+	pages       int
+	includeTags []string
+	excludeTags []string
+	comments    int
+
+	lastExtract
+	clientHolder
+}
+
+func init() {
+	Register(TypeInfo{
+		Type:        "tildes",
+		Description: "Tildes topic listing",
+		Factory: func(cfg models.SourceConfig) (Source, error) {
+			return NewTildesSource(cfg.Name, cfg.URL, cfg.Options, cfg.Options.Int("limit", defaultItemLimit)), nil
+		},
+		Params: []Param{
+			{Name: "url", Required: true, Description: "Tildes listing URL, e.g. https://tildes.net/~comp?order=votes"},
+			{Name: "pages", Kind: models.OptionInt, Default: "1", Description: "Listing pages to follow"},
+			{Name: "tags", Kind: models.OptionList, Description: "Only topics with one of these tags (comma separated)"},
+			{Name: "exclude_tags", Kind: models.OptionList, Description: "Skip topics with any of these tags"},
+			{Name: "comments", Kind: models.OptionInt, Default: "0", Description: "Top-level comments to include"},
+		},
+		Capabilities: Capabilities{Scores: true},
+	})
 }
 
 // NewTildesSource creates a new Tildes source.
-func NewTildesSource(name, rawURL string, limit int) *TildesSource {
-	return &TildesSource{
+func NewTildesSource(name, rawURL string, options models.Options, limit int) *TildesSource {
+	t := &TildesSource{
 		name:  name,
-		url:   normalizeTildesURL(rawURL),
 		limit: limit,
+		pages: defaultTildesPages,
 	}
+
+	if parsed, err := neturl.Parse(rawURL); err == nil {
+		q := newSettings(options, parsed.Query())
+		t.pages = parseBoundedInt(q.Get("pages"), defaultTildesPages, 1, maxTildesPages)
+		t.includeTags = splitList(strings.ToLower(q.Get("tags")))
+		t.excludeTags = splitList(strings.ToLower(q.Get("exclude_tags")))
+		t.comments = parseBoundedInt(q.Get("comments"), 0, 0, maxTildesComments)
+	}
+	t.url = normalizeTildesURL(rawURL)
+
+	return t
 }
 
 func normalizeTildesURL(rawURL string) string {
@@ -37,6 +92,12 @@ func normalizeTildesURL(rawURL string) string {
 		return rawURL
 	}
 
+	q := parsed.Query()
+	for _, key := range tildesOptionParams {
+		q.Del(key)
+	}
+	parsed.RawQuery = q.Encode()
+
 	switch {
 	case strings.HasSuffix(parsed.Path, "/topics.atom"):
 		parsed.Path = strings.TrimSuffix(parsed.Path, "/topics.atom")
@@ -66,49 +127,67 @@ func (t *TildesSource) fetchDocument(ctx context.Context, fetchURL string) (*goq
 	req.Header.Set("User-Agent", httpclient.RandomUserAgent())
 	req.Header.Set("Accept", "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8")
 
-	resp, err := httpclient.GetClient().Do(req)
+	resp, err := t.httpClient().Do(req)
 	if err != nil {
 		return nil, nil, fmt.Errorf("tildes: failed to fetch listing: %w", err)
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, nil, fmt.Errorf("tildes: unexpected status %d", resp.StatusCode)
+	body, err := httpclient.ReadBody(resp, httpclient.ExpectHTML)
+	if err != nil {
+		return nil, nil, fmt.Errorf("tildes: failed to fetch listing: %w", err)
 	}
 
-	doc, err := goquery.NewDocumentFromReader(resp.Body)
+	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(body))
 	if err != nil {
-		return nil, nil, fmt.Errorf("tildes: failed to parse HTML: %w", err)
+		return nil, nil, fetcherr.New(fetcherr.Parse, "tildes: failed to parse HTML: %w", err)
 	}
 
 	return doc, resp.Request.URL, nil
 }
 
-func (t *TildesSource) parseTopic(article *goquery.Selection, baseURL *neturl.URL) (models.Item, bool) {
+func (t *TildesSource) parseTopic(article *goquery.Selection, baseURL *neturl.URL, rec *extractRecorder) (models.Item, bool) {
 	titleLink := article.Find("h1.topic-title a[href]").First()
 	title := strings.TrimSpace(titleLink.Text())
 	if title == "" {
+		rec.missing("title")
 		return models.Item{}, false
 	}
 
-	linkHref := strings.TrimSpace(article.Find("footer.topic-info .topic-info-comments a[href]").First().AttrOr("href", ""))
+	commentsLink := article.Find("footer.topic-info .topic-info-comments a[href]").First()
+	linkHref := strings.TrimSpace(commentsLink.AttrOr("href", ""))
 	if linkHref == "" {
 		linkHref = strings.TrimSpace(titleLink.AttrOr("href", ""))
 	}
 	if linkHref == "" {
+		rec.missing("link")
 		return models.Item{}, false
 	}
 
 	datetime := strings.TrimSpace(article.Find("footer.topic-info time[datetime]").First().AttrOr("datetime", ""))
 	if datetime == "" {
+		rec.missing("published")
 		return models.Item{}, false
 	}
 
 	published, err := time.Parse(time.RFC3339, datetime)
 	if err != nil {
+		rec.missing("published")
 		return models.Item{}, false
 	}
 
+	tags := make([]string, 0, 4)
+	article.Find("ul.topic-tags li a").Each(func(_ int, tag *goquery.Selection) {
+		if name := strings.TrimSpace(tag.Text()); name != "" {
+			tags = append(tags, name)
+		}
+	})
+
+	domain := strings.TrimSpace(article.Find(".topic-info-source").First().AttrOr("title", ""))
+	if domain == "" {
+		domain = normalizeWhitespace(article.Find(".topic-info-source").First().Text())
+	}
+
 	return models.Item{
 		Title:       title,
 		Link:        resolveTildesURL(baseURL, linkHref),
@@ -116,35 +195,108 @@ func (t *TildesSource) parseTopic(article *goquery.Selection, baseURL *neturl.UR
 		Author:      strings.TrimSpace(article.AttrOr("data-topic-posted-by", "")),
 		Published:   published,
 		SourceName:  t.name,
+		SourceType:  "tildes",
+		Score:       leadingInt(article.Find(".topic-voting-votes").First().Text()),
+		Comments:    leadingInt(commentsLink.Text()),
+		Tags:        tags,
+		Domain:      domain,
 	}, true
 }
 
-func (t *TildesSource) parseListingPage(doc *goquery.Document, baseURL *neturl.URL) ([]models.Item, error) {
+// parseListingPage returns the topics of one listing page and the absolute URL
+// of the next page, if any.
+func (t *TildesSource) parseListingPage(doc *goquery.Document, baseURL *neturl.URL, rec *extractRecorder) ([]models.Item, string, error) {
+	rec.page()
 	listing := doc.Find("ol.topic-listing")
 	if listing.Length() == 0 {
-		return nil, fmt.Errorf("tildes: topic listing not found")
+		return nil, "", fetcherr.New(fetcherr.SchemaDrift, "tildes: topic listing not found")
 	}
 
 	items := make([]models.Item, 0, 32)
-	listing.Find("article.topic").Each(func(_ int, article *goquery.Selection) {
-		if item, ok := t.parseTopic(article, baseURL); ok {
+	articles := listing.Find("article.topic")
+	articles.Each(func(_ int, article *goquery.Selection) {
+		rec.seen()
+		if item, ok := t.parseTopic(article, baseURL, rec); ok {
+			rec.parsed()
 			items = append(items, item)
 		}
 	})
+	if articles.Length() > 0 && len(items) == 0 {
+		return nil, "", fetcherr.New(fetcherr.SchemaDrift, "tildes: none of %d topics could be parsed", articles.Length())
+	}
 
-	return items, nil
+	nextURL := ""
+	if href := strings.TrimSpace(doc.Find(`#next-page[href], .pagination a[rel~="next"][href]`).First().AttrOr("href", "")); href != "" {
+		nextURL = resolveTildesURL(baseURL, href)
+	}
+
+	return items, nextURL, nil
 }
 
-// Fetch retrieves topics from the configured Tildes listing.
-func (t *TildesSource) Fetch(ctx context.Context) ([]models.Item, error) {
-	doc, baseURL, err := t.fetchDocument(ctx, t.url)
-	if err != nil {
-		return nil, err
+func (t *TildesSource) matchesTags(item models.Item) bool {
+	hasTag := func(wanted []string) bool {
+		for _, tag := range item.Tags {
+			if slices.Contains(wanted, strings.ToLower(tag)) {
+				return true
+			}
+		}
+		return false
 	}
 
-	items, err := t.parseListingPage(doc, baseURL)
+	if len(t.includeTags) > 0 && !hasTag(t.includeTags) {
+		return false
+	}
+	return !hasTag(t.excludeTags)
+}
+
+// fetchComments returns the top-level comments of a topic page, in the order
+// Tildes shows them, as HTML.
+func (t *TildesSource) fetchComments(ctx context.Context, topicURL string) (string, error) {
+	doc, _, err := t.fetchDocument(ctx, topicURL)
 	if err != nil {
-		return nil, err
+		return "", err
+	}
+
+	var b strings.Builder
+	count := 0
+	doc.Find("#comments > li > article.comment").EachWithBreak(func(_ int, comment *goquery.Selection) bool {
+		text, err := comment.Find(".comment-itself .comment-text").First().Html()
+		if err != nil || strings.TrimSpace(text) == "" {
+			return true
+		}
+		author := strings.TrimSpace(comment.Find(".comment-itself header .link-user").First().Text())
+		fmt.Fprintf(&b, "<blockquote><p><b>%s</b>:</p>\n%s</blockquote>\n", html.EscapeString(author), strings.TrimSpace(text))
+		count++
+		return count < t.comments
+	})
+
+	return b.String(), nil
+}
+
+// Fetch retrieves topics from the configured Tildes listing.
+func (t *TildesSource) Fetch(ctx context.Context) ([]models.Item, error) {
+	items := make([]models.Item, 0, 32*t.pages)
+	pageURL := t.url
+	rec := &extractRecorder{}
+	defer t.store(rec)
+
+	for page := 0; page < t.pages && pageURL != ""; page++ {
+		doc, baseURL, err := t.fetchDocument(ctx, pageURL)
+		if err != nil {
+			return nil, err
+		}
+
+		pageItems, nextURL, err := t.parseListingPage(doc, baseURL, rec)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, item := range pageItems {
+			if t.matchesTags(item) {
+				items = append(items, item)
+			}
+		}
+		pageURL = nextURL
 	}
 
 	sort.SliceStable(items, func(i, j int) bool {
@@ -155,9 +307,36 @@ func (t *TildesSource) Fetch(ctx context.Context) ([]models.Item, error) {
 		items = items[:t.limit]
 	}
 
+	if t.comments > 0 {
+		for i := range items {
+			content, err := t.fetchComments(ctx, items[i].Link)
+			if err != nil {
+				if ctx.Err() != nil {
+					return nil, err
+				}
+				continue
+			}
+			items[i].Content = content
+		}
+	}
+
 	return items, nil
 }
 
+// leadingInt parses the first run of digits in text ("12 comments" -> 12).
+func leadingInt(text string) int {
+	text = strings.TrimSpace(text)
+	end := strings.IndexFunc(text, func(r rune) bool { return r < '0' || r > '9' })
+	if end < 0 {
+		end = len(text)
+	}
+	n, err := strconv.Atoi(text[:end])
+	if err != nil {
+		return 0
+	}
+	return n
+}
+
 // Name returns the source name.
 func (t *TildesSource) Name() string {
 	return t.name
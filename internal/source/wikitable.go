@@ -0,0 +1,524 @@
+package source
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	neturl "net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/hashicorp/go-retryablehttp"
+	"github.com/ppowo/feedlet/internal/models"
+	"github.com/ppowo/feedlet/internal/source/fetcherr"
+	"github.com/ppowo/feedlet/internal/source/httpclient"
+)
+
+const defaultWikiTableDateLayout = "January 2, 2006"
+
+// WikiTableConfig describes which Wikipedia tables a WikiTableSource reads
+// and how their columns map onto items. Column indexes are 0-based and count
+// cells after rowspans have been expanded.
+type WikiTableConfig struct {
+	Site               string   // e.g. https://en.wikipedia.org
+	Article            string   // Article title, e.g. List_of_Hugo_Award_winners
+	Table              string   // Selector of the candidate tables; table.wikitable if empty
+	Headers            []string // Labels that must all appear in a table's header row
+	TitleColumn        int
+	DateColumn         int
+	LinkColumn         int            // Column whose first link is the item link; -1 uses the title cell
+	DescriptionColumns []int          // Columns joined into the description
+	ContentColumns     []int          // Columns listed as "Label: value" lines in the content; nil lists all
+	ValuePrefix        map[int]string // Text put before a column's value in description and content, e.g. "★"
+	RequiredColumns    []int          // Columns besides title and date that rows must fill
+	DateLayout         string
+	SortColumn         int // Column to rank rows by before limiting; -1 sorts by date
+}
+
+// ParseWikiTableURL builds a WikiTableConfig from an article URL such as
+// https://en.wikipedia.org/wiki/Some_list?headers=Date,Title&title=1&date=0.
+//
+// Query parameters: headers (comma separated labels), title, date, link,
+// description, content and required (comma separated columns), date_layout
+// (Go layout) and sort.
+func ParseWikiTableURL(rawURL string, options models.Options) (WikiTableConfig, error) {
+	parsed, err := neturl.Parse(strings.TrimSpace(rawURL))
+	if err != nil {
+		return WikiTableConfig{}, fmt.Errorf("invalid wikitable URL: %w", err)
+	}
+	if parsed.Scheme == "" || parsed.Host == "" {
+		return WikiTableConfig{}, fmt.Errorf("wikitable URL %q must include scheme and host", rawURL)
+	}
+
+	article := strings.TrimPrefix(parsed.Path, "/wiki/")
+	if article == "" || article == parsed.Path {
+		return WikiTableConfig{}, fmt.Errorf("wikitable URL %q does not name an article", rawURL)
+	}
+
+	q := newSettings(options, parsed.Query())
+	cfg := WikiTableConfig{
+		Site:       parsed.Scheme + "://" + parsed.Host,
+		Article:    article,
+		Headers:    splitList(q.Get("headers")),
+		LinkColumn: -1,
+		SortColumn: -1,
+		DateLayout: strings.TrimSpace(q.Get("date_layout")),
+	}
+	if cfg.DateLayout == "" {
+		cfg.DateLayout = defaultWikiTableDateLayout
+	}
+
+	columns := map[string]*int{
+		"title": &cfg.TitleColumn,
+		"date":  &cfg.DateColumn,
+		"link":  &cfg.LinkColumn,
+		"sort":  &cfg.SortColumn,
+	}
+	for _, key := range []string{"title", "date", "link", "sort"} {
+		value := strings.TrimSpace(q.Get(key))
+		if value == "" {
+			if key == "title" || key == "date" {
+				return WikiTableConfig{}, fmt.Errorf("wikitable URL %q is missing the %s column", rawURL, key)
+			}
+			continue
+		}
+		n, ok := parsePositiveInt(value)
+		if !ok {
+			return WikiTableConfig{}, fmt.Errorf("invalid wikitable %s column %q", key, value)
+		}
+		*columns[key] = n
+	}
+
+	lists := []struct {
+		key  string
+		dest *[]int
+	}{
+		{"description", &cfg.DescriptionColumns},
+		{"content", &cfg.ContentColumns},
+		{"required", &cfg.RequiredColumns},
+	}
+	for _, list := range lists {
+		for _, value := range splitList(q.Get(list.key)) {
+			n, ok := parsePositiveInt(value)
+			if !ok {
+				return WikiTableConfig{}, fmt.Errorf("invalid wikitable %s column %q", list.key, value)
+			}
+			*list.dest = append(*list.dest, n)
+		}
+	}
+
+	return cfg, nil
+}
+
+// PageURL returns the canonical article URL.
+func (c WikiTableConfig) PageURL() string {
+	return c.Site + "/wiki/" + c.Article
+}
+
+func (c WikiTableConfig) renderURL() string {
+	return c.Site + "/w/index.php?title=" + neturl.QueryEscape(c.Article) + "&action=render"
+}
+
+// WikiTableSource turns the rows of matching tables in a Wikipedia article
+// into items. Presets such as meltzerwiki are WikiTableSources with a fixed
+// config and their own type.
+type WikiTableSource struct {
+	name    string
+	typ     string
+	limit   int
+	cfg     WikiTableConfig
+	baseURL *neturl.URL
+
+	lastExtract
+	clientHolder
+}
+
+func init() {
+	Register(TypeInfo{
+		Type:        "wikitable",
+		Description: "Rows of a Wikipedia list article",
+		Factory: func(cfg models.SourceConfig) (Source, error) {
+			tableCfg, err := ParseWikiTableURL(cfg.URL, cfg.Options)
+			if err != nil {
+				return nil, err
+			}
+			return NewWikiTableSource(cfg.Name, tableCfg, cfg.Options.Int("limit", defaultItemLimit)), nil
+		},
+		Params: []Param{
+			{Name: "url", Required: true, Description: "Wikipedia article URL"},
+			{Name: "headers", Kind: models.OptionList, Description: "Labels that must all appear in the table header"},
+			{Name: "title", Kind: models.OptionInt, Required: true, Description: "Title column (0-based)"},
+			{Name: "date", Kind: models.OptionInt, Required: true, Description: "Date column (0-based)"},
+			{Name: "link", Kind: models.OptionInt, Description: "Link column"},
+			{Name: "description", Kind: models.OptionList, Description: "Columns joined into the description"},
+			{Name: "content", Kind: models.OptionList, Description: "Columns listed in the content; all if unset"},
+			{Name: "required", Kind: models.OptionList, Description: "Columns that must not be empty, besides title and date"},
+			{Name: "date_layout", Default: "January 2, 2006", Description: "Go time layout of the date column"},
+			{Name: "sort", Kind: models.OptionInt, Description: "Column to rank rows by instead of date"},
+		},
+		Policy: Policy{Interval: 6 * time.Hour},
+	})
+}
+
+// NewWikiTableSource creates a new Wikipedia table source.
+func NewWikiTableSource(name string, cfg WikiTableConfig, limit int) *WikiTableSource {
+	baseURL, _ := neturl.Parse(cfg.Site + "/")
+	return &WikiTableSource{
+		name:    name,
+		typ:     "wikitable",
+		limit:   limit,
+		cfg:     cfg,
+		baseURL: baseURL,
+	}
+}
+
+type wikiTableCell struct {
+	text string
+	link string
+}
+
+type wikiTablePendingCell struct {
+	cell      wikiTableCell
+	remaining int
+}
+
+type wikiTableRow struct {
+	item    models.Item
+	sortKey string
+}
+
+func resolveWikiURL(baseURL *neturl.URL, href string) string {
+	ref, err := neturl.Parse(strings.TrimSpace(href))
+	if err != nil || ref.String() == "" {
+		return ""
+	}
+	if baseURL == nil {
+		return ref.String()
+	}
+	return baseURL.ResolveReference(ref).String()
+}
+
+func normalizeWhitespace(value string) string {
+	return strings.Join(strings.Fields(strings.TrimSpace(value)), " ")
+}
+
+func cleanWikiTableCellText(cell *goquery.Selection) string {
+	html, err := cell.Html()
+	if err != nil {
+		return normalizeWhitespace(cell.Text())
+	}
+
+	html = strings.NewReplacer("<br>", " / ", "<br/>", " / ", "<br />", " / ").Replace(html)
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader("<div>" + html + "</div>"))
+	if err != nil {
+		return normalizeWhitespace(cell.Text())
+	}
+	doc.Find("sup.reference").Remove()
+	return normalizeWhitespace(doc.Text())
+}
+
+func extractWikiTableCell(cell *goquery.Selection, baseURL *neturl.URL) wikiTableCell {
+	return wikiTableCell{
+		text: cleanWikiTableCellText(cell),
+		link: resolveWikiURL(baseURL, strings.TrimSpace(cell.Find("a[href]").First().AttrOr("href", ""))),
+	}
+}
+
+func consumeWikiTablePendingCells(cells []wikiTableCell, pending map[int]wikiTablePendingCell, col *int) []wikiTableCell {
+	for {
+		span, ok := pending[*col]
+		if !ok {
+			return cells
+		}
+
+		cells = append(cells, span.cell)
+		if span.remaining <= 1 {
+			delete(pending, *col)
+		} else {
+			span.remaining--
+			pending[*col] = span
+		}
+		*col++
+	}
+}
+
+// parseWikiTable returns the data rows of a wikitable with rowspans expanded,
+// so that every row has its cells at their visual column index.
+func parseWikiTable(table *goquery.Selection, baseURL *neturl.URL) ([][]wikiTableCell, error) {
+	rows := make([][]wikiTableCell, 0, 64)
+	pending := make(map[int]wikiTablePendingCell)
+
+	table.Find("tr").Each(func(_ int, row *goquery.Selection) {
+		if row.Find("td").Length() == 0 || row.Find("th").Length() > 0 {
+			return
+		}
+
+		cells := make([]wikiTableCell, 0, 8)
+		col := 0
+		row.ChildrenFiltered("td").Each(func(_ int, cell *goquery.Selection) {
+			cells = consumeWikiTablePendingCells(cells, pending, &col)
+
+			parsed := extractWikiTableCell(cell, baseURL)
+			cells = append(cells, parsed)
+
+			if rowspan, err := strconv.Atoi(strings.TrimSpace(cell.AttrOr("rowspan", ""))); err == nil && rowspan > 1 {
+				pending[col] = wikiTablePendingCell{cell: parsed, remaining: rowspan - 1}
+			}
+			col++
+		})
+
+		cells = consumeWikiTablePendingCells(cells, pending, &col)
+		if len(cells) > 0 {
+			rows = append(rows, cells)
+		}
+	})
+
+	if len(rows) == 0 {
+		return nil, fetcherr.New(fetcherr.Empty, "no data rows found in table")
+	}
+
+	return rows, nil
+}
+
+// wikiTableHeaderLabels returns the cleaned labels of a table's first row.
+func wikiTableHeaderLabels(table *goquery.Selection) []string {
+	labels := make([]string, 0, 8)
+	table.Find("tr").First().ChildrenFiltered("th, td").Each(func(_ int, cell *goquery.Selection) {
+		labels = append(labels, cleanWikiTableCellText(cell))
+	})
+	return labels
+}
+
+// wikiTableHasHeaders reports whether a table's header row mentions every label.
+func wikiTableHasHeaders(table *goquery.Selection, headers []string) bool {
+	headerText := normalizeWhitespace(table.Find("tr").First().Text())
+	for _, header := range headers {
+		if !strings.Contains(headerText, header) {
+			return false
+		}
+	}
+	return true
+}
+
+// fetchWikiDocument fetches the rendered article body of a Wikipedia page.
+func fetchWikiDocument(ctx context.Context, client *retryablehttp.Client, renderURL, errPrefix string) (*goquery.Document, error) {
+	req, err := retryablehttp.NewRequestWithContext(ctx, http.MethodGet, renderURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to create request: %w", errPrefix, err)
+	}
+	req.Header.Set("User-Agent", httpclient.RandomUserAgent())
+	req.Header.Set("Accept", "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to fetch listing: %w", errPrefix, err)
+	}
+	defer resp.Body.Close()
+
+	body, err := httpclient.ReadBody(resp, httpclient.ExpectHTML)
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to fetch listing: %w", errPrefix, err)
+	}
+
+	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(body))
+	if err != nil {
+		return nil, fetcherr.New(fetcherr.Parse, "%s: failed to parse HTML: %w", errPrefix, err)
+	}
+
+	return doc, nil
+}
+
+func (w *WikiTableSource) parseRow(row []wikiTableCell, labels []string, rec *extractRecorder) (wikiTableRow, bool) {
+	cell := func(col int) wikiTableCell {
+		if col < 0 || col >= len(row) {
+			return wikiTableCell{}
+		}
+		return row[col]
+	}
+
+	title := cell(w.cfg.TitleColumn).text
+	if title == "" {
+		rec.missing("title")
+		return wikiTableRow{}, false
+	}
+
+	dateStr := cell(w.cfg.DateColumn).text
+	if dateStr == "" {
+		rec.missing("date")
+		return wikiTableRow{}, false
+	}
+
+	published, err := time.Parse(w.cfg.DateLayout, dateStr)
+	if err != nil {
+		rec.missing("date")
+		return wikiTableRow{}, false
+	}
+
+	for _, col := range w.cfg.RequiredColumns {
+		if cell(col).text == "" {
+			field := fmt.Sprintf("column %d", col)
+			if col < len(labels) && labels[col] != "" {
+				field = strings.ToLower(labels[col])
+			}
+			rec.missing(field)
+			return wikiTableRow{}, false
+		}
+	}
+
+	link := cell(w.cfg.TitleColumn).link
+	if w.cfg.LinkColumn >= 0 {
+		link = cell(w.cfg.LinkColumn).link
+	}
+	if link == "" {
+		link = w.cfg.PageURL()
+	}
+
+	parts := make([]string, 0, len(w.cfg.DescriptionColumns))
+	for _, col := range w.cfg.DescriptionColumns {
+		if text := cell(col).text; text != "" {
+			parts = append(parts, w.cfg.ValuePrefix[col]+text)
+		}
+	}
+
+	contentColumns := w.cfg.ContentColumns
+	if contentColumns == nil {
+		contentColumns = make([]int, len(row))
+		for i := range row {
+			contentColumns[i] = i
+		}
+	}
+	var content strings.Builder
+	for _, col := range contentColumns {
+		text := cell(col).text
+		if text == "" {
+			continue
+		}
+		label := fmt.Sprintf("Column %d", col+1)
+		if col < len(labels) && labels[col] != "" {
+			label = labels[col]
+		}
+		fmt.Fprintf(&content, "%s: %s%s\n", label, w.cfg.ValuePrefix[col], text)
+	}
+
+	return wikiTableRow{
+		item: models.Item{
+			Title:       title,
+			Link:        link,
+			Description: strings.Join(parts, " | "),
+			Content:     strings.TrimRight(content.String(), "\n"),
+			Author:      "Wikipedia",
+			Published:   published,
+			SourceName:  w.name,
+			SourceType:  w.typ,
+		},
+		sortKey: cell(w.cfg.SortColumn).text,
+	}, true
+}
+
+func (w *WikiTableSource) parseDocument(doc *goquery.Document, rec *extractRecorder) ([]wikiTableRow, error) {
+	rec.page()
+	rows := make([]wikiTableRow, 0, 256)
+	tables := 0
+	var parseErr error
+
+	selector := w.cfg.Table
+	if selector == "" {
+		selector = "table.wikitable"
+	}
+	doc.Find(selector).Each(func(_ int, table *goquery.Selection) {
+		if parseErr != nil || !wikiTableHasHeaders(table, w.cfg.Headers) {
+			return
+		}
+
+		tables++
+		labels := wikiTableHeaderLabels(table)
+		cells, err := parseWikiTable(table, w.baseURL)
+		if err != nil {
+			parseErr = fmt.Errorf("%s: %w", w.typ, err)
+			return
+		}
+
+		for _, row := range cells {
+			rec.seen()
+			if parsed, ok := w.parseRow(row, labels, rec); ok {
+				rec.parsed()
+				rows = append(rows, parsed)
+			}
+		}
+	})
+
+	if parseErr != nil {
+		return nil, parseErr
+	}
+	if tables == 0 {
+		return nil, fetcherr.New(fetcherr.SchemaDrift, "%s: no table with headers %v found", w.typ, w.cfg.Headers)
+	}
+	if len(rows) == 0 {
+		return nil, fetcherr.New(fetcherr.SchemaDrift, "%s: no rows parsed", w.typ)
+	}
+
+	return rows, nil
+}
+
+// Fetch retrieves the matching table rows from the configured article.
+func (w *WikiTableSource) Fetch(ctx context.Context) ([]models.Item, error) {
+	rec := &extractRecorder{}
+	defer w.store(rec)
+
+	doc, err := fetchWikiDocument(ctx, w.httpClient(), w.cfg.renderURL(), w.typ)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := w.parseDocument(doc, rec)
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(rows, func(i, j int) bool {
+		if w.cfg.SortColumn >= 0 {
+			return wikiTableSortKeyGreater(rows[i].sortKey, rows[j].sortKey)
+		}
+		return rows[i].item.Published.After(rows[j].item.Published)
+	})
+
+	if w.limit > 0 && len(rows) > w.limit {
+		rows = rows[:w.limit]
+	}
+
+	items := make([]models.Item, len(rows))
+	for i, row := range rows {
+		items[i] = row.item
+	}
+	return items, nil
+}
+
+// Name returns the source name.
+func (w *WikiTableSource) Name() string { return w.name }
+
+// Type returns the source type.
+func (w *WikiTableSource) Type() string { return w.typ }
+
+// wikiTableSortKeyGreater orders cells numerically when both hold a number
+// (ignoring symbols such as ★ or %), and lexically otherwise.
+func wikiTableSortKeyGreater(a, b string) bool {
+	na, errA := strconv.ParseFloat(wikiTableNumericPart(a), 64)
+	nb, errB := strconv.ParseFloat(wikiTableNumericPart(b), 64)
+	if errA == nil && errB == nil {
+		return na > nb
+	}
+	return a > b
+}
+
+func wikiTableNumericPart(value string) string {
+	return strings.Map(func(r rune) rune {
+		if (r >= '0' && r <= '9') || r == '.' || r == '-' {
+			return r
+		}
+		return -1
+	}, value)
+}
@@ -0,0 +1,318 @@
+package source
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	neturl "net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/hashicorp/go-retryablehttp"
+	"github.com/mmcdole/gofeed"
+	ext "github.com/mmcdole/gofeed/extensions"
+	"github.com/ppowo/feedlet/internal/models"
+	"github.com/ppowo/feedlet/internal/source/httpclient"
+)
+
+const (
+	youtubeBaseURL         = "https://www.youtube.com"
+	youtubeFeedURL         = "https://www.youtube.com/feeds/videos.xml"
+	youtubeExcerptLength   = 300
+	defaultYouTubeShortsRe = `(?i)(/shorts/|#shorts\b)`
+)
+
+var (
+	youtubeChannelIDRe = regexp.MustCompile(`^UC[0-9A-Za-z_-]{22}$`)
+	youtubePageIDRes   = []*regexp.Regexp{
+		regexp.MustCompile(`<link rel="canonical" href="https://www\.youtube\.com/channel/(UC[0-9A-Za-z_-]{22})"`),
+		regexp.MustCompile(`<meta itemprop="(?:identifier|channelId)" content="(UC[0-9A-Za-z_-]{22})"`),
+		regexp.MustCompile(`"externalId":"(UC[0-9A-Za-z_-]{22})"`),
+		regexp.MustCompile(`"channelId":"(UC[0-9A-Za-z_-]{22})"`),
+	}
+)
+
+// YouTubeSource fetches videos of a channel or playlist via YouTube's Atom feed.
+//
+// The URL may be a channel handle (https://www.youtube.com/@name or just
+// @name), a channel URL or ID, a playlist URL, or the feed URL itself. Handles
+// and custom channel URLs are resolved to a channel ID once and cached.
+// Query parameters: shorts=false drops Shorts, shorts_pattern overrides the regular
+// expression matched against title and link to recognise them.
+type YouTubeSource struct {
+	name          string
+	url           string
+	excludeShorts bool
+	shortsPattern *regexp.Regexp
+
+	mu   sync.Mutex
+	feed *FeedSource
+
+	clientHolder
+}
+
+func init() {
+	Register(TypeInfo{
+		Type:        "youtube",
+		Description: "Latest videos of a YouTube channel or playlist",
+		Factory: func(cfg models.SourceConfig) (Source, error) {
+			return NewYouTubeSource(cfg.Name, cfg.URL, cfg.Options)
+		},
+		Params: []Param{
+			{Name: "url", Required: true, Description: "Channel handle, channel URL or ID, or playlist URL"},
+			{Name: "shorts", Kind: models.OptionBool, Default: "true", Description: "Include Shorts; false hides them"},
+			{Name: "shorts_pattern", Description: "Regexp that recognises Shorts"},
+		},
+		Capabilities: Capabilities{Push: true},
+	})
+}
+
+// NewYouTubeSource creates a new YouTube channel or playlist source.
+func NewYouTubeSource(name, rawURL string, options models.Options) (*YouTubeSource, error) {
+	y := &YouTubeSource{
+		name: name,
+		url:  strings.TrimSpace(rawURL),
+	}
+
+	pattern := defaultYouTubeShortsRe
+	if parsed, err := neturl.Parse(y.url); err == nil {
+		q := newSettings(options, parsed.Query())
+		y.excludeShorts = !q.Bool("shorts", true)
+		if custom := strings.TrimSpace(q.Get("shorts_pattern")); custom != "" {
+			pattern = custom
+		}
+	}
+
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("youtube: invalid shorts pattern %q: %w", pattern, err)
+	}
+	y.shortsPattern = re
+
+	return y, nil
+}
+
+// Fetch retrieves the latest videos from the resolved feed.
+func (y *YouTubeSource) Fetch(ctx context.Context) ([]models.Item, error) {
+	feedSource, err := y.feedSource(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	feed, err := feedSource.fetchFeed(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return y.feedItems(feed), nil
+}
+
+// PushHub returns the WebSub hub advertised by the channel feed, if any.
+func (y *YouTubeSource) PushHub() (hub, topic string) {
+	y.mu.Lock()
+	feed := y.feed
+	y.mu.Unlock()
+
+	if feed == nil {
+		return "", ""
+	}
+	return feed.PushHub()
+}
+
+// ParsePush parses a feed update delivered by the WebSub hub.
+func (y *YouTubeSource) ParsePush(body []byte) ([]models.Item, error) {
+	feed, err := gofeed.NewParser().Parse(bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse pushed feed %s: %w", y.name, err)
+	}
+	return y.feedItems(feed), nil
+}
+
+func (y *YouTubeSource) feedItems(feed *gofeed.Feed) []models.Item {
+	items := make([]models.Item, 0, len(feed.Items))
+	for _, entry := range feed.Items {
+		published := entry.PublishedParsed
+		if published == nil {
+			published = entry.UpdatedParsed
+		}
+		if published == nil {
+			continue
+		}
+
+		if y.excludeShorts && (y.shortsPattern.MatchString(entry.Title) || y.shortsPattern.MatchString(entry.Link)) {
+			continue
+		}
+
+		var author string
+		if entry.Author != nil {
+			author = entry.Author.Name
+		}
+
+		media := youtubeMediaGroup(entry)
+		description := media.childValue("description")
+
+		items = append(items, models.Item{
+			Title:       entry.Title,
+			Link:        entry.Link,
+			Description: truncateText(description, youtubeExcerptLength),
+			Content:     description,
+			Author:      author,
+			Published:   *published,
+			SourceName:  y.name,
+			SourceType:  "youtube",
+			Thumbnail:   media.thumbnail(),
+			Views:       media.views(),
+		})
+	}
+
+	return items
+}
+
+// Name returns the source name.
+func (y *YouTubeSource) Name() string {
+	return y.name
+}
+
+// Type returns the source type.
+func (y *YouTubeSource) Type() string {
+	return "youtube"
+}
+
+func (y *YouTubeSource) feedSource(ctx context.Context) (*FeedSource, error) {
+	y.mu.Lock()
+	defer y.mu.Unlock()
+
+	if y.feed != nil {
+		return y.feed, nil
+	}
+
+	feedURL, err := y.resolveFeedURL(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	y.feed = NewFeedSource(y.name, feedURL, "youtube", false)
+	y.feed.SetHTTPClient(y.client)
+	return y.feed, nil
+}
+
+func (y *YouTubeSource) resolveFeedURL(ctx context.Context) (string, error) {
+	raw := y.url
+	switch {
+	case youtubeChannelIDRe.MatchString(raw):
+		return youtubeFeedURL + "?channel_id=" + raw, nil
+	case strings.HasPrefix(raw, "@"):
+		raw = youtubeBaseURL + "/" + raw
+	case strings.HasPrefix(raw, "PL") && !strings.Contains(raw, "/"):
+		return youtubeFeedURL + "?playlist_id=" + neturl.QueryEscape(raw), nil
+	}
+
+	parsed, err := neturl.Parse(raw)
+	if err != nil {
+		return "", fmt.Errorf("youtube: invalid URL %q: %w", y.url, err)
+	}
+	q := parsed.Query()
+
+	if strings.HasPrefix(parsed.Path, "/feeds/videos.xml") {
+		feedQuery := neturl.Values{}
+		for _, key := range []string{"channel_id", "playlist_id", "user"} {
+			if value := q.Get(key); value != "" {
+				feedQuery.Set(key, value)
+			}
+		}
+		return youtubeFeedURL + "?" + feedQuery.Encode(), nil
+	}
+
+	if list := q.Get("list"); list != "" {
+		return youtubeFeedURL + "?playlist_id=" + neturl.QueryEscape(list), nil
+	}
+
+	segments := strings.FieldsFunc(parsed.Path, func(r rune) bool { return r == '/' })
+	if len(segments) >= 2 && segments[0] == "channel" && youtubeChannelIDRe.MatchString(segments[1]) {
+		return youtubeFeedURL + "?channel_id=" + segments[1], nil
+	}
+	if len(segments) >= 2 && segments[0] == "user" {
+		return youtubeFeedURL + "?user=" + neturl.QueryEscape(segments[1]), nil
+	}
+	if len(segments) == 0 {
+		return "", fmt.Errorf("youtube: URL %q does not name a channel or playlist", y.url)
+	}
+
+	pageURL := youtubeBaseURL + "/" + strings.Join(segments, "/")
+	channelID, err := y.lookupChannelID(ctx, pageURL)
+	if err != nil {
+		return "", err
+	}
+	return youtubeFeedURL + "?channel_id=" + channelID, nil
+}
+
+// lookupChannelID loads a channel page (handle or custom URL) and extracts the
+// canonical UC... channel ID from its markup.
+func (y *YouTubeSource) lookupChannelID(ctx context.Context, pageURL string) (string, error) {
+	req, err := retryablehttp.NewRequestWithContext(ctx, http.MethodGet, pageURL, nil)
+	if err != nil {
+		return "", fmt.Errorf("youtube: failed to create request: %w", err)
+	}
+	req.Header.Set("User-Agent", httpclient.RandomUserAgent())
+	req.Header.Set("Accept", "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8")
+	// Skip the EU cookie consent interstitial.
+	req.Header.Set("Cookie", "CONSENT=YES+cb; SOCS=CAI")
+
+	resp, err := y.httpClient().Do(req)
+	if err != nil {
+		return "", fmt.Errorf("youtube: failed to fetch channel page: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := httpclient.ReadBody(resp, httpclient.ExpectHTML)
+	if err != nil {
+		return "", fmt.Errorf("youtube: failed to fetch channel page %s: %w", pageURL, err)
+	}
+
+	for _, re := range youtubePageIDRes {
+		if match := re.FindSubmatch(body); match != nil {
+			return string(match[1]), nil
+		}
+	}
+
+	return "", fmt.Errorf("youtube: channel ID not found on %s", pageURL)
+}
+
+// youtubeMedia wraps the media:group extension of a YouTube feed entry.
+type youtubeMedia map[string][]ext.Extension
+
+func youtubeMediaGroup(entry *gofeed.Item) youtubeMedia {
+	groups := entry.Extensions["media"]["group"]
+	if len(groups) == 0 {
+		return nil
+	}
+	return youtubeMedia(groups[0].Children)
+}
+
+func (m youtubeMedia) childValue(name string) string {
+	if children := m[name]; len(children) > 0 {
+		return strings.TrimSpace(children[0].Value)
+	}
+	return ""
+}
+
+func (m youtubeMedia) thumbnail() string {
+	if thumbs := m["thumbnail"]; len(thumbs) > 0 {
+		return thumbs[0].Attrs["url"]
+	}
+	return ""
+}
+
+func (m youtubeMedia) views() int64 {
+	for _, community := range m["community"] {
+		for _, stats := range community.Children["statistics"] {
+			if views, err := strconv.ParseInt(stats.Attrs["views"], 10, 64); err == nil {
+				return views
+			}
+		}
+	}
+	return 0
+}
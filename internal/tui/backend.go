@@ -0,0 +1,185 @@
+package tui
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/ppowo/feedlet/internal/fetcher"
+	"github.com/ppowo/feedlet/internal/models"
+	"github.com/ppowo/feedlet/internal/server"
+)
+
+// Backend supplies the dashboard content and tells when it changes.
+type Backend interface {
+	// Page returns the current dashboard content.
+	Page(ctx context.Context) (server.Page, error)
+
+	// Watch signals on changed whenever the content may have changed, until
+	// ctx is done.
+	Watch(ctx context.Context, changed chan<- struct{})
+
+	// Describe names the backend for the status bar.
+	Describe() string
+}
+
+// notify signals on changed without blocking; one pending signal is enough.
+func notify(changed chan<- struct{}) {
+	select {
+	case changed <- struct{}{}:
+	default:
+	}
+}
+
+// Local shows an in-process fetcher.
+type Local struct {
+	fetcher *fetcher.Fetcher
+	configs []models.SourceConfig
+}
+
+// NewLocal shows the feed of f, laid out for the sources in configs.
+func NewLocal(f *fetcher.Fetcher, configs []models.SourceConfig) *Local {
+	return &Local{fetcher: f, configs: configs}
+}
+
+func (l *Local) Page(ctx context.Context) (server.Page, error) {
+	return server.BuildPage(l.fetcher.GetFeed(), l.configs, server.DefaultLimit), nil
+}
+
+func (l *Local) Watch(ctx context.Context, changed chan<- struct{}) {
+	updates, err := l.fetcher.Subscribe()
+	if err != nil {
+		return
+	}
+	defer l.fetcher.Unsubscribe(updates)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-updates:
+			notify(changed)
+		}
+	}
+}
+
+func (l *Local) Describe() string {
+	return "local fetcher"
+}
+
+const (
+	remoteTimeout      = 10 * time.Second
+	reconnectDelay     = 2 * time.Second
+	maxReconnectDelay  = 30 * time.Second
+	remoteFeedPath     = "/api/v1/feed"
+	remoteEventsPath   = "/events"
+	remoteEventsUpdate = "data: update"
+)
+
+// Remote shows a running feedlet server, reading the dashboard from its
+// JSON API and following its /events stream.
+type Remote struct {
+	baseURL   string
+	client    *http.Client
+	connected atomic.Bool
+}
+
+// NewRemote attaches to the server at baseURL, e.g. http://localhost:3737.
+func NewRemote(baseURL string) *Remote {
+	return &Remote{
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		client:  &http.Client{},
+	}
+}
+
+func (r *Remote) Page(ctx context.Context) (server.Page, error) {
+	ctx, cancel := context.WithTimeout(ctx, remoteTimeout)
+	defer cancel()
+
+	var page server.Page
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, r.baseURL+remoteFeedPath, nil)
+	if err != nil {
+		return page, err
+	}
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return page, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return page, fmt.Errorf("%s: http %s", remoteFeedPath, resp.Status)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&page); err != nil {
+		return page, fmt.Errorf("%s: %w", remoteFeedPath, err)
+	}
+	return page, nil
+}
+
+// Watch follows the event stream, reconnecting with backoff when it drops.
+// Each (re)connection counts as a change, since updates may have been
+// missed in between.
+func (r *Remote) Watch(ctx context.Context, changed chan<- struct{}) {
+	delay := reconnectDelay
+	for {
+		if r.follow(ctx, changed) {
+			delay = reconnectDelay
+		}
+		if r.connected.Swap(false) {
+			notify(changed)
+		}
+		if ctx.Err() != nil {
+			return
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+		delay = min(delay*2, maxReconnectDelay)
+	}
+}
+
+// follow reads the event stream until it ends. It reports whether the
+// stream was established.
+func (r *Remote) follow(ctx context.Context, changed chan<- struct{}) bool {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, r.baseURL+remoteEventsPath, nil)
+	if err != nil {
+		return false
+	}
+	req.Header.Set("Accept", "text/event-stream")
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return false
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return false
+	}
+
+	r.connected.Store(true)
+	notify(changed)
+
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		if strings.TrimSpace(scanner.Text()) == remoteEventsUpdate {
+			notify(changed)
+		}
+	}
+	return true
+}
+
+func (r *Remote) Describe() string {
+	if !r.connected.Load() {
+		return r.baseURL + " (disconnected)"
+	}
+	return r.baseURL
+}
@@ -0,0 +1,302 @@
+package tui
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/dustin/go-humanize"
+
+	"github.com/ppowo/feedlet/internal/models"
+	"github.com/ppowo/feedlet/internal/server"
+)
+
+// minPaneWidth is the narrowest a pane gets before the grid drops a column.
+const minPaneWidth = 40
+
+const (
+	styleReset   = "\x1b[0m"
+	styleBold    = "\x1b[1m"
+	styleDim     = "\x1b[2m"
+	styleReverse = "\x1b[7m"
+	styleRed     = "\x1b[31m"
+	styleYellow  = "\x1b[33m"
+)
+
+const helpText = "q quit  ←↓↑→/hjkl move  ⏎ open  p print link  r reload"
+
+// draw renders the whole screen. Lines are overwritten in place rather than
+// cleared first, which keeps redraws from flickering.
+func (v *view) draw(w io.Writer) {
+	if v.width <= 0 || v.height <= 0 {
+		return
+	}
+
+	lines, selStart, selLine := v.layout()
+
+	viewport := max(v.height-1, 1)
+	if selStart < v.scroll {
+		v.scroll = selStart
+	}
+	if selLine >= v.scroll+viewport {
+		v.scroll = selLine - viewport + 1
+	}
+	v.scroll = max(min(v.scroll, len(lines)-viewport), 0)
+
+	fmt.Fprint(w, "\x1b[H")
+	for i := 0; i < viewport; i++ {
+		if n := v.scroll + i; n < len(lines) {
+			fmt.Fprint(w, lines[n])
+		}
+		fmt.Fprint(w, styleReset+"\x1b[K\r\n")
+	}
+	fmt.Fprint(w, v.statusBar()+styleReset+"\x1b[K")
+}
+
+// layout renders the panes in a grid. It returns the lines, the first line
+// of the selected pane's row and the line of the selection.
+func (v *view) layout() (lines []string, selStart, selLine int) {
+	tiles := v.page.Sources
+	if len(tiles) == 0 {
+		text := "No sources"
+		switch {
+		case v.loadErr != nil:
+			text = "Failed to load: " + v.loadErr.Error()
+		case v.loading:
+			text = "Loading..."
+		}
+		return []string{" " + fit(text, v.width-1)}, 0, 0
+	}
+
+	columns := min(max(v.width/minPaneWidth, 1), len(tiles))
+	v.columns = columns
+	paneWidth := v.width / columns
+
+	for row := 0; row*columns < len(tiles); row++ {
+		first := row * columns
+		last := min(first+columns, len(tiles))
+
+		panes := make([][]string, 0, columns)
+		height := 0
+		for i := first; i < last; i++ {
+			item := -1
+			if i == v.pane {
+				item = v.item
+			}
+			pane := renderPane(tiles[i], paneWidth, i == v.pane, item)
+			panes = append(panes, pane)
+			height = max(height, len(pane))
+		}
+
+		if v.pane >= first && v.pane < last {
+			selStart = len(lines)
+			selLine = selStart
+			if v.item >= 0 {
+				selLine += 1 + v.item
+			}
+		}
+
+		blank := strings.Repeat(" ", paneWidth)
+		for n := 0; n < height; n++ {
+			var line strings.Builder
+			for _, pane := range panes {
+				if n < len(pane) {
+					line.WriteString(pane[n])
+				} else {
+					line.WriteString(blank)
+				}
+			}
+			lines = append(lines, line.String())
+		}
+		lines = append(lines, "")
+	}
+	return lines, selStart, selLine
+}
+
+// renderPane renders a tile as lines exactly width cells wide. selectedItem
+// is the highlighted item, -1 for none.
+func renderPane(tile server.Tile, width int, selected bool, selectedItem int) []string {
+	inner := width - 1 // Gap between panes
+
+	status := tile.StatusText
+	if tile.Degraded {
+		status += " · degraded"
+	}
+	if tile.NSFW {
+		status += " · nsfw"
+	}
+	nameWidth := max(inner-cellWidth(status)-3, 1)
+	header := " " + fit(tile.Name, nameWidth) + " " + fit(status, inner-nameWidth-2)
+	if selected {
+		header = styleReverse + styleBold + header + styleReset
+	} else {
+		header = styleBold + header + styleReset
+	}
+	lines := []string{header + " "}
+
+	if len(tile.Items) == 0 {
+		text, style := tile.EmptyText, styleDim
+		if tile.Error != "" {
+			icon := tile.ErrorIcon
+			if icon == "" {
+				icon = "⚠️"
+			}
+			text, style = fmt.Sprintf("%s %s: %s", icon, tile.ErrorLabel, tile.Error), styleRed
+		}
+		return append(lines, style+"  "+fit(text, inner-2)+styleReset+" ")
+	}
+
+	for i, item := range tile.Items {
+		meta := itemMeta(item)
+		metaWidth := cellWidth(meta)
+		titleWidth := inner - 2 - metaWidth - 1
+		if titleWidth < inner/2 {
+			meta, metaWidth = "", 0
+			titleWidth = inner - 2
+		}
+
+		marker := "  "
+		if i == selectedItem {
+			marker = styleYellow + "▶ " + styleReset
+		}
+		title := fit(item.Title, titleWidth)
+		if i == selectedItem {
+			title = styleReverse + title + styleReset
+		}
+		line := marker + title
+		if metaWidth > 0 {
+			line += " " + styleDim + meta + styleReset
+		}
+		lines = append(lines, line+" ")
+	}
+
+	if tile.Error != "" {
+		icon := tile.ErrorIcon
+		if icon == "" {
+			icon = "⚠️"
+		}
+		lines = append(lines, styleRed+"  "+fit(icon+" "+tile.ErrorLabel, inner-2)+styleReset+" ")
+	}
+	return lines
+}
+
+// itemMeta is the dimmed detail after an item's title, e.g. "412↑ 37c 5h".
+func itemMeta(item models.Item) string {
+	var parts []string
+	if item.Score > 0 {
+		parts = append(parts, shortCount(item.Score)+"↑")
+	}
+	if item.Comments > 0 {
+		parts = append(parts, shortCount(item.Comments)+"c")
+	}
+	if !item.Published.IsZero() {
+		parts = append(parts, shortAge(time.Since(item.Published)))
+	}
+	return strings.Join(parts, " ")
+}
+
+// shortCount formats a count in at most four characters, e.g. "1.2k".
+func shortCount(n int) string {
+	switch {
+	case n < 1000:
+		return fmt.Sprint(n)
+	case n < 10_000:
+		return strings.TrimSuffix(fmt.Sprintf("%.1f", float64(n)/1000), ".0") + "k"
+	case n < 1_000_000:
+		return fmt.Sprintf("%dk", n/1000)
+	default:
+		return strings.TrimSuffix(fmt.Sprintf("%.1f", float64(n)/1_000_000), ".0") + "M"
+	}
+}
+
+// shortAge formats an age in its largest unit, e.g. "5h" or "3d".
+func shortAge(age time.Duration) string {
+	switch {
+	case age < time.Minute:
+		return "now"
+	case age < time.Hour:
+		return fmt.Sprintf("%dm", int(age.Minutes()))
+	case age < 24*time.Hour:
+		return fmt.Sprintf("%dh", int(age.Hours()))
+	case age < 365*24*time.Hour:
+		return fmt.Sprintf("%dd", int(age.Hours()/24))
+	default:
+		return fmt.Sprintf("%dy", int(age.Hours()/24/365))
+	}
+}
+
+func (v *view) statusBar() string {
+	left := " feedlet · " + v.backend.Describe()
+	if !v.loaded.IsZero() {
+		left += " · " + fmt.Sprintf("%d sources", len(v.page.Sources)) + " · updated " + humanize.Time(v.page.UpdatedAt)
+	}
+	if v.loading {
+		left += " · loading"
+	}
+
+	right := helpText
+	switch {
+	case v.message != "":
+		right = v.message
+	case v.loadErr != nil && !v.loaded.IsZero():
+		right = "reload failed: " + v.loadErr.Error()
+	}
+
+	leftWidth := min(cellWidth(left), max(v.width-cellWidth(right)-1, v.width/3))
+	return styleReverse + fit(left, leftWidth) + " " + fitRight(right, v.width-leftWidth-1)
+}
+
+// fit truncates or pads s to exactly width terminal cells.
+func fit(s string, width int) string {
+	if width <= 0 {
+		return ""
+	}
+	s = strings.Join(strings.Fields(s), " ")
+	if used := cellWidth(s); used <= width {
+		return s + strings.Repeat(" ", width-used)
+	}
+
+	var b strings.Builder
+	used := 0
+	for _, r := range s {
+		w := runeWidth(r)
+		if used+w > width-1 {
+			break
+		}
+		b.WriteRune(r)
+		used += w
+	}
+	b.WriteString("…")
+	return b.String() + strings.Repeat(" ", width-used-1)
+}
+
+// fitRight is fit with the padding on the left.
+func fitRight(s string, width int) string {
+	fitted := strings.TrimRight(fit(s, width), " ")
+	return strings.Repeat(" ", max(width-cellWidth(fitted), 0)) + fitted
+}
+
+func cellWidth(s string) int {
+	n := 0
+	for _, r := range s {
+		n += runeWidth(r)
+	}
+	return n
+}
+
+// runeWidth approximates the number of terminal cells r takes: two for
+// wide East Asian characters and emoji, none for combining marks.
+func runeWidth(r rune) int {
+	switch {
+	case r == 0xFE0F || r == 0x200D || unicode.Is(unicode.Mn, r) || unicode.IsControl(r):
+		return 0
+	case r >= 0x1100 && r <= 0x115F, r >= 0x2E80 && r <= 0xA4CF, r >= 0xAC00 && r <= 0xD7A3,
+		r >= 0xF900 && r <= 0xFAFF, r >= 0xFE30 && r <= 0xFE4F, r >= 0xFF00 && r <= 0xFF60,
+		r >= 0xFFE0 && r <= 0xFFE6, r >= 0x1F300 && r <= 0x1FAFF, r >= 0x20000 && r <= 0x3FFFD:
+		return 2
+	default:
+		return 1
+	}
+}
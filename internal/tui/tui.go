@@ -0,0 +1,385 @@
+// Package tui draws the dashboard on a terminal, for when feedlet runs on a
+// box reached over SSH. Sources are panes in a grid; the keyboard moves
+// between panes and items and opens links.
+package tui
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"golang.org/x/term"
+
+	"github.com/ppowo/feedlet/internal/server"
+)
+
+// redrawInterval refreshes relative times and picks up terminal resizes.
+const redrawInterval = time.Second
+
+type keyCode int
+
+const (
+	keyRune keyCode = iota
+	keyUp
+	keyDown
+	keyLeft
+	keyRight
+	keyTab
+	keyBackTab
+	keyEnter
+	keyPageUp
+	keyPageDown
+	keyHome
+	keyEnd
+	keyQuit
+)
+
+type key struct {
+	code keyCode
+	r    rune
+}
+
+// escapeKeys maps the escape sequences of special keys to their codes.
+var escapeKeys = map[string]keyCode{
+	"\x1b[A": keyUp, "\x1bOA": keyUp,
+	"\x1b[B": keyDown, "\x1bOB": keyDown,
+	"\x1b[C": keyRight, "\x1bOC": keyRight,
+	"\x1b[D": keyLeft, "\x1bOD": keyLeft,
+	"\x1b[Z":  keyBackTab,
+	"\x1b[5~": keyPageUp,
+	"\x1b[6~": keyPageDown,
+	"\x1b[H":  keyHome, "\x1b[1~": keyHome, "\x1bOH": keyHome,
+	"\x1b[F": keyEnd, "\x1b[4~": keyEnd, "\x1bOF": keyEnd,
+}
+
+type pageResult struct {
+	page server.Page
+	err  error
+}
+
+// Run draws the dashboard of backend until the user quits or ctx is done.
+func Run(ctx context.Context, backend Backend) error {
+	in, out := int(os.Stdin.Fd()), int(os.Stdout.Fd())
+	if !term.IsTerminal(in) || !term.IsTerminal(out) {
+		return errors.New("tui needs an interactive terminal")
+	}
+
+	saved, err := term.MakeRaw(in)
+	if err != nil {
+		return err
+	}
+	defer term.Restore(in, saved)
+
+	// Alternate screen, hidden cursor
+	fmt.Fprint(os.Stdout, "\x1b[?1049h\x1b[?25l")
+	defer fmt.Fprint(os.Stdout, "\x1b[?25h\x1b[?1049l")
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	keys := make(chan key, 16)
+	go readKeys(os.Stdin, keys)
+
+	changed := make(chan struct{}, 1)
+	go backend.Watch(ctx, changed)
+
+	pages := make(chan pageResult, 1)
+	load := func() {
+		go func() {
+			page, err := backend.Page(ctx)
+			select {
+			case pages <- pageResult{page: page, err: err}:
+			case <-ctx.Done():
+			}
+		}()
+	}
+	load()
+
+	v := &view{backend: backend, item: -1, loading: true}
+	ticker := time.NewTicker(redrawInterval)
+	defer ticker.Stop()
+
+	screen := bufio.NewWriter(os.Stdout)
+	for {
+		if width, height, err := term.GetSize(out); err == nil {
+			v.width, v.height = width, height
+		}
+		v.draw(screen)
+		screen.Flush()
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case k, ok := <-keys:
+			if !ok || k.code == keyQuit {
+				return nil
+			}
+			if v.handle(k) {
+				load()
+			}
+		case <-changed:
+			load()
+		case res := <-pages:
+			v.setPage(res)
+		case <-ticker.C:
+		}
+	}
+}
+
+// readKeys decodes key presses from r until it fails.
+func readKeys(r io.Reader, keys chan<- key) {
+	defer close(keys)
+
+	buf := make([]byte, 64)
+	for {
+		n, err := r.Read(buf)
+		if err != nil {
+			return
+		}
+		input := buf[:n]
+		for len(input) > 0 {
+			k, size := decodeKey(input)
+			input = input[size:]
+			keys <- k
+		}
+	}
+}
+
+func decodeKey(input []byte) (key, int) {
+	if input[0] == 0x1b {
+		for seq, code := range escapeKeys {
+			if strings.HasPrefix(string(input), seq) {
+				return key{code: code}, len(seq)
+			}
+		}
+		// A lone Esc, or a sequence we don't know: swallow it
+		if len(input) > 1 && (input[1] == '[' || input[1] == 'O') {
+			return key{code: keyRune}, len(input)
+		}
+		return key{code: keyRune}, 1
+	}
+
+	switch input[0] {
+	case 3, 4, 'q': // Ctrl-C, Ctrl-D, q
+		return key{code: keyQuit}, 1
+	case '\r', '\n':
+		return key{code: keyEnter}, 1
+	case '\t':
+		return key{code: keyTab}, 1
+	}
+
+	r, size := utf8.DecodeRune(input)
+	return key{code: keyRune, r: r}, size
+}
+
+// view is the state of the terminal UI.
+type view struct {
+	backend Backend
+	page    server.Page
+	loaded  time.Time
+	loading bool
+	loadErr error
+
+	pane    int // Selected pane
+	item    int // Selected item of the pane, -1 for the pane itself
+	scroll  int // First line shown
+	message string
+
+	width, height int
+	columns       int // Panes per row, as last drawn
+}
+
+// setPage shows a freshly loaded page, keeping the selection on the same
+// source and link.
+func (v *view) setPage(res pageResult) {
+	v.loading = false
+	v.loadErr = res.err
+	if res.err != nil {
+		return
+	}
+
+	var name, link string
+	if tile, ok := v.selectedTile(); ok {
+		name = tile.Name
+		if v.item >= 0 && v.item < len(tile.Items) {
+			link = tile.Items[v.item].Link
+		}
+	}
+
+	v.page = res.page
+	v.loaded = time.Now()
+	v.pane, v.item = 0, -1
+	if len(v.page.Sources) > 0 {
+		v.item = 0
+		if len(v.page.Sources[0].Items) == 0 {
+			v.item = -1
+		}
+	}
+
+	for i, tile := range v.page.Sources {
+		if tile.Name != name {
+			continue
+		}
+		v.pane, v.item = i, -1
+		if len(tile.Items) > 0 {
+			v.item = 0
+		}
+		for j, item := range tile.Items {
+			if item.Link == link {
+				v.item = j
+			}
+		}
+	}
+}
+
+func (v *view) selectedTile() (server.Tile, bool) {
+	if v.pane < 0 || v.pane >= len(v.page.Sources) {
+		return server.Tile{}, false
+	}
+	return v.page.Sources[v.pane], true
+}
+
+// selectedLink returns the link of the selected item, or the home page of
+// the selected source if it has no items or the item has no link.
+func (v *view) selectedLink() string {
+	tile, ok := v.selectedTile()
+	if !ok {
+		return ""
+	}
+	if v.item >= 0 && v.item < len(tile.Items) && tile.Items[v.item].Link != "" {
+		return tile.Items[v.item].Link
+	}
+	return tile.HomeURL
+}
+
+// handle applies a key press. It reports whether the page should be
+// reloaded.
+func (v *view) handle(k key) bool {
+	v.message = ""
+
+	code := k.code
+	if code == keyRune {
+		switch k.r {
+		case 'k':
+			code = keyUp
+		case 'j':
+			code = keyDown
+		case 'h':
+			code = keyLeft
+		case 'l':
+			code = keyRight
+		case 'g':
+			code = keyHome
+		case 'G':
+			code = keyEnd
+		case 'o':
+			code = keyEnter
+		case 'p':
+			if link := v.selectedLink(); link != "" {
+				v.message = link
+			}
+			return false
+		case 'r':
+			v.loading = true
+			return true
+		}
+	}
+
+	switch code {
+	case keyLeft, keyBackTab:
+		v.selectPane(v.pane - 1)
+	case keyRight, keyTab:
+		v.selectPane(v.pane + 1)
+	case keyUp:
+		v.moveItem(-1)
+	case keyDown:
+		v.moveItem(1)
+	case keyPageUp:
+		v.selectPane(v.pane - max(v.columns, 1))
+	case keyPageDown:
+		v.selectPane(v.pane + max(v.columns, 1))
+	case keyHome:
+		v.selectPane(0)
+	case keyEnd:
+		v.selectPane(len(v.page.Sources) - 1)
+	case keyEnter:
+		v.open()
+	}
+	return false
+}
+
+func (v *view) selectPane(pane int) {
+	if len(v.page.Sources) == 0 {
+		return
+	}
+	v.pane = min(max(pane, 0), len(v.page.Sources)-1)
+	v.item = -1
+	if len(v.page.Sources[v.pane].Items) > 0 {
+		v.item = 0
+	}
+}
+
+// moveItem moves the selection by delta items, continuing into the pane
+// above or below at the ends.
+func (v *view) moveItem(delta int) {
+	tile, ok := v.selectedTile()
+	if !ok {
+		return
+	}
+	columns := max(v.columns, 1)
+
+	next := v.item + delta
+	switch {
+	case next >= 0 && next < len(tile.Items):
+		v.item = next
+	case delta > 0 && v.pane+columns < len(v.page.Sources):
+		v.selectPane(v.pane + columns)
+	case delta < 0 && v.pane-columns >= 0:
+		v.selectPane(v.pane - columns)
+		if items := len(v.page.Sources[v.pane].Items); items > 0 {
+			v.item = items - 1
+		}
+	}
+}
+
+// open opens the selected link with $BROWSER, or shows it if that is not
+// set.
+func (v *view) open() {
+	link := v.selectedLink()
+	if link == "" {
+		return
+	}
+
+	browser := strings.Fields(strings.Split(os.Getenv("BROWSER"), ":")[0])
+	if len(browser) == 0 {
+		v.message = link
+		return
+	}
+
+	args := browser[1:]
+	substituted := false
+	for i, arg := range args {
+		if strings.Contains(arg, "%s") {
+			args[i] = strings.ReplaceAll(arg, "%s", link)
+			substituted = true
+		}
+	}
+	if !substituted {
+		args = append(args, link)
+	}
+
+	cmd := exec.Command(browser[0], args...)
+	if err := cmd.Start(); err != nil {
+		v.message = fmt.Sprintf("%s: %v (%s)", browser[0], err, link)
+		return
+	}
+	go cmd.Wait()
+	v.message = "Opened " + link
+}
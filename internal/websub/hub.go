@@ -0,0 +1,211 @@
+package websub
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	hubRequestTimeout = 15 * time.Second
+	hubDefaultLease   = 24 * time.Hour
+	hubMaxLease       = 10 * 24 * time.Hour
+)
+
+type hubSubscription struct {
+	secret    string
+	expiresAt time.Time
+}
+
+// LocalHub is a minimal in-process WebSub hub for development and testing.
+// It verifies subscriber intent, and distributes content either when
+// Publish is called or when a publisher POSTs hub.mode=publish with hub.url.
+// It keeps no state across restarts.
+type LocalHub struct {
+	selfURL string
+	client  *http.Client
+
+	mu   sync.Mutex
+	subs map[string]map[string]hubSubscription // topic -> callback -> subscription
+}
+
+// NewLocalHub creates a hub that advertises itself as selfURL.
+func NewLocalHub(selfURL string) *LocalHub {
+	return &LocalHub{
+		selfURL: selfURL,
+		client:  &http.Client{Timeout: hubRequestTimeout},
+		subs:    make(map[string]map[string]hubSubscription),
+	}
+}
+
+// ServeHTTP handles subscription and publish requests.
+func (h *LocalHub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", "POST")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "invalid form", http.StatusBadRequest)
+		return
+	}
+
+	switch mode := r.PostForm.Get("hub.mode"); mode {
+	case "subscribe", "unsubscribe":
+		callback := r.PostForm.Get("hub.callback")
+		topic := r.PostForm.Get("hub.topic")
+		if _, err := url.ParseRequestURI(callback); err != nil || topic == "" {
+			http.Error(w, "hub.callback and hub.topic are required", http.StatusBadRequest)
+			return
+		}
+
+		lease := hubDefaultLease
+		if seconds, err := strconv.Atoi(r.PostForm.Get("hub.lease_seconds")); err == nil && seconds > 0 {
+			lease = min(time.Duration(seconds)*time.Second, hubMaxLease)
+		}
+
+		w.WriteHeader(http.StatusAccepted)
+		go h.verify(mode, callback, topic, r.PostForm.Get("hub.secret"), lease)
+	case "publish":
+		topic := r.PostForm.Get("hub.url")
+		if topic == "" {
+			topic = r.PostForm.Get("hub.topic")
+		}
+		if topic == "" {
+			http.Error(w, "hub.url is required", http.StatusBadRequest)
+			return
+		}
+
+		w.WriteHeader(http.StatusAccepted)
+		go func() {
+			if err := h.fetchAndPublish(topic); err != nil {
+				slog.Warn("Local hub: publish failed", "topic", topic, "error", err)
+			}
+		}()
+	default:
+		http.Error(w, fmt.Sprintf("unsupported hub.mode %q", mode), http.StatusBadRequest)
+	}
+}
+
+// Publish distributes body to every subscriber of topic, signing it with each
+// subscription's secret.
+func (h *LocalHub) Publish(topic string, body []byte, contentType string) {
+	now := time.Now()
+
+	h.mu.Lock()
+	targets := make(map[string]hubSubscription, len(h.subs[topic]))
+	for callback, sub := range h.subs[topic] {
+		if now.After(sub.expiresAt) {
+			delete(h.subs[topic], callback)
+			continue
+		}
+		targets[callback] = sub
+	}
+	h.mu.Unlock()
+
+	for callback, sub := range targets {
+		req, err := http.NewRequest(http.MethodPost, callback, bytes.NewReader(body))
+		if err != nil {
+			slog.Warn("Local hub: invalid callback", "callback", callback, "error", err)
+			continue
+		}
+		req.Header.Set("Content-Type", contentType)
+		req.Header.Set("Link", fmt.Sprintf("<%s>; rel=\"hub\", <%s>; rel=\"self\"", h.selfURL, topic))
+		if sub.secret != "" {
+			req.Header.Set("X-Hub-Signature", Sign(sub.secret, body))
+		}
+
+		resp, err := h.client.Do(req)
+		if err != nil {
+			slog.Warn("Local hub: delivery failed", "callback", callback, "error", err)
+			continue
+		}
+		resp.Body.Close()
+		slog.Info("Local hub: delivered", "topic", topic, "callback", callback, "status", resp.StatusCode)
+	}
+}
+
+func (h *LocalHub) verify(mode, callback, topic, secret string, lease time.Duration) {
+	challengeBytes := make([]byte, 16)
+	if _, err := rand.Read(challengeBytes); err != nil {
+		slog.Error("Local hub: failed to create challenge", "error", err)
+		return
+	}
+	challenge := hex.EncodeToString(challengeBytes)
+
+	verifyURL, err := url.Parse(callback)
+	if err != nil {
+		return
+	}
+	q := verifyURL.Query()
+	q.Set("hub.mode", mode)
+	q.Set("hub.topic", topic)
+	q.Set("hub.challenge", challenge)
+	q.Set("hub.lease_seconds", strconv.Itoa(int(lease.Seconds())))
+	verifyURL.RawQuery = q.Encode()
+
+	ctx, cancel := context.WithTimeout(context.Background(), hubRequestTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, verifyURL.String(), nil)
+	if err != nil {
+		return
+	}
+	resp, err := h.client.Do(req)
+	if err != nil {
+		slog.Warn("Local hub: verification failed", "callback", callback, "error", err)
+		return
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	if resp.StatusCode/100 != 2 || strings.TrimSpace(string(body)) != challenge {
+		slog.Warn("Local hub: callback did not confirm", "callback", callback, "mode", mode, "topic", topic, "status", resp.StatusCode)
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if mode == "unsubscribe" {
+		delete(h.subs[topic], callback)
+		slog.Info("Local hub: unsubscribed", "callback", callback, "topic", topic)
+		return
+	}
+	if h.subs[topic] == nil {
+		h.subs[topic] = make(map[string]hubSubscription)
+	}
+	h.subs[topic][callback] = hubSubscription{secret: secret, expiresAt: time.Now().Add(lease)}
+	slog.Info("Local hub: subscribed", "callback", callback, "topic", topic, "lease", lease)
+}
+
+func (h *LocalHub) fetchAndPublish(topic string) error {
+	resp, err := h.client.Get(topic)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("http %d %s", resp.StatusCode, resp.Status)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxPushBodyBytes))
+	if err != nil {
+		return err
+	}
+
+	contentType := resp.Header.Get("Content-Type")
+	if contentType == "" {
+		contentType = "application/xml"
+	}
+	h.Publish(topic, body, contentType)
+	return nil
+}
@@ -0,0 +1,368 @@
+// Package websub implements the subscriber side of WebSub (PubSubHubbub):
+// subscribing to hubs advertised by feeds, verifying intent, validating
+// signed content distribution and renewing leases.
+package websub
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/go-retryablehttp"
+	"github.com/ppowo/feedlet/internal/source/httpclient"
+)
+
+const (
+	// CallbackPath is the path prefix the subscriber's handler is mounted on.
+	CallbackPath = "/websub/"
+
+	requestedLease     = 7 * 24 * time.Hour
+	defaultLease       = 24 * time.Hour
+	pendingTimeout     = 10 * time.Minute
+	minRenewLead       = time.Hour
+	renewCheckInterval = time.Minute
+	maxPushBodyBytes   = 10 << 20
+)
+
+type subscriptionState int
+
+const (
+	statePending subscriptionState = iota
+	stateActive
+	stateDenied
+)
+
+type subscription struct {
+	name        string
+	id          string
+	hub         string
+	topic       string
+	secret      string
+	state       subscriptionState
+	requested   bool // A subscribe request awaits the hub's verification
+	requestedAt time.Time
+	leaseExpiry time.Time
+	lease       time.Duration
+}
+
+// awaitingVerification reports whether a subscribe request was sent recently
+// enough that the hub may still verify it.
+func (sub *subscription) awaitingVerification(now time.Time) bool {
+	return sub.requested && now.Sub(sub.requestedAt) < pendingTimeout
+}
+
+// DeliverFunc receives validated content pushed for a source.
+type DeliverFunc func(name string, body []byte)
+
+// Subscriber manages WebSub subscriptions for sources and serves their
+// callback endpoint.
+type Subscriber struct {
+	callbackBase string
+	deliver      DeliverFunc
+
+	mu     sync.Mutex
+	byName map[string]*subscription
+	byID   map[string]*subscription
+}
+
+// NewSubscriber creates a subscriber whose callbacks live under
+// publicBaseURL + CallbackPath.
+func NewSubscriber(publicBaseURL string, deliver DeliverFunc) *Subscriber {
+	return &Subscriber{
+		callbackBase: strings.TrimRight(publicBaseURL, "/") + CallbackPath,
+		deliver:      deliver,
+		byName:       make(map[string]*subscription),
+		byID:         make(map[string]*subscription),
+	}
+}
+
+// Ensure subscribes the named source to topic at hub unless an equivalent
+// subscription is already active or awaiting verification.
+func (s *Subscriber) Ensure(ctx context.Context, name, hub, topic string) error {
+	s.mu.Lock()
+	sub, exists := s.byName[name]
+	if exists && sub.hub == hub && sub.topic == topic {
+		switch {
+		case sub.state == stateActive && time.Now().Before(sub.leaseExpiry):
+			s.mu.Unlock()
+			return nil
+		case sub.state == statePending && time.Since(sub.requestedAt) < pendingTimeout:
+			s.mu.Unlock()
+			return nil
+		}
+	}
+
+	if !exists {
+		sub = &subscription{
+			name: name,
+			id:   callbackID(),
+		}
+		s.byName[name] = sub
+		s.byID[sub.id] = sub
+	}
+	sub.hub = hub
+	sub.topic = topic
+	sub.secret = randomHex(32)
+	sub.state = statePending
+	sub.requested = true
+	sub.requestedAt = time.Now()
+	req := *sub
+	s.mu.Unlock()
+
+	return s.subscribe(ctx, req)
+}
+
+// Active reports whether the named source currently receives pushes, in
+// which case polling can be skipped.
+func (s *Subscriber) Active(name string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sub, ok := s.byName[name]
+	return ok && sub.state == stateActive && time.Now().Before(sub.leaseExpiry)
+}
+
+// Run renews leases shortly before they expire until ctx is cancelled. A lease
+// that cannot be renewed lapses, and the source falls back to polling.
+func (s *Subscriber) Run(ctx context.Context) {
+	ticker := time.NewTicker(renewCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		for _, sub := range s.renewalsDue(time.Now()) {
+			if err := s.subscribe(ctx, sub); err != nil {
+				slog.Warn("WebSub renewal failed", "source", sub.name, "error", err)
+			}
+		}
+	}
+}
+
+// renewalsDue marks the active subscriptions whose lease ends within the
+// renewal lead as requested and returns copies of them. Subscriptions with a
+// renewal awaiting verification are skipped until it is verified or times
+// out, so a slow hub is not asked again on every check.
+func (s *Subscriber) renewalsDue(now time.Time) []subscription {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	due := make([]subscription, 0)
+	for _, sub := range s.byName {
+		if sub.state != stateActive || sub.awaitingVerification(now) {
+			continue
+		}
+		lead := sub.lease / 10
+		if lead < minRenewLead {
+			lead = minRenewLead
+		}
+		if now.Add(lead).After(sub.leaseExpiry) {
+			sub.requested = true
+			sub.requestedAt = now
+			due = append(due, *sub)
+		}
+	}
+	return due
+}
+
+func (s *Subscriber) subscribe(ctx context.Context, sub subscription) error {
+	form := url.Values{}
+	form.Set("hub.mode", "subscribe")
+	form.Set("hub.topic", sub.topic)
+	form.Set("hub.callback", s.callbackBase+sub.id)
+	form.Set("hub.secret", sub.secret)
+	form.Set("hub.lease_seconds", strconv.Itoa(int(requestedLease.Seconds())))
+
+	req, err := retryablehttp.NewRequestWithContext(ctx, http.MethodPost, sub.hub, strings.NewReader(form.Encode()))
+	if err != nil {
+		return fmt.Errorf("websub: failed to create subscribe request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("User-Agent", httpclient.RandomUserAgent())
+
+	resp, err := httpclient.GetClient().Do(req)
+	if err != nil {
+		return fmt.Errorf("websub: subscribe to %s failed: %w", sub.hub, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusAccepted && resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("websub: hub %s rejected subscription: http %d %s", sub.hub, resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	slog.Info("WebSub subscription requested", "source", sub.name, "hub", sub.hub, "topic", sub.topic)
+	return nil
+}
+
+// ServeHTTP handles hub verification requests (GET) and content
+// distribution (POST) on CallbackPath + id.
+func (s *Subscriber) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	id := strings.TrimPrefix(r.URL.Path, CallbackPath)
+
+	s.mu.Lock()
+	sub, ok := s.byID[id]
+	s.mu.Unlock()
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		s.handleVerify(w, r, sub)
+	case http.MethodPost:
+		s.handleContent(w, r, sub)
+	default:
+		w.Header().Set("Allow", "GET, POST")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (s *Subscriber) handleVerify(w http.ResponseWriter, r *http.Request, sub *subscription) {
+	q := r.URL.Query()
+	mode := q.Get("hub.mode")
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if q.Get("hub.topic") != sub.topic {
+		http.NotFound(w, r)
+		return
+	}
+
+	switch mode {
+	case "subscribe":
+		// Only confirm subscriptions we asked for, so that nobody else can
+		// subscribe us or extend a lease we let go.
+		if sub.state == stateDenied || !sub.awaitingVerification(time.Now()) {
+			http.NotFound(w, r)
+			return
+		}
+		lease := defaultLease
+		if seconds, err := strconv.Atoi(q.Get("hub.lease_seconds")); err == nil && seconds > 0 {
+			lease = time.Duration(seconds) * time.Second
+		}
+		sub.state = stateActive
+		sub.requested = false
+		sub.lease = lease
+		sub.leaseExpiry = time.Now().Add(lease)
+		slog.Info("WebSub subscription active", "source", sub.name, "lease", lease)
+	case "unsubscribe":
+		// Subscriptions are only ever left to lapse, never cancelled, so an
+		// unsubscribe to verify was not ours to confirm.
+		http.NotFound(w, r)
+		return
+	case "denied":
+		sub.state = stateDenied
+		sub.requested = false
+		slog.Warn("WebSub subscription denied", "source", sub.name, "reason", q.Get("hub.reason"))
+		w.WriteHeader(http.StatusOK)
+		return
+	default:
+		http.Error(w, "unknown hub.mode", http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain")
+	io.WriteString(w, q.Get("hub.challenge"))
+}
+
+func (s *Subscriber) handleContent(w http.ResponseWriter, r *http.Request, sub *subscription) {
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxPushBodyBytes+1))
+	if err != nil {
+		http.Error(w, "failed to read body", http.StatusBadRequest)
+		return
+	}
+	if len(body) > maxPushBodyBytes {
+		http.Error(w, "body too large", http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	s.mu.Lock()
+	secret := sub.secret
+	active := sub.state == stateActive
+	s.mu.Unlock()
+
+	// Per the spec, content with a missing or wrong signature is acknowledged
+	// but ignored, so that the hub cannot learn which signatures are valid.
+	w.WriteHeader(http.StatusAccepted)
+	if !active {
+		return
+	}
+	if !ValidSignature(secret, r.Header.Get("X-Hub-Signature"), body) {
+		slog.Warn("WebSub push ignored: invalid signature", "source", sub.name)
+		return
+	}
+
+	s.deliver(sub.name, body)
+}
+
+// ValidSignature checks an X-Hub-Signature header ("sha256=<hex>", also
+// sha1/sha384/sha512) against the HMAC of body under secret.
+func ValidSignature(secret, header string, body []byte) bool {
+	method, signature, ok := strings.Cut(strings.TrimSpace(header), "=")
+	if !ok {
+		return false
+	}
+
+	var newHash func() hash.Hash
+	switch strings.ToLower(method) {
+	case "sha1":
+		newHash = sha1.New
+	case "sha256":
+		newHash = sha256.New
+	case "sha384":
+		newHash = sha512.New384
+	case "sha512":
+		newHash = sha512.New
+	default:
+		return false
+	}
+
+	expected, err := hex.DecodeString(signature)
+	if err != nil {
+		return false
+	}
+
+	mac := hmac.New(newHash, []byte(secret))
+	mac.Write(body)
+	return hmac.Equal(mac.Sum(nil), expected)
+}
+
+// Sign returns the X-Hub-Signature value for body under secret.
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func callbackID() string {
+	return randomHex(16)
+}
+
+func randomHex(n int) string {
+	buf := make([]byte, n)
+	if _, err := rand.Read(buf); err != nil {
+		panic(fmt.Sprintf("websub: crypto/rand failed: %v", err))
+	}
+	return hex.EncodeToString(buf)
+}
@@ -0,0 +1,231 @@
+package websub
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha512"
+	"encoding/hex"
+	"hash"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestValidSignature(t *testing.T) {
+	body := []byte("<feed/>")
+	tests := []struct {
+		name   string
+		header string
+		want   bool
+	}{
+		{"sha256", Sign("secret", body), true},
+		{"sha1", "sha1=" + hmacHex(sha1.New, "secret", body), true},
+		{"sha512", "sha512=" + hmacHex(sha512.New, "secret", body), true},
+		{"wrong secret", Sign("other", body), false},
+		{"upper-case method", "SHA256=" + strings.TrimPrefix(Sign("secret", body), "sha256="), true},
+		{"unknown method", "md5=" + strings.TrimPrefix(Sign("secret", body), "sha256="), false},
+		{"not hex", "sha256=zz", false},
+		{"no method", strings.TrimPrefix(Sign("secret", body), "sha256="), false},
+		{"empty", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ValidSignature("secret", tt.header, body); got != tt.want {
+				t.Errorf("ValidSignature(%q) = %v, want %v", tt.header, got, tt.want)
+			}
+		})
+	}
+	if ValidSignature("secret", Sign("secret", body), []byte("<feed>tampered</feed>")) {
+		t.Error("signature accepted for a different body")
+	}
+}
+
+func hmacHex(newHash func() hash.Hash, secret string, body []byte) string {
+	mac := hmac.New(newHash, []byte(secret))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+type pushed struct {
+	name string
+	body string
+}
+
+// startHub serves a LocalHub on /hub and a subscriber on its callback path,
+// and returns them with the hub URL and the channel pushes are delivered to.
+func startHub(t *testing.T) (*LocalHub, *Subscriber, string, chan pushed) {
+	t.Helper()
+	deliveries := make(chan pushed, 4)
+	mux := http.NewServeMux()
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	hub := NewLocalHub(srv.URL + "/hub")
+	sub := NewSubscriber(srv.URL, func(name string, body []byte) {
+		deliveries <- pushed{name, string(body)}
+	})
+	mux.Handle("/hub", hub)
+	mux.Handle(CallbackPath, sub)
+	return hub, sub, srv.URL + "/hub", deliveries
+}
+
+// waitSubscribed waits until the named source's subscription to topic is
+// active on both ends.
+func waitSubscribed(t *testing.T, hub *LocalHub, s *Subscriber, name, topic string) {
+	t.Helper()
+	subscribed := func() bool {
+		hub.mu.Lock()
+		defer hub.mu.Unlock()
+		return s.Active(name) && len(hub.subs[topic]) > 0
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for !subscribed() {
+		if time.Now().After(deadline) {
+			t.Fatalf("%s never became active", name)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// callbackURL returns the callback of the named source's subscription.
+func callbackURL(s *Subscriber, name string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.callbackBase + s.byName[name].id
+}
+
+func TestSubscribeHandshakeAndPush(t *testing.T) {
+	hub, sub, hubURL, deliveries := startHub(t)
+	const topic = "https://example.com/feed.xml"
+
+	if err := sub.Ensure(context.Background(), "blog", hubURL, topic); err != nil {
+		t.Fatalf("Ensure: %v", err)
+	}
+	waitSubscribed(t, hub, sub, "blog", topic)
+
+	hub.Publish(topic, []byte("<feed>new</feed>"), "application/atom+xml")
+	select {
+	case got := <-deliveries:
+		if got.name != "blog" || got.body != "<feed>new</feed>" {
+			t.Errorf("delivered %+v", got)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("push was not delivered")
+	}
+}
+
+func TestPushWithBadSignatureIsIgnored(t *testing.T) {
+	hub, sub, hubURL, deliveries := startHub(t)
+	const topic = "https://example.com/feed.xml"
+	if err := sub.Ensure(context.Background(), "blog", hubURL, topic); err != nil {
+		t.Fatalf("Ensure: %v", err)
+	}
+	waitSubscribed(t, hub, sub, "blog", topic)
+
+	for _, signature := range []string{"", Sign("guessed", []byte("<feed>forged</feed>"))} {
+		req, _ := http.NewRequest(http.MethodPost, callbackURL(sub, "blog"), strings.NewReader("<feed>forged</feed>"))
+		if signature != "" {
+			req.Header.Set("X-Hub-Signature", signature)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusAccepted {
+			t.Errorf("signature %q: status %d, want 202", signature, resp.StatusCode)
+		}
+	}
+
+	select {
+	case got := <-deliveries:
+		t.Errorf("forged push delivered: %+v", got)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestVerifyRejectsUnrequestedSubscribe(t *testing.T) {
+	hub, sub, hubURL, _ := startHub(t)
+	const topic = "https://example.com/feed.xml"
+	if err := sub.Ensure(context.Background(), "blog", hubURL, topic); err != nil {
+		t.Fatalf("Ensure: %v", err)
+	}
+	waitSubscribed(t, hub, sub, "blog", topic)
+
+	// The request was verified, so a second verification is unsolicited
+	verify, _ := url.Parse(callbackURL(sub, "blog"))
+	verify.RawQuery = url.Values{
+		"hub.mode":          {"subscribe"},
+		"hub.topic":         {topic},
+		"hub.challenge":     {"abc"},
+		"hub.lease_seconds": {"999999"},
+	}.Encode()
+	resp, err := http.Get(verify.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("unsolicited verification: status %d, want 404", resp.StatusCode)
+	}
+
+	sub.mu.Lock()
+	lease := sub.byName["blog"].lease
+	sub.mu.Unlock()
+	if lease == 999999*time.Second {
+		t.Error("unsolicited verification changed the lease")
+	}
+}
+
+func TestVerifyRejectsUnrequestedUnsubscribe(t *testing.T) {
+	hub, sub, hubURL, _ := startHub(t)
+	const topic = "https://example.com/feed.xml"
+	if err := sub.Ensure(context.Background(), "blog", hubURL, topic); err != nil {
+		t.Fatalf("Ensure: %v", err)
+	}
+	waitSubscribed(t, hub, sub, "blog", topic)
+
+	verify, _ := url.Parse(callbackURL(sub, "blog"))
+	verify.RawQuery = url.Values{
+		"hub.mode":      {"unsubscribe"},
+		"hub.topic":     {topic},
+		"hub.challenge": {"abc"},
+	}.Encode()
+	resp, err := http.Get(verify.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("unsolicited unsubscribe: status %d, want 404", resp.StatusCode)
+	}
+	if !sub.Active("blog") {
+		t.Error("unsolicited unsubscribe ended the subscription")
+	}
+}
+
+func TestRenewalsDueSkipsOutstandingRequests(t *testing.T) {
+	s := NewSubscriber("http://feedlet.test", nil)
+	now := time.Now()
+	s.byName["blog"] = &subscription{
+		name:        "blog",
+		id:          "1",
+		state:       stateActive,
+		lease:       24 * time.Hour,
+		leaseExpiry: now.Add(30 * time.Minute),
+	}
+
+	if due := s.renewalsDue(now); len(due) != 1 {
+		t.Fatalf("first check renews %d subscriptions, want 1", len(due))
+	}
+	if due := s.renewalsDue(now.Add(renewCheckInterval)); len(due) != 0 {
+		t.Errorf("renewal requested again while the first is outstanding")
+	}
+	if due := s.renewalsDue(now.Add(pendingTimeout)); len(due) != 1 {
+		t.Errorf("renewal not retried after the request timed out")
+	}
+}
@@ -4,11 +4,13 @@ package main
 
 import (
 	"fmt"
+	"net/http"
 	"os"
 	"os/exec"
 
 	"github.com/magefile/mage/mg"
 	"github.com/magefile/mage/sh"
+	"github.com/ppowo/feedlet/internal/websub"
 )
 
 // Install development tools
@@ -79,3 +81,19 @@ func Setup() error {
 
 	return InstallTools()
 }
+
+// Run a local WebSub hub for testing push subscriptions (FEEDLET_HUB_ADDR, default :7070)
+func Hub() error {
+	addr := os.Getenv("FEEDLET_HUB_ADDR")
+	if addr == "" {
+		addr = ":7070"
+	}
+	selfURL := "http://localhost" + addr
+	if addr[0] != ':' {
+		selfURL = "http://" + addr
+	}
+
+	fmt.Printf("Local WebSub hub listening on %s\n", addr)
+	fmt.Println("Publish with: curl -d hub.mode=publish -d hub.url=<feed url> " + selfURL)
+	return http.ListenAndServe(addr, websub.NewLocalHub(selfURL))
+}
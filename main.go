@@ -5,17 +5,21 @@ package main
 import (
 	"context"
 	_ "embed"
-	"log"
+	"fmt"
+	"log/slog"
 	"os"
 	"os/signal"
 	"sync"
 	"syscall"
 	"time"
 
+	"github.com/ppowo/feedlet/internal/cli"
 	"github.com/ppowo/feedlet/internal/config"
 	"github.com/ppowo/feedlet/internal/fetcher"
 	"github.com/ppowo/feedlet/internal/logging"
 	"github.com/ppowo/feedlet/internal/server"
+	"github.com/ppowo/feedlet/internal/source/httpclient"
+	"github.com/ppowo/feedlet/internal/websub"
 	"github.com/ppowo/feedlet/web"
 )
 
@@ -24,12 +28,22 @@ var (
 )
 
 func main() {
-	if err := logging.Setup(); err != nil {
-		log.Fatal(err)
+	if len(os.Args) > 1 {
+		os.Exit(cli.Run(os.Args[1:]))
 	}
 
 	// Load embedded configuration
 	cfg := config.GetConfig()
+	if err := logging.Setup(cfg.Logging, cfg.Sources); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	if err := config.Validate(cfg); err != nil {
+		fatal("Invalid configuration", err)
+	}
+	if err := httpclient.Configure(cfg.HTTPProfiles); err != nil {
+		fatal("Invalid HTTP profiles", err)
+	}
 
 	// Create fetcher with configuration
 	f := fetcher.NewFromConfigs(cfg.Sources, cfg.MinFetchInterval, cfg.MaxSubscribers)
@@ -38,6 +52,14 @@ func main() {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
+	// Receive WebSub pushes when feedlet is reachable from the outside
+	var subscriber *websub.Subscriber
+	if cfg.PublicBaseURL != "" {
+		subscriber = websub.NewSubscriber(cfg.PublicBaseURL, f.HandlePush)
+		f.SetPushSubscriber(subscriber)
+		go subscriber.Run(ctx)
+	}
+
 	// Give fetcher a moment to initialize
 	go f.Start(ctx)
 	time.Sleep(100 * time.Millisecond)
@@ -48,9 +70,13 @@ func main() {
 		port = 8080
 	}
 
-	srv, err := server.New(f, web.IndexTemplate, port, cfg.Sources, 4)
+	srv, err := server.New(f, web.IndexTemplate, port, cfg.Sources, server.DefaultLimit)
 	if err != nil {
-		log.Fatal(err)
+		fatal("Failed to create server", err)
+	}
+	srv.SetHealth(cfg.Health)
+	if subscriber != nil {
+		srv.Handle(websub.CallbackPath, subscriber)
 	}
 
 	// Handle graceful shutdown
@@ -60,14 +86,14 @@ func main() {
 	go func() {
 		<-sigChan
 		shutdownOnce.Do(func() {
-			log.Println("Shutting down...")
+			slog.Info("Shutting down")
 			cancel()
 
 			shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 5*time.Second)
 			defer shutdownCancel()
 
 			if err := srv.Shutdown(shutdownCtx); err != nil {
-				log.Printf("Server shutdown error: %v", err)
+				slog.Error("Server shutdown failed", "error", err)
 			}
 
 			done := make(chan struct{})
@@ -78,14 +104,19 @@ func main() {
 
 			select {
 			case <-done:
-				log.Println("Fetcher shutdown complete")
+				slog.Info("Fetcher shutdown complete")
 			case <-time.After(10 * time.Second):
-				log.Println("Fetcher shutdown timeout - proceeding anyway")
+				slog.Warn("Fetcher shutdown timed out, proceeding anyway")
 			}
 		})
 	}()
 
 	if err := srv.Start(); err != nil {
-		log.Fatal(err)
+		fatal("Server failed", err)
 	}
 }
+
+func fatal(msg string, err error) {
+	slog.Error(msg, "error", err)
+	os.Exit(1)
+}